@@ -0,0 +1,90 @@
+package engine
+
+import (
+	"reflect"
+)
+
+// Accessor marks a struct field that is backed by methods of the struct rather than stored in it.
+// The value of a field Foo of type Accessor is got with the GetFoo method and set with the SetFoo method,
+// which may have a pointer receiver. The type of the value is the result type of GetFoo:
+//
+//	type Order struct {
+//		Total engine.Accessor `tag:"total"`
+//		items []Item
+//	}
+//
+//	func (o *Order) GetTotal() int { ... }
+//	func (o *Order) SetTotal(total int) error { ... }
+//
+// SetFoo may return nothing or an error.
+type Accessor struct{}
+
+var (
+	accessorType = reflect.TypeOf(Accessor{})
+	errorType    = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// accessors returns the getter and the setter of the Accessor field of the struct type t
+// and the type of its value.
+func accessors(t reflect.Type, name string) (get func(reflect.Value) reflect.Value, put func(v, rv reflect.Value) error, typ reflect.Type, err error) {
+	p := reflect.PointerTo(t)
+
+	getter, ok := p.MethodByName("Get" + name)
+	if !ok || getter.Type.NumIn() != 1 || getter.Type.NumOut() != 1 {
+		return nil, nil, nil, ErrInvalidAccessor
+	}
+
+	setter, ok := p.MethodByName("Set" + name)
+	if !ok || setter.Type.NumIn() != 2 || setter.Type.In(1) != getter.Type.Out(0) ||
+		setter.Type.NumOut() > 1 || setter.Type.NumOut() == 1 && setter.Type.Out(0) != errorType {
+		return nil, nil, nil, ErrInvalidAccessor
+	}
+
+	get = func(v reflect.Value) reflect.Value {
+		return getter.Func.Call([]reflect.Value{pointerTo(v)})[0]
+	}
+	put = func(v, rv reflect.Value) error {
+		out := setter.Func.Call([]reflect.Value{pointerTo(v), rv})
+		if len(out) != 0 && !out[0].IsNil() {
+			return out[0].Interface().(error)
+		}
+		return nil
+	}
+	return get, put, getter.Type.Out(0), nil
+}
+
+// value returns the value of the field of the v. The value of a field that isn't stored in the v,
+// see Accessor and FieldDescriber, is got with its getter, a new value is returned
+// if the v is addressable to set it later.
+func (f *field[T]) value(v reflect.Value) reflect.Value {
+	if f.get == nil {
+		return v.Field(f.index)
+	}
+
+	rv := f.get(v)
+	if v.CanAddr() {
+		// Decoding, the value must be settable.
+		p := reflect.New(f.typ)
+		p.Elem().Set(rv)
+		rv = p.Elem()
+	}
+	return rv
+}
+
+// set passes the value of a field that isn't stored in the v to its setter, it does nothing for other fields.
+func (f *field[T]) set(v, rv reflect.Value) error {
+	if f.put == nil {
+		return nil
+	}
+	return f.put(v, rv)
+}
+
+// pointerTo returns a pointer to the value v, or to its copy if it isn't addressable.
+func pointerTo(v reflect.Value) reflect.Value {
+	if v.CanAddr() {
+		return v.Addr()
+	}
+	p := reflect.New(v.Type())
+	p.Elem().Set(v)
+	return p
+}
@@ -0,0 +1,53 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+)
+
+var errNegative = errors.New("negative total")
+
+type order struct {
+	ID    string
+	Total Accessor
+	Note  string
+	cents int
+}
+
+func (o *order) GetTotal() int {
+	return o.cents / 100
+}
+
+func (o *order) SetTotal(total int) error {
+	if total < 0 {
+		return errNegative
+	}
+	o.cents = total * 100
+	return nil
+}
+
+type noSetter struct {
+	Total Accessor
+}
+
+func (noSetter) GetTotal() int {
+	return 0
+}
+
+func TestAccessor(t *testing.T) {
+	e := newTestEngine(nil)
+
+	b, err := e.Marshal(order{ID: "a", Note: "n", cents: 400})
+	equal(t, nil, err)
+	equal(t, "a,4,n", string(b))
+
+	var got order
+	equal(t, nil, e.Unmarshal([]byte("b,7,m"), &got))
+	equal(t, order{ID: "b", Note: "m", cents: 700}, got)
+
+	err = e.Unmarshal([]byte("b,-1,m"), &got)
+	equal(t, true, errors.Is(err, errNegative))
+
+	_, err = e.Marshal(noSetter{})
+	equal(t, true, errors.Is(err, ErrInvalidAccessor))
+}
@@ -0,0 +1,160 @@
+package engine
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrInvalidConfig is returned by ConfigBuilder.Build when the settings of the configuration contradict each other
+// or the Marshaller and the Unmarshaler aren't set.
+var ErrInvalidConfig = errors.New("invalid configuration")
+
+// ConfigBuilder builds a Config step by step and checks that its settings don't contradict each other, e.g.
+// UnwrapWhenDecoding without the StructOpener and the StructCloser to remove. Its methods return the builder
+// itself, so that the calls can be chained.
+type ConfigBuilder struct {
+	cfg Config
+}
+
+// NewConfigBuilder returns a new builder starting from the base configuration, e.g. a zero Config or CSVConfig().
+func NewConfigBuilder(base Config) *ConfigBuilder {
+	return &ConfigBuilder{cfg: base.clone()}
+}
+
+// WithStructDelimiters sets the StructOpener and the StructCloser, unwrap sets UnwrapWhenDecoding.
+func (b *ConfigBuilder) WithStructDelimiters(opener, closer string, unwrap bool) *ConfigBuilder {
+	b.cfg.StructOpener, b.cfg.StructCloser, b.cfg.UnwrapWhenDecoding = []byte(opener), []byte(closer), unwrap
+	return b
+}
+
+// WithSeparator sets the ValueSeparator, remove sets RemoveSeparatorWhenDecoding.
+func (b *ConfigBuilder) WithSeparator(separator string, remove bool) *ConfigBuilder {
+	b.cfg.ValueSeparator, b.cfg.RemoveSeparatorWhenDecoding = []byte(separator), remove
+	return b
+}
+
+// WithComponentSeparator sets the ComponentSeparator, so that the library splits the data itself when decoding.
+func (b *ConfigBuilder) WithComponentSeparator(separator string) *ConfigBuilder {
+	b.cfg.ComponentSeparator = []byte(separator)
+	return b
+}
+
+// WithSliceDelimiters sets the SliceOpener, the SliceCloser and the ElementSeparator.
+func (b *ConfigBuilder) WithSliceDelimiters(opener, closer, separator string) *ConfigBuilder {
+	b.cfg.SliceOpener, b.cfg.SliceCloser, b.cfg.ElementSeparator = []byte(opener), []byte(closer), []byte(separator)
+	return b
+}
+
+// WithMapDelimiters sets the MapOpener, the MapCloser, the KeyValueSeparator and the EntrySeparator.
+func (b *ConfigBuilder) WithMapDelimiters(opener, closer, keyValue, entry string) *ConfigBuilder {
+	b.cfg.MapOpener, b.cfg.MapCloser = []byte(opener), []byte(closer)
+	b.cfg.KeyValueSeparator, b.cfg.EntrySeparator = []byte(keyValue), []byte(entry)
+	return b
+}
+
+// WithEscapeChar sets the EscapeChar.
+func (b *ConfigBuilder) WithEscapeChar(c byte) *ConfigBuilder {
+	b.cfg.EscapeChar = c
+	return b
+}
+
+// WithRecordSeparator sets the RecordSeparator.
+func (b *ConfigBuilder) WithRecordSeparator(separator string) *ConfigBuilder {
+	b.cfg.RecordSeparator = []byte(separator)
+	return b
+}
+
+// WithStrictDecoding sets DisallowTrailingData, DisallowUnknownFields and DisallowEmptyStructs.
+func (b *ConfigBuilder) WithStrictDecoding() *ConfigBuilder {
+	b.cfg.DisallowTrailingData, b.cfg.DisallowUnknownFields, b.cfg.DisallowEmptyStructs = true, true, true
+	return b
+}
+
+// WithMarshalling sets the Marshaller and the Unmarshaler interfaces of the format.
+func (b *ConfigBuilder) WithMarshalling(marshaller, unmarshaler reflect.Type) *ConfigBuilder {
+	b.cfg.Marshaller, b.cfg.Unmarshaler = marshaller, unmarshaler
+	return b
+}
+
+// With changes the configuration with the function, for the settings the builder has no methods for.
+func (b *ConfigBuilder) With(update func(cfg *Config)) *ConfigBuilder {
+	update(&b.cfg)
+	return b
+}
+
+// Build checks the configuration and returns it, or the first contradiction found wrapping ErrInvalidConfig.
+// The Marshaller and the Unmarshaler must be set, see WithMarshalling.
+func (b *ConfigBuilder) Build() (Config, error) {
+	if err := b.cfg.check(); err != nil {
+		return Config{}, err
+	}
+	return b.cfg.clone(), nil
+}
+
+// check reports the first contradiction between the settings of the configuration, or the missing interfaces.
+func (c *Config) check() error {
+	split := len(c.ComponentSeparator) != 0 || len(c.Separators) != 0
+
+	switch {
+	case c.UnwrapWhenDecoding && len(c.StructOpener) == 0 && len(c.StructCloser) == 0:
+		return fmt.Errorf("%w: UnwrapWhenDecoding without the StructOpener and the StructCloser", ErrInvalidConfig)
+	case c.RemoveSeparatorWhenDecoding && len(c.ValueSeparator) == 0:
+		return fmt.Errorf("%w: RemoveSeparatorWhenDecoding without the ValueSeparator", ErrInvalidConfig)
+	case c.CountElements && len(c.ElementSeparator) == 0:
+		return fmt.Errorf("%w: CountElements without the ElementSeparator", ErrInvalidConfig)
+	case c.DisallowTrailingData && !split:
+		return fmt.Errorf("%w: DisallowTrailingData without the ComponentSeparator or the Separators", ErrInvalidConfig)
+	case c.DisallowUnknownFields && !split:
+		return fmt.Errorf("%w: DisallowUnknownFields without the ComponentSeparator or the Separators", ErrInvalidConfig)
+	case c.NilInterfaceWhenEncoding == NilInterfaceLiteral && len(c.NilLiteral) == 0:
+		return fmt.Errorf("%w: NilInterfaceLiteral without the NilLiteral", ErrInvalidConfig)
+	}
+
+	pairs := []struct {
+		name           string
+		opener, closer []byte
+	}{
+		{"Struct", c.StructOpener, c.StructCloser},
+		{"Slice", c.SliceOpener, c.SliceCloser},
+		{"Element", c.ElementOpener, c.ElementCloser},
+		{"Map", c.MapOpener, c.MapCloser},
+	}
+	for _, p := range pairs {
+		if (len(p.opener) == 0) != (len(p.closer) == 0) {
+			return fmt.Errorf("%w: the %sOpener and the %sCloser must be set together", ErrInvalidConfig, p.name, p.name)
+		}
+	}
+
+	separators := []struct {
+		name      string
+		separator []byte
+	}{
+		{"ValueSeparator", c.ValueSeparator},
+		{"ComponentSeparator", c.ComponentSeparator},
+		{"ElementSeparator", c.ElementSeparator},
+		{"KeyValueSeparator", c.KeyValueSeparator},
+		{"EntrySeparator", c.EntrySeparator},
+		{"RecordSeparator", c.RecordSeparator},
+	}
+	for i, a := range separators {
+		if len(a.separator) == 0 {
+			continue
+		}
+		if c.EscapeChar != 0 && bytes.IndexByte(a.separator, c.EscapeChar) >= 0 {
+			return fmt.Errorf("%w: the EscapeChar is a part of the %s", ErrInvalidConfig, a.name)
+		}
+		for _, b := range separators[i+1:] {
+			if bytes.Equal(a.separator, b.separator) {
+				return fmt.Errorf("%w: the %s and the %s are the same", ErrInvalidConfig, a.name, b.name)
+			}
+		}
+	}
+
+	// The engine checks the types of values against the interfaces, so they can't be left nil.
+	if c.Marshaller == nil || c.Unmarshaler == nil {
+		return fmt.Errorf("%w: the Marshaller and the Unmarshaler must be set, see WithMarshalling", ErrInvalidConfig)
+	}
+	return nil
+}
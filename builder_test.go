@@ -0,0 +1,52 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestConfigBuilder(t *testing.T) {
+	base := func(cfg Config) *ConfigBuilder {
+		return NewConfigBuilder(cfg).WithMarshalling(testConfig().Marshaller, testConfig().Unmarshaler)
+	}
+
+	var tests = []struct {
+		builder *ConfigBuilder
+		expect  error
+	}{
+		{
+			builder: base(CSVConfig()).WithStrictDecoding(),
+		},
+		{
+			builder: base(PipeDelimitedConfig()),
+		},
+		{
+			builder: base(LineRecordsConfig()),
+		},
+		{
+			builder: base(Config{}).WithStructDelimiters("", "", true),
+			expect:  ErrInvalidConfig,
+		},
+		{
+			builder: base(Config{}).WithSliceDelimiters("[", "", ","),
+			expect:  ErrInvalidConfig,
+		},
+		{
+			builder: base(Config{}).WithSeparator(",", false).WithComponentSeparator(","),
+			expect:  ErrInvalidConfig,
+		},
+		{
+			builder: base(Config{}).WithSeparator(",", false).WithStrictDecoding(),
+			expect:  ErrInvalidConfig,
+		},
+		{
+			// The presets leave the interfaces of the format to the caller.
+			builder: NewConfigBuilder(CSVConfig()),
+			expect:  ErrInvalidConfig,
+		},
+	}
+	for _, tt := range tests {
+		_, err := tt.builder.Build()
+		equal(t, tt.expect, errors.Unwrap(err))
+	}
+}
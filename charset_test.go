@@ -0,0 +1,65 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+)
+
+// charsetMeta is a parsed tag giving its field the charset CP500 if the value of the tag is "cp500",
+// see Charsetter.
+type charsetMeta struct {
+	charset Charset
+}
+
+func (m *charsetMeta) parse(tagValue string) (bool, error) {
+	if tagValue == "cp500" {
+		m.charset = CP500
+	}
+	return false, nil
+}
+
+func (m *charsetMeta) Charset() Charset {
+	return m.charset
+}
+
+func TestCharset(t *testing.T) {
+	type record struct {
+		A string
+		B string `test:"cp500"`
+		L []string
+		M map[string]int
+	}
+	e := newEngineOf[charsetMeta](func(cfg *Config) {
+		cfg.Charset = CP037
+		cfg.ElementSeparator, cfg.EntrySeparator, cfg.KeyValueSeparator = []byte("~"), []byte(";"), []byte("=")
+	})
+	value := record{A: "Hé[!", B: "[!", L: []string{"a", "b"}, M: map[string]int{"k": 1}}
+
+	b, err := e.Marshal(value)
+	equal(t, nil, err)
+	// The values are converted one by one, the separators are left as they are.
+	equal(t, "\xc8\x51\xba\x5a,\x4a\x4f,\x81~\x82,\x92=\xf1", string(b))
+
+	var got record
+	equal(t, nil, e.Unmarshal(b, &got))
+	equal(t, value, got)
+
+	value.A = "€"
+	_, err = e.Marshal(value)
+	equal(t, true, errors.Is(err, ErrCharset))
+}
+
+func TestCodePage(t *testing.T) {
+	for _, cp := range []Charset{CP037, CP500} {
+		for b := 0; b < 256; b++ {
+			p, err := cp.Decode(nil, []byte{byte(b)})
+			equal(t, nil, err)
+			got, err := cp.Encode(nil, p)
+			equal(t, nil, err)
+			equal(t, []byte{byte(b)}, got)
+		}
+	}
+
+	_, err := CP037.Encode(nil, []byte{0xff})
+	equal(t, true, errors.Is(err, ErrCharset))
+}
@@ -0,0 +1,139 @@
+package engine
+
+import "io"
+
+// DefaultChunkSize is the size of the chunks of a ChunkedEncoder created with a size that isn't positive.
+const DefaultChunkSize = 64 << 10
+
+// A ChunkedEncoder encodes a value in chunks the caller pulls with Read, so that a large value, e.g. a struct
+// with a long slice of structs, is encoded without holding the whole output in one buffer. The value is encoded
+// in the background, which waits while the caller reads the chunk encoded before.
+//
+// The output is handed over in chunks after the fields of structs and the elements and the entries of slices
+// and maps of structs, once it reaches the size of a chunk, so a chunk may be larger if a single value is,
+// e.g. a list passed to Tag.Encode as a whole. The structs of a Tag implementing TagStructHooks or
+// with Config.TrimTrailingEmpty are handed over as a whole. If encoding fails, the chunks already read
+// are a part of the output and Read returns the error.
+type ChunkedEncoder struct {
+	chunks chan []byte   // the chunks encoded, closed when encoding is over
+	next   chan struct{} // the chunk is read, its buffer may be reused
+	stop   chan struct{} // closed by Close
+	chunk  []byte        // the rest of the chunk being read
+	taken  bool          // the background waits on next for the chunk being read
+	closed bool
+	err    error // the error of encoding, set before chunks is closed
+}
+
+// NewChunkedEncoder returns a new chunked encoder of the engine e that encodes the value v in chunks
+// of the size, DefaultChunkSize if it isn't positive. The encoder must be read to the end or closed.
+// The encoder of an engine without the NewChunkedEncoder method returns ErrUnsupported.
+func NewChunkedEncoder(e Engine, v any, size int) *ChunkedEncoder {
+	if n, ok := e.(interface {
+		NewChunkedEncoder(v any, size int) *ChunkedEncoder
+	}); ok {
+		return n.NewChunkedEncoder(v, size)
+	}
+	c := newChunkedEncoder()
+	c.finish(unsupported(e, "NewChunkedEncoder"))
+	return c
+}
+
+// NewChunkedEncoder returns a new chunked encoder of the value v, see the function NewChunkedEncoder.
+func (e *engine[T]) NewChunkedEncoder(v any, size int) *ChunkedEncoder {
+	if size <= 0 {
+		size = DefaultChunkSize
+	}
+
+	c := newChunkedEncoder()
+	go func() {
+		s := e.newEncodeState()
+		defer encodeStatePool.Put(s)
+
+		s.chunk, s.chunkSize = c.send, size
+		s.marshal(v)
+		err := s.err
+		if err == nil && s.Len() != 0 {
+			err = c.send(s.Bytes())
+		}
+		c.finish(err)
+	}()
+	return c
+}
+
+func newChunkedEncoder() *ChunkedEncoder {
+	return &ChunkedEncoder{
+		chunks: make(chan []byte),
+		next:   make(chan struct{}),
+		stop:   make(chan struct{}),
+	}
+}
+
+// Read reads up to len(p) bytes of the encoded value into p. It returns io.EOF when the whole value is read,
+// the error of encoding if it fails and io.ErrClosedPipe if the encoder is closed.
+func (c *ChunkedEncoder) Read(p []byte) (int, error) {
+	if c.closed {
+		return 0, io.ErrClosedPipe
+	}
+
+	for len(c.chunk) == 0 {
+		if c.taken {
+			c.taken = false
+			c.next <- struct{}{}
+		}
+		chunk, ok := <-c.chunks
+		if !ok {
+			if c.err != nil {
+				return 0, c.err
+			}
+			return 0, io.EOF
+		}
+		c.chunk, c.taken = chunk, true
+	}
+
+	n := copy(p, c.chunk)
+	c.chunk = c.chunk[n:]
+	return n, nil
+}
+
+// Close stops encoding the value, the rest of the output is discarded.
+func (c *ChunkedEncoder) Close() error {
+	if !c.closed {
+		c.closed = true
+		close(c.stop)
+	}
+	return nil
+}
+
+// send hands the chunk over to the reader and waits until it is read, then the buffer of the chunk may be reused.
+func (c *ChunkedEncoder) send(p []byte) error {
+	select {
+	case c.chunks <- p:
+	case <-c.stop:
+		return io.ErrClosedPipe
+	}
+	select {
+	case <-c.next:
+		return nil
+	case <-c.stop:
+		return io.ErrClosedPipe
+	}
+}
+
+// finish ends encoding with the error.
+func (c *ChunkedEncoder) finish(err error) {
+	c.err = err
+	close(c.chunks)
+}
+
+// yield hands the output over to the ChunkedEncoder when it reaches the size of a chunk,
+// unless a struct being encoded may still change it.
+func (s *encodeState[T]) yield() error {
+	if s.chunk == nil || s.hold != 0 || s.Len() < s.chunkSize {
+		return nil
+	}
+	if err := s.chunk(s.Bytes()); err != nil {
+		return err
+	}
+	s.Reset()
+	return nil
+}
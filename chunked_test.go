@@ -0,0 +1,51 @@
+package engine
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"testing"
+)
+
+func TestChunkedEncoder(t *testing.T) {
+	type batch struct {
+		Name    string
+		Parties []party
+	}
+	e := newTestEngine(func(cfg *Config) { cfg.ElementSeparator = []byte(";") })
+
+	value := batch{Name: "b"}
+	for i := 0; i < 100; i++ {
+		value.Parties = append(value.Parties, party{ID: strconv.Itoa(i), Agency: i})
+	}
+	expect, err := e.Marshal(value)
+	equal(t, nil, err)
+
+	c := NewChunkedEncoder(e, value, 16)
+	// The first chunk is a part of the output.
+	p := make([]byte, 1)
+	n, err := c.Read(p)
+	equal(t, nil, err)
+	equal(t, 1, n)
+	equal(t, true, len(c.chunk) < 32)
+
+	rest, err := io.ReadAll(c)
+	equal(t, nil, err)
+	equal(t, string(expect), string(p)+string(rest))
+
+	// A closed encoder stops encoding.
+	c = NewChunkedEncoder(e, value, 16)
+	_, err = c.Read(p)
+	equal(t, nil, err)
+	equal(t, nil, c.Close())
+	_, err = c.Read(p)
+	equal(t, io.ErrClosedPipe, err)
+
+	// The error of encoding follows the chunks read.
+	c = NewChunkedEncoder(e, struct{ C chan int }{}, 0)
+	_, err = io.ReadAll(c)
+	equal(t, true, errors.Is(err, ErrNotSupportType))
+
+	_, err = io.ReadAll(NewChunkedEncoder(foreignEngine{e}, value, 0))
+	equal(t, true, errors.Is(err, ErrUnsupported))
+}
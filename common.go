@@ -1,10 +1,13 @@
 package engine
 
 import (
+	"encoding"
 	"errors"
 	"fmt"
 	"reflect"
-	"sync"
+	"sort"
+	"strings"
+	"time"
 )
 
 var (
@@ -14,15 +17,55 @@ var (
 	ErrNilInterface        = errors.New("interface is nil")
 	ErrPointerToUnexported = errors.New("cannot set embedded pointer to unexported struct")
 	ErrInvalidFormat       = errors.New("the raw data has an invalid format for an object value")
+	ErrInvalidAccessor     = errors.New("accessor field has no suitable getter and setter methods")
+	ErrUnsupported         = errors.New("the engine doesn't support the operation")
+	ErrArrayLength         = errors.New("the number of decoded elements doesn't match the array length")
+	ErrNoEngine            = errors.New("no engine is registered for the header")
+	ErrCodecType           = errors.New("the value isn't of the type the codec was compiled for")
+	ErrTrailingData        = errors.New("unexpected data after the value")
+	ErrNoFields            = errors.New("the struct has no fields to decode")
+	ErrLimit               = errors.New("the data exceeds a decoding limit")
+	ErrNotSettable         = errors.New("the value isn't settable")
+	ErrMaxDepth            = errors.New("the value exceeds the maximum nesting depth")
+	ErrCycle               = errors.New("unsupported value: encountered a cycle")
+	ErrControlChar         = errors.New("the value contains a control character")
+)
+
+var (
+	durationType          = reflect.TypeOf(time.Duration(0))
+	binaryMarshalerType   = reflect.TypeOf((*encoding.BinaryMarshaler)(nil)).Elem()
+	binaryUnmarshalerType = reflect.TypeOf((*encoding.BinaryUnmarshaler)(nil)).Elem()
+	zeroerType            = reflect.TypeOf((*interface{ IsZero() bool })(nil)).Elem()
 )
 
 // field represents a single field found in a struct.
 type field[T any] struct {
 	index     int
 	name      string
+	key       string   // the name passed to the Tag, see Renamer
+	aliases   []string // the other keys of the field in keyed data, see Aliaser
 	typ       reflect.Type
+	tag       string
 	meta      *T
 	omitEmpty bool
+	keyOrder  KeyOrder
+	unique    bool  // decoding of a Set fails on repeated elements, see DuplicateRejecter
+	width     int   // the width in bytes of an integer of a binary format, see IntegerWidther
+	little    bool  // the integer is little-endian
+	extras    bool  // the field captures the values matching no field, see Extras
+	def       any   // the default value of the field, see Defaulter
+	err       error // the error of the tag or the accessor of the field, reported by the coders
+	composite bool
+	list      bool
+	normalize func([]byte) []byte
+	delegate  Engine                              // the engine encoding the value of the field, see Delegator
+	format    *timeFormat                         // the encoding of a time.Time field, see TimeFormatter
+	number    *numberFormat                       // the representation of a numeric field, see NumberFormatter
+	charset   Charset                             // the charset of the values of the field, see Charsetter
+	output    OutputCharsetValidator              // the characters of the values of the field, see OutputCharsetter
+	transform Transform                           // the transform of the encoded value of the field, see Transformer
+	get       func(v reflect.Value) reflect.Value // getter and setter of a field that isn't stored in a struct
+	put       func(v, rv reflect.Value) error
 	encoder   encoderFunc[T]
 	decoder   decoderFunc[T]
 	embedded  structFields[T]
@@ -30,21 +73,28 @@ type field[T any] struct {
 
 type structFields[T any] []field[T]
 
-var fieldCache sync.Map // map[reflect.Type]structFields[T]
+// dialectKey is the key of the fields of a type read with a dialect in the field cache.
+type dialectKey struct {
+	typ     reflect.Type
+	dialect string
+}
 
 // cachedFields is like typeFields but uses a cache to avoid repeated work.
 func (e *engine[T]) cachedFields(t reflect.Type) structFields[T] {
-	if c, ok := fieldCache.Load(t); ok {
+	var key any = t
+	if e.dialect != nil {
+		key = dialectKey{typ: t, dialect: e.dialect.Name}
+	}
+
+	if c, ok := e.fields.Load(key); ok {
 		return c.(structFields[T])
 	}
-	c, _ := fieldCache.LoadOrStore(t, e.typeFields(t))
+	c, _ := e.fields.LoadOrStore(key, e.typeFields(t))
 	return c.(structFields[T])
 }
 
 // typeFields returns a list of fields that the encoder should recognize for the given type.
 func (e *engine[T]) typeFields(t reflect.Type) structFields[T] {
-	var err error
-
 	fields := make(structFields[T], 0, t.NumField())
 
 	// Scan v for fields to encode.
@@ -55,6 +105,7 @@ func (e *engine[T]) typeFields(t reflect.Type) structFields[T] {
 		fld := field[T]{
 			index: i,
 			name:  structField.Name,
+			key:   e.mapName(structField.Name),
 			typ:   fieldType,
 		}
 
@@ -69,7 +120,12 @@ func (e *engine[T]) typeFields(t reflect.Type) structFields[T] {
 			}
 
 			// Do not ignore embedded fields of unexported struct types since they may have exported fields.
-			fld.embedded = e.typeFields(fieldType)
+			// The values matching no field are captured by the Extras field of the outer struct only.
+			for _, ef := range e.typeFields(fieldType) {
+				if !ef.extras {
+					fld.embedded = append(fld.embedded, ef)
+				}
+			}
 
 			if fld.embedded == nil {
 				continue
@@ -82,39 +138,221 @@ func (e *engine[T]) typeFields(t reflect.Type) structFields[T] {
 			continue
 		}
 
-		if tag, ok := structField.Tag.Lookup(e.Name()); ok {
-			// Ignore the field if the tag has a skip fieldValue.
-			if e.Skip(tag) {
-				continue
-			}
+		if skip, err := e.parseTag(&fld, t.Name(), structField.Tag); skip {
+			continue
+		} else if err != nil {
+			return append(fields, fld)
+		}
 
-			fld.meta = new(T)
-			if fld.omitEmpty, err = e.Parse(tag, fld.meta); err != nil {
-				fld.encoder, fld.decoder = invalidTagEncoder[T](tag, err), invalidTagDecoder[T](tag, err)
-				return append(fields, fld)
+		if fieldType == extrasType {
+			fld.extras = true
+			fields = append(fields, fld)
+			continue
+		}
+
+		if fieldType == accessorType {
+			get, put, typ, err := accessors(t, fld.name)
+			if err != nil {
+				fld.err = err
+				fld.encoder, fld.decoder = invalidFieldEncoder[T](err), invalidFieldDecoder[T](err)
+				fields = append(fields, fld)
+				continue
 			}
+			fld.get, fld.put, fld.typ, fieldType = get, put, typ, typ
 		}
 
-		fld.encoder, fld.decoder = e.typeCoders(fieldType)
+		e.setCoders(&fld, fieldType)
 		fields = append(fields, fld)
 	}
 
+	e.sortFields(fields)
 	return fields
 }
 
+// parseTag parses the engine tag of the field. It returns a flag indicating that the field should be ignored,
+// by its tag or for the lack of it, see Config.RequireTag, and the parsing error, then the field gets coders reporting the error.
+func (e *engine[T]) parseTag(fld *field[T], structName string, structTag reflect.StructTag) (skip bool, err error) {
+	tag, ok := e.lookupTag(structName, fld.name, structTag)
+	if !ok {
+		return e.requireTag, nil
+	}
+
+	// Ignore the field if the tag has a skip fieldValue.
+	if e.Skip(tag) {
+		return true, nil
+	}
+
+	fld.tag = tag
+	fld.meta = new(T)
+	if fld.omitEmpty, err = e.Parse(tag, fld.meta); err != nil {
+		fld.err = err
+		fld.encoder, fld.decoder = invalidTagEncoder[T](tag, err), invalidTagDecoder[T](tag, err)
+		return false, err
+	}
+
+	e.setOptions(fld)
+	return false, nil
+}
+
+// setOptions sets the options of the field declared by the interfaces its parsed tag implements.
+func (e *engine[T]) setOptions(fld *field[T]) {
+	if n, ok := any(fld.meta).(Normalizer); ok {
+		fld.normalize = n.Normalize
+	}
+	if d, ok := any(fld.meta).(Delegator); ok {
+		fld.delegate = d.Delegate()
+	}
+	if o, ok := any(fld.meta).(KeyOrderer); ok {
+		fld.keyOrder = o.KeyOrder()
+	}
+	if r, ok := any(fld.meta).(DuplicateRejecter); ok {
+		fld.unique = r.RejectDuplicates()
+	}
+	if r, ok := any(fld.meta).(Renamer); ok && r.Rename() != "" {
+		fld.key = r.Rename()
+	}
+	if d, ok := any(fld.meta).(Defaulter); ok {
+		fld.def = d.DefaultValue()
+	}
+	if a, ok := any(fld.meta).(Aliaser); ok {
+		fld.aliases = a.Aliases()
+	}
+	if w, ok := any(fld.meta).(IntegerWidther); ok {
+		fld.width, fld.little = w.IntegerWidth()
+	}
+	if f, ok := any(fld.meta).(TimeFormatter); ok {
+		fld.format = newTimeFormat(f)
+	}
+	if f, ok := any(fld.meta).(NumberFormatter); ok {
+		nf := new(numberFormat)
+		if nf.format, nf.scale = f.NumberFormat(); nf.format != PlainNumber || nf.scale != 0 {
+			fld.number = nf
+		}
+	}
+	if c, ok := any(fld.meta).(Charsetter); ok {
+		fld.charset = c.Charset()
+	}
+	if o, ok := any(fld.meta).(OutputCharsetter); ok {
+		fld.output = o.OutputCharset()
+	}
+	if t, ok := any(fld.meta).(Transformer); ok {
+		fld.transform = t.Transform()
+	}
+}
+
+// setCoders sets the coders of the field of the type, a field delegated to another engine,
+// holding an integer of a declared width, a formatted time or a represented number is a single value.
+func (e *engine[T]) setCoders(fld *field[T], t reflect.Type) {
+	var err error
+	switch {
+	case fld.delegate != nil:
+		fld.encoder, fld.decoder = delegateEncoder[T](fld.delegate), delegateDecoder[T](fld.delegate)
+	case fld.width != 0:
+		fld.encoder, fld.decoder, err = integerCoders[T](t, fld.width, fld.little)
+	case fld.format != nil:
+		fld.encoder, fld.decoder, err = timeCoders[T](t, fld.format)
+	case fld.number != nil:
+		fld.encoder, fld.decoder, err = numberCoders[T](t, fld.number)
+	default:
+		fld.composite, fld.list = e.isComposite(t), e.isList(t)
+		fld.encoder, fld.decoder = e.typeCoders(t)
+	}
+	if err == nil && fld.transform != nil && (fld.composite || fld.list) {
+		err = fmt.Errorf("%w: transform of %s", ErrNotSupportType, t)
+	}
+	if err != nil {
+		fld.err = err
+		fld.encoder, fld.decoder = invalidFieldEncoder[T](err), invalidFieldDecoder[T](err)
+	}
+}
+
+// charsetOf returns the charset of the values of the field, the charset of the engine cs if the field
+// declares none, or nil if the values aren't converted, see Charset.
+func (f *field[T]) charsetOf(cs Charset) Charset {
+	switch {
+	case f.binary():
+		return nil
+	case f.charset != nil:
+		return f.charset
+	}
+	return cs
+}
+
+// binary reports whether the values of the field are binary rather than text: delegated to another engine,
+// integers of a declared width or packed and zoned numbers.
+func (f *field[T]) binary() bool {
+	return f.delegate != nil || f.width != 0 || f.number != nil && f.number.format >= ZonedDecimal
+}
+
+// sortFields orders the fields with the Config.FieldLess comparator.
+func (e *engine[T]) sortFields(fields structFields[T]) {
+	if e.fieldLess != nil {
+		sort.SliceStable(fields, func(i, j int) bool {
+			return e.fieldLess(fields[i].info(), fields[j].info())
+		})
+	}
+}
+
+// info returns the description of the field for the Config.FieldLess comparator.
+func (f *field[T]) info() FieldInfo {
+	fi := FieldInfo{
+		Index: f.index,
+		Name:  f.name,
+		Type:  f.typ,
+		Tag:   f.tag,
+	}
+	if f.meta != nil {
+		fi.Meta = f.meta
+	}
+	return fi
+}
+
 // typeCoders returns encoderFunc and decoderFunc for a type.
 func (e *engine[T]) typeCoders(t reflect.Type) (ef encoderFunc[T], df decoderFunc[T]) {
+	if ef, df = e.customCoders(t); ef != nil && df != nil {
+		return
+	}
+
 	if t.Kind() != reflect.Pointer {
 		p := reflect.PointerTo(t)
+		if e.binary && p.Implements(binaryMarshalerType) {
+			ef = setCoder[T](ef, binaryMarshalerEncoder[T])
+		}
+		if e.binary && p.Implements(binaryUnmarshalerType) {
+			df = setCoder[T](df, binaryUnmarshalerDecoder[T])
+		}
+		if ef != nil && df != nil {
+			return
+		}
+
 		if p.Implements(e.marshaller) {
-			ef = marshallerEncoder[T]
+			ef = setCoder[T](ef, marshallerEncoder[T])
 		}
 		if p.Implements(e.unmarshaler) {
-			df = unmarshalerDecoder[T]
+			df = setCoder[T](df, unmarshalerDecoder[T])
 			if ef != nil {
 				return
 			}
 		}
+
+		if t.Kind() != reflect.Struct && p.Implements(describerType) {
+			return setCoder[T](ef, describerEncoder[T]), setCoder[T](df, describerDecoder[T])
+		}
+	}
+
+	if isSingleKind(t.Kind()) {
+		kef, kdf := e.customCoders(t.Kind())
+		ef, df = setCoder[T](ef, kef), setCoder[T](df, kdf)
+	}
+	if t == durationType {
+		return setCoder[T](ef, durationEncoder[T]), setCoder[T](df, durationDecoder[T])
+	}
+
+	if m, ok := orderedMapOf(t); ok {
+		return e.mapCoders(m, ef, df)
+	}
+	if st, ok := setOf(t); ok {
+		return e.uniqueSetCoders(st, ef, df)
 	}
 
 	switch t.Kind() {
@@ -126,16 +364,16 @@ func (e *engine[T]) typeCoders(t reflect.Type) (ef encoderFunc[T], df decoderFun
 		return setCoder[T](ef, uintEncoder[T]), setCoder[T](df, uintDecoder[T])
 	case reflect.Float32, reflect.Float64:
 		return setCoder[T](ef, floatEncoder[T]), setCoder[T](df, floatDecoder[T])
-	//case reflect.Array:
-	//	return setCoder[T](ef, arrayEncoder[T]), setCoder[T](df, arrayDecoder[T])
+	case reflect.Array:
+		return e.arrayCoders(t, ef, df)
 	case reflect.Interface:
 		return setCoder[T](ef, interfaceEncoder[T]), setCoder[T](df, interfaceDecoder[T])
-	//case reflect.Map:
-	//	return setCoder[T](ef, mapEncoder[T]), setCoder[T](df, mapDecoder[T])
+	case reflect.Map:
+		return e.mapCoders(t, ef, df)
 	case reflect.Pointer:
 		return setCoder[T](ef, pointerEncoder[T]), setCoder[T](df, pointerDecoder[T])
 	case reflect.Slice:
-		return sliceCoders(t, ef, df)
+		return e.sliceCoders(t, ef, df)
 	case reflect.String:
 		return setCoder[T](ef, stringEncoder[T]), setCoder[T](df, stringDecoder[T])
 	case reflect.Struct:
@@ -145,6 +383,54 @@ func (e *engine[T]) typeCoders(t reflect.Type) (ef encoderFunc[T], df decoderFun
 	}
 }
 
+// isComposite reports whether a value of the type is a struct or a FieldDescriber the library splits into components
+// itself when it splits the data, or a slice, an array or a map of such values.
+func (e *engine[T]) isComposite(t reflect.Type) bool {
+	// A FieldDescriber may be a map or a slice itself, its elements aren't the components.
+	for !e.isCustom(t) && !reflect.PointerTo(t).Implements(describerType) {
+		c := containerOf(t)
+		if c.Kind() != reflect.Pointer && !isSequence(c) && c.Kind() != reflect.Map {
+			break
+		}
+		t = c.Elem()
+	}
+	p := reflect.PointerTo(t)
+	return !e.isCustom(t) && (t.Kind() == reflect.Struct || p.Implements(describerType)) && !e.isUnmarshaler(p)
+}
+
+// isList reports whether a value of the type is a slice, an array or a map of values the library joins into a list.
+func (e *engine[T]) isList(t reflect.Type) bool {
+	for t.Kind() == reflect.Pointer && !e.isCustom(t) {
+		t = t.Elem()
+	}
+	c := containerOf(t)
+	return !e.isCustom(t) && (isSequence(c) || c.Kind() == reflect.Map) && !e.isUnmarshaler(reflect.PointerTo(t)) &&
+		!e.isComposite(t)
+}
+
+// isUnmarshaler reports whether the pointer type implements the Unmarshaler,
+// or encoding.BinaryUnmarshaler if it's preferred, so the values are decoded as a whole.
+func (e *engine[T]) isUnmarshaler(p reflect.Type) bool {
+	return p.Implements(e.unmarshaler) || e.binary && p.Implements(binaryUnmarshalerType)
+}
+
+// containerOf returns the type of the map holding the values of an OrderedMap, or the type of the slice
+// holding the elements of a Set, or the type itself.
+func containerOf(t reflect.Type) reflect.Type {
+	if m, ok := orderedMapOf(t); ok {
+		return m
+	}
+	if st, ok := setOf(t); ok {
+		return st
+	}
+	return t
+}
+
+// isSequence reports whether the type is a slice or an array of elements other than bytes.
+func isSequence(t reflect.Type) bool {
+	return (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && t.Elem().Kind() != reflect.Uint8
+}
+
 func setCoder[T any, F encoderFunc[T] | decoderFunc[T]](i, f F) F {
 	if i != nil {
 		return i
@@ -152,12 +438,47 @@ func setCoder[T any, F encoderFunc[T] | decoderFunc[T]](i, f F) F {
 	return f
 }
 
-func sliceCoders[T any](t reflect.Type, ef encoderFunc[T], df decoderFunc[T]) (encoderFunc[T], decoderFunc[T]) {
-	if t.Elem().Kind() == reflect.Uint8 {
+func (e *engine[T]) sliceCoders(t reflect.Type, ef encoderFunc[T], df decoderFunc[T]) (encoderFunc[T], decoderFunc[T]) {
+	switch {
+	case t.Elem().Kind() == reflect.Uint8:
 		return setCoder[T](ef, bytesEncoder[T]), setCoder[T](df, bytesDecoder[T])
-	} else {
-		return setCoder[T](ef, unsupportedTypeEncoder[T]), setCoder[T](df, unsupportedTypeDecoder[T])
+	case e.isComposite(t.Elem()):
+		return setCoder[T](ef, compositeSliceEncoder[T]), setCoder[T](df, compositeSliceDecoder[T])
+	default:
+		return setCoder[T](ef, sliceEncoder[T]), setCoder[T](df, sliceDecoder[T])
+	}
+}
+
+// arrayCoders returns the coders of an array, arrays of bytes are handled like []byte
+// and other arrays like slices.
+func (e *engine[T]) arrayCoders(t reflect.Type, ef encoderFunc[T], df decoderFunc[T]) (encoderFunc[T], decoderFunc[T]) {
+	if t.Elem().Kind() == reflect.Uint8 {
+		return setCoder[T](ef, byteArrayEncoder[T]), setCoder[T](df, byteArrayDecoder[T])
 	}
+	return e.sliceCoders(t, ef, df)
+}
+
+// mapCoders returns the coders of a map, maps of composite values are written like slices of them.
+func (e *engine[T]) mapCoders(t reflect.Type, ef encoderFunc[T], df decoderFunc[T]) (encoderFunc[T], decoderFunc[T]) {
+	switch {
+	case !isMapSupported(t):
+	case e.isComposite(t.Elem()):
+		return setCoder[T](ef, compositeMapEncoder[T]), setCoder[T](df, compositeMapDecoder[T])
+	default:
+		return setCoder[T](ef, mapEncoder[T]), setCoder[T](df, mapDecoder[T])
+	}
+	return setCoder[T](ef, unsupportedTypeEncoder[T]), setCoder[T](df, unsupportedTypeDecoder[T])
+}
+
+// isMapSupported reports whether the map has keys of string or integer kinds.
+func isMapSupported(t reflect.Type) bool {
+	switch t.Key().Kind() {
+	case reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	}
+	return false
 }
 
 func bitSize(v reflect.Kind) int {
@@ -176,6 +497,32 @@ func bitSize(v reflect.Kind) int {
 	return 0
 }
 
+// isEmpty reports whether the value of a field with omitempty is empty, see Config.KeepEmptySlices.
+func (s *settings) isEmpty(v reflect.Value) bool {
+	if s.emptiness != nil {
+		return s.emptiness(v)
+	}
+	if s.keepEmptySlices && v.Kind() == reflect.Slice {
+		return v.IsNil()
+	}
+	if containerOf(v.Type()) != v.Type() {
+		return pointerTo(v).Interface().(interface{ Len() int }).Len() == 0
+	}
+	if isEmptyValue(v) {
+		return true
+	}
+	// A type like time.Time knows better when it is zero.
+	switch k := v.Kind(); {
+	case k == reflect.Interface:
+		return false
+	case v.Type().Implements(zeroerType):
+		return v.Interface().(interface{ IsZero() bool }).IsZero()
+	case k != reflect.Pointer && reflect.PointerTo(v.Type()).Implements(zeroerType):
+		return pointerTo(v).Interface().(interface{ IsZero() bool }).IsZero()
+	}
+	return false
+}
+
 func isEmptyValue(v reflect.Value) bool {
 	switch v.Kind() {
 	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
@@ -197,21 +544,110 @@ func isEmptyValue(v reflect.Value) bool {
 type context[T any] struct {
 	structName string
 	field      field[T]
+	depth      int    // nesting depth of the struct being processed
+	maxDepth   int    // see Config.MaxDepth
+	path       string // path of the struct being processed, tracked only if paths is set
+	paths      bool
+	structs    []string // the outermost struct and the fields holding the struct being processed, see structPath
 	err        error
 }
 
+// nest processes a nested struct with the function f and restores the context of the enclosing struct.
+// A struct nested deeper than the maximum depth fails with ErrMaxDepth, see Config.MaxDepth.
+// An error is passed to fail before, so that it is reported with the context of the field that failed.
+func (c *context[T]) nest(fail func(err error), f func() error) error {
+	structName, fld, path := c.structName, c.field, c.path
+	c.depth++
+	if c.paths {
+		c.path = c.fieldPath()
+	}
+	var err error
+	if c.maxDepth > 0 && c.depth > c.maxDepth {
+		err = fmt.Errorf("%w of %d", ErrMaxDepth, c.maxDepth)
+	} else {
+		err = f()
+	}
+	if err != nil {
+		fail(err)
+		err = errExist
+	}
+	c.depth--
+	c.structName, c.field, c.path = structName, fld, path
+	return err
+}
+
+// fieldPath returns the path of the current field, the names of the fields it is nested in and its own name
+// separated by dots. The elements of a slice share the path.
+func (c *context[T]) fieldPath() string {
+	switch {
+	case c.path == "":
+		return c.field.name
+	case c.field.name == "":
+		return c.path
+	default:
+		return c.path + "." + c.field.name
+	}
+}
+
+// enter starts processing the struct of the type t, the outermost struct is recorded by the name of its type
+// and the others by the names of the fields holding them, embedded structs included.
+// It returns the name of the enclosing struct that leave restores.
+func (c *context[T]) enter(t reflect.Type) (structName string) {
+	structName, c.structName = c.structName, t.Name()
+	if len(c.structs) == 0 {
+		c.structs = append(c.structs, t.Name())
+	} else {
+		c.structs = append(c.structs, c.field.name)
+	}
+	return structName
+}
+
+// leave ends processing the struct when it succeeds, the context is kept for the error otherwise, see nest.
+func (c *context[T]) leave(structName string) {
+	c.structs = c.structs[:len(c.structs)-1]
+	c.structName = structName
+}
+
+// structPath returns the path of the current field from the outermost struct, e.g. "Outer.Inner.Leaf".
+func (c *context[T]) structPath() string {
+	if len(c.structs) == 0 {
+		return c.structName + "." + c.field.name
+	}
+
+	var b strings.Builder
+	for _, name := range c.structs {
+		if name != "" {
+			b.WriteString(name)
+			b.WriteByte('.')
+		}
+	}
+	b.WriteString(c.field.name)
+	return b.String()
+}
+
+// setError sets the error of the current field, an *EncodeError if the state is marshalError
+// and a *DecodeError otherwise.
 func (c *context[T]) setError(tagName, state string, err error) {
+	var structType, fieldName, path string
+	if c.structName != "" {
+		structType, fieldName, path = c.structName, c.field.name, c.structPath()
+	}
 	err = unwrapErr(err)
-	if c.structName == "" {
-		c.err = fmt.Errorf("%s: cannot %s Go value of type %s: %w", tagName, state, c.field.typ, err)
+
+	if state == marshalError {
+		c.err = &EncodeError{TagName: tagName, StructType: structType, FieldName: fieldName, Path: path, FieldType: c.field.typ, Err: err}
 	} else {
-		c.err = fmt.Errorf("%s: cannot %s Go struct field %s.%s of type %s: %w", tagName, state, c.structName, c.field.name, c.field.typ, err)
+		c.err = &DecodeError{TagName: tagName, StructType: structType, FieldName: fieldName, Path: path, FieldType: c.field.typ, Err: err}
 	}
 }
 
+// unwrapErr returns the error wrapped by err, if any. The errors of the limits, the cycles and the control
+// characters are kept, their messages tell the limit exceeded, the type of the cycle or the character found,
+// e.g. "... of 3" for ErrMaxDepth.
 func unwrapErr(err error) error {
-	if ew := errors.Unwrap(err); ew != nil {
-		return ew
+	ew := errors.Unwrap(err)
+	if ew == nil || ew == ErrMaxDepth || ew == ErrLimit || ew == ErrCycle || ew == ErrControlChar {
+		return err
 	}
-	return err
+	return ew
 }
@@ -1,10 +1,14 @@
 package engine
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
 	"math/bits"
 	"reflect"
+	"sync"
 	"testing"
+	"time"
 )
 
 func equal(t *testing.T, exp, got interface{}) {
@@ -13,6 +17,135 @@ func equal(t *testing.T, exp, got interface{}) {
 	}
 }
 
+// testMeta is the parsed tag of the test engines, the value of the tag as it is.
+type testMeta struct {
+	value string
+}
+
+func (m *testMeta) parse(tagValue string) (bool, error) {
+	m.value = tagValue
+	return tagValue == "omitempty", nil
+}
+
+// testParser is the interface implemented by the parsed tags of testTag parsing the tag values themselves,
+// so that the tests declare the options of the fields with their own parsed tags.
+type testParser interface {
+	parse(tagValue string) (omitEmpty bool, err error)
+}
+
+// testTag writes the values as they are, the library splits the data itself, see testConfig.
+type testTag[T any] struct {
+	Default[T]
+}
+
+func (testTag[T]) Name() string {
+	return "test"
+}
+
+func (testTag[T]) Skip(tagValue string) bool {
+	return tagValue == "-"
+}
+
+func (testTag[T]) Parse(tagValue string, tag *T) (bool, error) {
+	if p, ok := any(tag).(testParser); ok {
+		return p.parse(tagValue)
+	}
+	return false, nil
+}
+
+func (testTag[T]) Encode(_ string, _ *T, in []byte, out Writer) error {
+	_, err := out.Write(in)
+	return err
+}
+
+func (testTag[T]) Decode(_ string, _ *T, in []byte, out Writer) error {
+	_, err := out.Write(in)
+	return err
+}
+
+func (testTag[T]) IsMarshaller(reflect.Value) (func() ([]byte, error), bool) {
+	return nil, false
+}
+
+func (testTag[T]) IsUnmarshaler(reflect.Value) (func([]byte) error, bool) {
+	return nil, false
+}
+
+// keyTag writes the values with the keys of their fields as "key=value", see KeyedTag.
+type keyTag[T any] struct {
+	testTag[T]
+}
+
+func (keyTag[T]) Encode(fieldName string, _ *T, in []byte, out Writer) error {
+	if _, err := out.WriteString(fieldName + "="); err != nil {
+		return err
+	}
+	_, err := out.Write(in)
+	return err
+}
+
+func (keyTag[T]) CutKey(value []byte) (string, []byte, bool) {
+	key, rest, ok := bytes.Cut(value, []byte("="))
+	return string(key), rest, ok
+}
+
+// nameMeta is a parsed tag renaming its field with the value of the tag, see Renamer.
+type nameMeta struct {
+	name string
+}
+
+func (m *nameMeta) parse(tagValue string) (bool, error) {
+	m.name = tagValue
+	return false, nil
+}
+
+func (m *nameMeta) Rename() string {
+	return m.name
+}
+
+type testMarshaller interface {
+	MarshalTest() ([]byte, error)
+}
+
+type testUnmarshaler interface {
+	UnmarshalTest([]byte) error
+}
+
+// testConfig returns the configuration of testTag separating the values with ',' and the components
+// of composite values with ':', so that the library splits the data itself when decoding.
+func testConfig() Config {
+	return Config{
+		ValueSeparator:     []byte(","),
+		ComponentSeparator: []byte(":"),
+		Marshaller:         reflect.TypeOf((*testMarshaller)(nil)).Elem(),
+		Unmarshaler:        reflect.TypeOf((*testUnmarshaler)(nil)).Elem(),
+	}
+}
+
+// newTestEngine returns an engine of testTag parsing the tags into testMeta
+// with the configuration changed by the function.
+func newTestEngine(configure func(cfg *Config)) Engine {
+	return newEngineOf[testMeta](configure)
+}
+
+// newEngineOf returns an engine of testTag parsing the tags into T with the configuration changed by the function.
+func newEngineOf[T any](configure func(cfg *Config)) Engine {
+	cfg := testConfig()
+	if configure != nil {
+		configure(&cfg)
+	}
+	return New[T](testTag[T]{}, cfg)
+}
+
+// newKeyEngine returns an engine of keyTag parsing the tags into T with the configuration changed by the function.
+func newKeyEngine[T any](configure func(cfg *Config)) Engine {
+	cfg := testConfig()
+	if configure != nil {
+		configure(&cfg)
+	}
+	return New[T](keyTag[T]{}, cfg)
+}
+
 func Test_bitSize(t *testing.T) {
 	var tests = []struct {
 		reflectKind reflect.Kind
@@ -133,14 +266,46 @@ func Test_isEmptyValue(t *testing.T) {
 	}
 }
 
+func Test_settingsIsEmpty(t *testing.T) {
+	var tests = []struct {
+		value  any
+		expect bool
+	}{
+		{
+			value:  time.Time{},
+			expect: true,
+		},
+		{
+			value:  time.Unix(1, 0),
+			expect: false,
+		},
+		{
+			value:  (*time.Time)(nil),
+			expect: true,
+		},
+		{
+			value:  &time.Time{},
+			expect: true,
+		},
+		{
+			value:  struct{}{},
+			expect: false,
+		},
+	}
+	for _, tt := range tests {
+		b := new(settings).isEmpty(reflect.ValueOf(tt.value))
+		equal(t, tt.expect, b)
+	}
+}
+
 type empty struct{}
 
 func Test_contextSetError(t *testing.T) {
 	name := "tagName"
-	str := "marshal/unmarshal"
 
 	var tests = []struct {
 		ctx    context[empty]
+		state  string
 		expect error
 	}{
 		{
@@ -152,7 +317,15 @@ func Test_contextSetError(t *testing.T) {
 				},
 				err: ErrNotSupportType,
 			},
-			expect: errors.New("tagName: cannot marshal/unmarshal Go struct field structName.fieldName of type bool: cannot support type"),
+			state: marshalError,
+			expect: &EncodeError{
+				TagName:    "tagName",
+				StructType: "structName",
+				FieldName:  "fieldName",
+				Path:       "structName.fieldName",
+				FieldType:  reflect.TypeOf(true),
+				Err:        ErrNotSupportType,
+			},
 		},
 		{
 			ctx: context[empty]{
@@ -163,15 +336,100 @@ func Test_contextSetError(t *testing.T) {
 				},
 				err: ErrNotSupportType,
 			},
-			expect: errors.New("tagName: cannot marshal/unmarshal Go value of type bool: cannot support type"),
+			state: unmarshalError,
+			expect: &DecodeError{
+				TagName:   "tagName",
+				FieldType: reflect.TypeOf(true),
+				Err:       ErrNotSupportType,
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt.ctx.setError(name, tt.state, tt.ctx.err)
+		equal(t, tt.expect, tt.ctx.err)
+	}
+
+	ctx := tests[1].ctx
+	ctx.setError(name, unmarshalError, ctx.err)
+
+	var de *DecodeError
+	err := fmt.Errorf("wrapped: %w", ctx.err)
+	equal(t, true, errors.As(err, &de))
+	equal(t, true, errors.Is(err, ErrNotSupportType))
+	equal(t, "tagName: cannot decode data into Go value of type bool: cannot support type", de.Error())
+
+	de.Offset, de.Line, de.Column = 12, 2, 5
+	equal(t, "tagName: cannot decode data into Go value of type bool at offset 12 (line 2, column 5): cannot support type", de.Error())
+}
+
+func Test_isList(t *testing.T) {
+	type composite struct{ A int }
+	type custom []int
+	e := &engine[struct{}]{
+		unmarshaler:    reflect.TypeOf((*interface{ UnmarshalTest([]byte) error })(nil)).Elem(),
+		customEncoders: new(sync.Map),
+		customDecoders: new(sync.Map),
+	}
+	e.customEncoders.Store(reflect.TypeOf(custom{}), EncoderFunc(nil))
+
+	var tests = []struct {
+		value  any
+		expect bool
+	}{
+		{
+			value:  []int{},
+			expect: true,
+		},
+		{
+			value:  &[]string{},
+			expect: true,
+		},
+		{
+			value:  [][]int{},
+			expect: true,
+		},
+		{
+			value:  []byte{},
+			expect: false,
+		},
+		{
+			value:  [2]int{},
+			expect: true,
+		},
+		{
+			value:  [4]byte{},
+			expect: false,
+		},
+		{
+			value:  map[string]int{},
+			expect: true,
+		},
+		{
+			value:  []composite{},
+			expect: false,
+		},
+		{
+			value:  [][]*composite{},
+			expect: false,
+		},
+		{
+			value:  map[string]composite{},
+			expect: false,
+		},
+		{
+			value:  map[string]map[string]int{},
+			expect: true,
+		},
+		{
+			value:  custom{},
+			expect: false,
+		},
+		{
+			value:  "",
+			expect: false,
 		},
 	}
 	for _, tt := range tests {
-		tt.ctx.setError(name, str, tt.ctx.err)
-		if tt.expect != nil {
-			equal(t, tt.expect.Error(), tt.ctx.err.Error())
-		} else {
-			equal(t, nil, tt.ctx.err)
-		}
+		equal(t, tt.expect, e.isList(reflect.TypeOf(tt.value)))
 	}
 }
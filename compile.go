@@ -0,0 +1,139 @@
+package engine
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// A Codec encodes and decodes values of the type it was compiled for, see Compile.
+type Codec interface {
+	// Type returns the type the codec was compiled for.
+	Type() reflect.Type
+	// Marshal encodes the value v of the type, or a pointer to it.
+	Marshal(v any) ([]byte, error)
+	// Unmarshal decodes the encoded data into the value of the type v points to.
+	Unmarshal(data []byte, v any) error
+}
+
+type codec[T any] struct {
+	e   *engine[T]
+	typ reflect.Type
+}
+
+// Compile walks the type t and the types of its fields, resolves their encoders and decoders of the engine e
+// and parses their tags in advance, so that the first call of the codec it returns doesn't pay for it.
+// It returns an error if a tag or an accessor of a field is invalid or a type isn't supported,
+// ErrUnsupported if e doesn't have the Compile method.
+func Compile(e Engine, t reflect.Type) (Codec, error) {
+	c, ok := implementation[compiler](e)
+	if !ok {
+		return nil, unsupported(e, "Compile")
+	}
+	return c.Compile(t)
+}
+
+// compiler is implemented by the engines compiling codecs, see Compile.
+type compiler interface {
+	Compile(t reflect.Type) (Codec, error)
+}
+
+// Compile resolves the coders of the type in advance and returns the codec of the type, see the function Compile.
+func (e *engine[T]) Compile(t reflect.Type) (Codec, error) {
+	if err := e.compile(t, make(map[reflect.Type]bool)); err != nil {
+		return nil, err
+	}
+	return &codec[T]{e: e, typ: t}, nil
+}
+
+func (e *engine[T]) compile(t reflect.Type, seen map[reflect.Type]bool) error {
+	if seen[t] {
+		return nil
+	}
+	seen[t] = true
+
+	es := e.newEncodeState()
+	es.cache(t)
+	encodeStatePool.Put(es)
+
+	ds := e.newDecodeState()
+	ds.cache(t)
+	decodeStatePool.Put(ds)
+
+	if p := reflect.PointerTo(t); t.Kind() != reflect.Pointer && p.Implements(e.marshaller) && p.Implements(e.unmarshaler) {
+		return nil
+	}
+	if p := reflect.PointerTo(t); e.binary && t.Kind() != reflect.Pointer && p.Implements(binaryMarshalerType) &&
+		p.Implements(binaryUnmarshalerType) {
+		return nil
+	}
+	if e.isCustom(t) {
+		return nil
+	}
+
+	t = containerOf(t)
+
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array:
+		return e.compile(t.Elem(), seen)
+	case reflect.Map:
+		if !isMapSupported(t) {
+			return fmt.Errorf("%s: %w: %s", e.Name(), ErrNotSupportType, t)
+		}
+		return e.compile(t.Elem(), seen)
+	case reflect.Struct:
+		return e.compileFields(t, e.cachedFields(t), seen)
+	case reflect.Complex64, reflect.Complex128:
+		if ef, df := e.customCoders(t.Kind()); ef != nil && df != nil {
+			return nil
+		}
+		return fmt.Errorf("%s: %w: %s", e.Name(), ErrNotSupportType, t)
+	case reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		return fmt.Errorf("%s: %w: %s", e.Name(), ErrNotSupportType, t)
+	}
+	return nil
+}
+
+func (e *engine[T]) compileFields(t reflect.Type, fields structFields[T], seen map[reflect.Type]bool) error {
+	for _, fld := range fields {
+		switch {
+		case fld.extras:
+		case fld.embedded != nil:
+			if err := e.compileFields(t, fld.embedded, seen); err != nil {
+				return err
+			}
+		case fld.delegate != nil:
+			if c, ok := implementation[compiler](fld.delegate); ok {
+				if _, err := c.Compile(fld.typ); err != nil {
+					return err
+				}
+			}
+		case fld.err == ErrInvalidAccessor:
+			return fmt.Errorf("%s: struct field %s.%s: %w", e.Name(), t.Name(), fld.name, fld.err)
+		case fld.err != nil:
+			return fmt.Errorf("%s: tag %s of struct field %s.%s: %w", e.Name(), fld.tag, t.Name(), fld.name, fld.err)
+		default:
+			if err := e.compile(fld.typ, seen); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (c *codec[T]) Type() reflect.Type {
+	return c.typ
+}
+
+func (c *codec[T]) Marshal(v any) ([]byte, error) {
+	if t := reflect.TypeOf(v); t != c.typ && t != reflect.PointerTo(c.typ) {
+		return nil, fmt.Errorf("%s: %w: %s", c.e.Name(), ErrCodecType, t)
+	}
+	return c.e.Marshal(v)
+}
+
+func (c *codec[T]) Unmarshal(data []byte, v any) error {
+	if t := reflect.TypeOf(v); t != reflect.PointerTo(c.typ) {
+		return fmt.Errorf("%s: %w: %s", c.e.Name(), ErrCodecType, t)
+	}
+	return c.e.Unmarshal(data, v)
+}
@@ -0,0 +1,69 @@
+package engine
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCompile(t *testing.T) {
+	e := newTestEngine(nil)
+
+	c, err := Compile(e, reflect.TypeOf(nameAndAddress{}))
+	equal(t, nil, err)
+	equal(t, reflect.TypeOf(nameAndAddress{}), c.Type())
+
+	value := nameAndAddress{Qualifier: "BY", Party: party{ID: "1", Agency: 9}, Agent: &party{ID: "2"}, Name: "ACME"}
+	b, err := c.Marshal(value)
+	equal(t, nil, err)
+	equal(t, "BY,1:9:,2:0:,ACME", string(b))
+	b2, err := c.Marshal(&value)
+	equal(t, nil, err)
+	equal(t, string(b), string(b2))
+
+	var got nameAndAddress
+	equal(t, nil, c.Unmarshal(b, &got))
+	equal(t, value, got)
+
+	// The codec only takes the type it was compiled for.
+	_, err = c.Marshal(party{})
+	equal(t, true, errors.Is(err, ErrCodecType))
+	equal(t, true, errors.Is(c.Unmarshal(b, got), ErrCodecType))
+	equal(t, true, errors.Is(c.Unmarshal(b, &party{}), ErrCodecType))
+
+	_, err = Compile(foreignEngine{e}, reflect.TypeOf(nameAndAddress{}))
+	equal(t, true, errors.Is(err, ErrUnsupported))
+}
+
+func TestCompileErrors(t *testing.T) {
+	type nested struct {
+		C chan int
+	}
+	e := newTestEngine(nil)
+
+	var tests = []struct {
+		value  any
+		expect error
+	}{
+		{
+			value:  struct{ A []nested }{},
+			expect: ErrNotSupportType,
+		},
+		{
+			value:  struct{ M map[[2]int]string }{},
+			expect: ErrNotSupportType,
+		},
+		{
+			value:  struct{ C complex64 }{},
+			expect: ErrNotSupportType,
+		},
+		{
+			value:  struct{ F func() }{},
+			expect: ErrNotSupportType,
+		},
+	}
+	for _, tt := range tests {
+		_, err := Compile(e, reflect.TypeOf(tt.value))
+		equal(t, true, errors.Is(err, tt.expect))
+	}
+}
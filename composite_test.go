@@ -0,0 +1,172 @@
+package engine
+
+import (
+	"testing"
+)
+
+type party struct {
+	ID     string
+	Agency int
+	Code   string
+}
+
+type nameAndAddress struct {
+	Qualifier string
+	Party     party
+	Agent     *party
+	Name      string
+}
+
+func TestComponentSeparator(t *testing.T) {
+	e := newTestEngine(func(cfg *Config) {
+		cfg.ValueSeparator, cfg.ComponentSeparator = []byte("+"), []byte(":")
+	})
+
+	var tests = []struct {
+		value nameAndAddress
+		data  string
+	}{
+		{
+			value: nameAndAddress{Qualifier: "BY", Party: party{ID: "5412345000176", Agency: 9}, Agent: &party{}, Name: "ACME"},
+			data:  "BY+5412345000176:9:+:0:+ACME",
+		},
+		{
+			value: nameAndAddress{Qualifier: "SU", Party: party{ID: "1", Code: "c"}, Agent: &party{ID: "2", Agency: 3, Code: "d"}},
+			data:  "SU+1:0:c+2:3:d+",
+		},
+	}
+	for _, tt := range tests {
+		b, err := e.Marshal(tt.value)
+		equal(t, nil, err)
+		equal(t, tt.data, string(b))
+
+		var got nameAndAddress
+		equal(t, nil, e.Unmarshal(b, &got))
+		equal(t, tt.value, got)
+	}
+
+	// The missing trailing components and values are left empty.
+	var got nameAndAddress
+	equal(t, nil, e.Unmarshal([]byte("BY+1:5"), &got))
+	equal(t, nameAndAddress{Qualifier: "BY", Party: party{ID: "1", Agency: 5}}, got)
+}
+
+type segment struct {
+	ID     string
+	Field  segmentField
+	Repeat string
+}
+
+type segmentField struct {
+	Value     string
+	Component component
+}
+
+type component struct {
+	A, B string
+}
+
+func TestSeparators(t *testing.T) {
+	e := newTestEngine(func(cfg *Config) {
+		cfg.Separators = [][]byte{[]byte("|"), []byte("^"), []byte("&")}
+	})
+
+	var tests = []struct {
+		value segment
+		data  string
+	}{
+		{
+			value: segment{ID: "PID", Field: segmentField{Value: "x", Component: component{"a", "b"}}, Repeat: "r"},
+			data:  "PID|x^a&b|r",
+		},
+		{
+			value: segment{ID: "PID"},
+			data:  "PID|^&|",
+		},
+	}
+	for _, tt := range tests {
+		b, err := e.Marshal(tt.value)
+		equal(t, nil, err)
+		equal(t, tt.data, string(b))
+
+		var got segment
+		equal(t, nil, e.Unmarshal(b, &got))
+		equal(t, tt.value, got)
+	}
+
+	// The Separators replace the ValueSeparator and the ComponentSeparator, the deeper levels
+	// without their own separators use the last one.
+	e = newTestEngine(func(cfg *Config) {
+		cfg.Separators = [][]byte{[]byte("|"), []byte("^")}
+	})
+	b, err := e.Marshal(tests[0].value)
+	equal(t, nil, err)
+	equal(t, "PID|x^a^b|r", string(b))
+}
+
+func TestEscapeChar(t *testing.T) {
+	e := newTestEngine(func(cfg *Config) {
+		cfg.ValueSeparator, cfg.EscapeChar = []byte("+"), '?'
+	})
+
+	var tests = []struct {
+		value nameAndAddress
+		data  string
+	}{
+		{
+			value: nameAndAddress{Qualifier: "B+Y", Party: party{ID: "a:b", Code: "?"}, Agent: &party{}, Name: "x'y"},
+			data:  "B?+Y+a?:b:0:??+:0:+x'y",
+		},
+		{
+			value: nameAndAddress{Qualifier: "??", Party: party{ID: "+:"}, Agent: &party{Code: "::"}},
+			data:  "????+?+?::0:+:0:?:?:+",
+		},
+	}
+	for _, tt := range tests {
+		b, err := e.Marshal(tt.value)
+		equal(t, nil, err)
+		equal(t, tt.data, string(b))
+
+		var got nameAndAddress
+		equal(t, nil, e.Unmarshal(b, &got))
+		equal(t, tt.value, got)
+	}
+
+	// The escape character releases any byte following it.
+	var got nameAndAddress
+	equal(t, nil, e.Unmarshal([]byte("a?b+1"), &got))
+	equal(t, nameAndAddress{Qualifier: "ab", Party: party{ID: "1"}}, got)
+}
+
+func TestTrimTrailingEmpty(t *testing.T) {
+	e := newTestEngine(func(cfg *Config) {
+		cfg.ValueSeparator, cfg.TrimTrailingEmpty = []byte("+"), true
+	})
+
+	var tests = []struct {
+		value nameAndAddress
+		data  string
+	}{
+		{
+			value: nameAndAddress{Qualifier: "BY", Party: party{ID: "1", Agency: 2}, Agent: &party{Agency: 3}, Name: "ACME"},
+			data:  "BY+1:2+:3+ACME",
+		},
+		{
+			value: nameAndAddress{Qualifier: "BY", Agent: &party{Agency: 3}},
+			data:  "BY+:0+:3",
+		},
+		{
+			value: nameAndAddress{Agent: &party{ID: "x"}},
+			data:  "+:0+x:0",
+		},
+	}
+	for _, tt := range tests {
+		b, err := e.Marshal(tt.value)
+		equal(t, nil, err)
+		equal(t, tt.data, string(b))
+
+		var got nameAndAddress
+		equal(t, nil, e.Unmarshal(b, &got))
+		equal(t, tt.value, got)
+	}
+}
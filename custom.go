@@ -0,0 +1,166 @@
+package engine
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// EncoderFunc returns the encoded value v of the type it's registered for, see RegisterEncoder.
+// The encoded value is escaped and passed to Tag.Encode like any other value.
+type EncoderFunc func(v reflect.Value) ([]byte, error)
+
+// DecoderFunc decodes the data into the settable value v of the type it's registered for, see RegisterDecoder.
+// The data is the value returned by Tag.Decode.
+type DecoderFunc func(data []byte, v reflect.Value) error
+
+// RegisterEncoder makes the engine e, its profiles and dialects encode the values of the type t with f
+// instead of the coders the library chooses for the type, t is encoded as a single value.
+// The caches are reset, so the types encoded before pick f up.
+// It returns ErrUnsupported if e doesn't have the RegisterEncoder method.
+func RegisterEncoder(e Engine, t reflect.Type, f EncoderFunc) error {
+	r, ok := implementation[registry](e)
+	if !ok {
+		return unsupported(e, "RegisterEncoder")
+	}
+	r.RegisterEncoder(t, f)
+	return nil
+}
+
+// RegisterDecoder makes the engine e, its profiles and dialects decode the values of the type t with f
+// instead of the coders the library chooses for the type, t is decoded as a single value.
+// The caches are reset, so the types decoded before pick f up.
+// It returns ErrUnsupported if e doesn't have the RegisterDecoder method.
+func RegisterDecoder(e Engine, t reflect.Type, f DecoderFunc) error {
+	r, ok := implementation[registry](e)
+	if !ok {
+		return unsupported(e, "RegisterDecoder")
+	}
+	r.RegisterDecoder(t, f)
+	return nil
+}
+
+// registry is implemented by the engines taking custom coders, see RegisterEncoder and RegisterDecoder.
+type registry interface {
+	RegisterEncoder(t reflect.Type, f EncoderFunc)
+	RegisterDecoder(t reflect.Type, f DecoderFunc)
+}
+
+// RegisterEncoder makes the engine encode the values of the type with f, see the function RegisterEncoder.
+func (e *engine[T]) RegisterEncoder(t reflect.Type, f EncoderFunc) {
+	e.customEncoders.Store(t, f)
+	e.resetCaches()
+}
+
+// RegisterDecoder makes the engine decode the values of the type with f, see the function RegisterDecoder.
+func (e *engine[T]) RegisterDecoder(t reflect.Type, f DecoderFunc) {
+	e.customDecoders.Store(t, f)
+	e.resetCaches()
+}
+
+// RegisterKindEncoder makes the engine e, its profiles and dialects encode the values of the kind k with f
+// instead of the built-in coder of the kind, unless their type has its own encoder, see RegisterEncoder,
+// or implements the Marshaller. Only the kinds of single values, booleans, numbers and strings, may be overridden,
+// otherwise ErrNotSupportType is returned. It returns ErrUnsupported if e doesn't have the RegisterKindEncoder method.
+func RegisterKindEncoder(e Engine, k reflect.Kind, f EncoderFunc) error {
+	r, ok := implementation[kindRegistry](e)
+	if !ok {
+		return unsupported(e, "RegisterKindEncoder")
+	}
+	return r.RegisterKindEncoder(k, f)
+}
+
+// RegisterKindDecoder makes the engine e, its profiles and dialects decode the values of the kind k with f
+// instead of the built-in coder of the kind, unless their type has its own decoder, see RegisterDecoder,
+// or implements the Unmarshaler. Only the kinds of single values, booleans, numbers and strings, may be overridden,
+// otherwise ErrNotSupportType is returned. It returns ErrUnsupported if e doesn't have the RegisterKindDecoder method.
+func RegisterKindDecoder(e Engine, k reflect.Kind, f DecoderFunc) error {
+	r, ok := implementation[kindRegistry](e)
+	if !ok {
+		return unsupported(e, "RegisterKindDecoder")
+	}
+	return r.RegisterKindDecoder(k, f)
+}
+
+// kindRegistry is implemented by the engines taking custom coders of kinds,
+// see RegisterKindEncoder and RegisterKindDecoder.
+type kindRegistry interface {
+	RegisterKindEncoder(k reflect.Kind, f EncoderFunc) error
+	RegisterKindDecoder(k reflect.Kind, f DecoderFunc) error
+}
+
+// RegisterKindEncoder makes the engine encode the values of the kind with f, see the function RegisterKindEncoder.
+func (e *engine[T]) RegisterKindEncoder(k reflect.Kind, f EncoderFunc) error {
+	if !isSingleKind(k) {
+		return fmt.Errorf("%s: %w: %s", e.Name(), ErrNotSupportType, k)
+	}
+	e.customEncoders.Store(k, f)
+	e.resetCaches()
+	return nil
+}
+
+// RegisterKindDecoder makes the engine decode the values of the kind with f, see the function RegisterKindDecoder.
+func (e *engine[T]) RegisterKindDecoder(k reflect.Kind, f DecoderFunc) error {
+	if !isSingleKind(k) {
+		return fmt.Errorf("%s: %w: %s", e.Name(), ErrNotSupportType, k)
+	}
+	e.customDecoders.Store(k, f)
+	e.resetCaches()
+	return nil
+}
+
+// isSingleKind reports whether the values of the kind are single values the coders of the kind may be overridden for.
+func isSingleKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return true
+	}
+	return false
+}
+
+// resetCaches drops the cached fields and coders of all types.
+func (e *engine[T]) resetCaches() {
+	for _, m := range []*sync.Map{e.fields, e.encoders, e.decoders} {
+		m.Range(func(key, _ any) bool {
+			m.Delete(key)
+			return true
+		})
+	}
+}
+
+// isCustom reports whether a custom encoder or decoder is registered for the type.
+func (e *engine[T]) isCustom(t reflect.Type) bool {
+	_, enc := e.customEncoders.Load(t)
+	_, dec := e.customDecoders.Load(t)
+	return enc || dec
+}
+
+// customCoders returns the coders registered for the type or the kind, the key, nil if there are none.
+func (e *engine[T]) customCoders(key any) (ef encoderFunc[T], df decoderFunc[T]) {
+	if f, ok := e.customEncoders.Load(key); ok {
+		ef = customEncoder[T](f.(EncoderFunc))
+	}
+	if f, ok := e.customDecoders.Load(key); ok {
+		df = customDecoder[T](f.(DecoderFunc))
+	}
+	return
+}
+
+func customEncoder[T any](f EncoderFunc) encoderFunc[T] {
+	return func(s *encodeState[T], v reflect.Value) error {
+		p, err := f(v)
+		if err != nil {
+			return err
+		}
+		return s.encodeValue(p)
+	}
+}
+
+func customDecoder[T any](f DecoderFunc) decoderFunc[T] {
+	return func(s *decodeState[T], v reflect.Value) error {
+		return f(s.Bytes(), v)
+	}
+}
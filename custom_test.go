@@ -0,0 +1,102 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+// stamp has no exported fields, it's encoded as "sec.nsec" by the registered coders.
+type stamp struct {
+	sec, nsec int
+}
+
+func encodeStamp(v reflect.Value) ([]byte, error) {
+	s := v.Interface().(stamp)
+	return []byte(fmt.Sprintf("%d.%d", s.sec, s.nsec)), nil
+}
+
+func decodeStamp(data []byte, v reflect.Value) error {
+	var s stamp
+	if _, err := fmt.Sscanf(string(data), "%d.%d", &s.sec, &s.nsec); err != nil {
+		return err
+	}
+	v.Set(reflect.ValueOf(s))
+	return nil
+}
+
+func TestRegisterCoders(t *testing.T) {
+	type record struct {
+		A stamp
+		B []stamp
+		C string
+	}
+	e := newTestEngine(func(cfg *Config) { cfg.ElementSeparator = []byte("~") })
+	value := record{A: stamp{1, 2}, B: []stamp{{3, 4}, {5, 6}}, C: "c"}
+
+	b, err := e.Marshal(value)
+	equal(t, nil, err)
+	equal(t, ",~,c", string(b))
+
+	// The types encoded before pick the registered coders up.
+	equal(t, nil, RegisterEncoder(e, reflect.TypeOf(stamp{}), encodeStamp))
+	equal(t, nil, RegisterDecoder(e, reflect.TypeOf(stamp{}), decodeStamp))
+	b, err = e.Marshal(value)
+	equal(t, nil, err)
+	equal(t, "1.2,3.4~5.6,c", string(b))
+
+	var got record
+	equal(t, nil, e.Unmarshal(b, &got))
+	equal(t, value, got)
+
+	_, err = Compile(e, reflect.TypeOf(record{}))
+	equal(t, nil, err)
+
+	equal(t, true, e.Unmarshal([]byte("1.2,x,c"), &got) != nil)
+}
+
+func TestRegisterKindCoders(t *testing.T) {
+	type celsius float64
+	type record struct {
+		A float64
+		B complex128
+		C celsius
+		D stamp
+	}
+	e := newTestEngine(nil)
+	equal(t, nil, RegisterEncoder(e, reflect.TypeOf(stamp{}), encodeStamp))
+	equal(t, nil, RegisterDecoder(e, reflect.TypeOf(stamp{}), decodeStamp))
+
+	// Only the kinds of single values may be overridden.
+	equal(t, true, errors.Is(RegisterKindEncoder(e, reflect.Struct, encodeStamp), ErrNotSupportType))
+	equal(t, true, errors.Is(RegisterKindDecoder(e, reflect.Slice, decodeStamp), ErrNotSupportType))
+
+	equal(t, nil, RegisterKindEncoder(e, reflect.Float64, func(v reflect.Value) ([]byte, error) {
+		return strconv.AppendFloat(nil, v.Float(), 'f', 2, 64), nil
+	}))
+	equal(t, nil, RegisterKindEncoder(e, reflect.Complex128, func(v reflect.Value) ([]byte, error) {
+		return []byte(strconv.FormatComplex(v.Complex(), 'g', -1, 128)), nil
+	}))
+	equal(t, nil, RegisterKindDecoder(e, reflect.Complex128, func(data []byte, v reflect.Value) error {
+		c, err := strconv.ParseComplex(string(data), 128)
+		v.SetComplex(c)
+		return err
+	}))
+
+	value := record{A: 1, B: 2 + 3i, C: 4.5, D: stamp{1, 2}}
+	b, err := e.Marshal(value)
+	equal(t, nil, err)
+	equal(t, "1.00,(2+3i),4.50,1.2", string(b))
+
+	var got record
+	equal(t, nil, e.Unmarshal(b, &got))
+	equal(t, value, got)
+
+	_, err = Compile(e, reflect.TypeOf(record{}))
+	equal(t, nil, err)
+
+	err = RegisterKindEncoder(foreignEngine{e}, reflect.Float64, encodeStamp)
+	equal(t, true, errors.Is(err, ErrUnsupported))
+}
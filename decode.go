@@ -2,11 +2,13 @@ package engine
 
 import (
 	"bytes"
+	"encoding"
 	"errors"
 	"fmt"
 	"reflect"
 	"strconv"
 	"sync"
+	"time"
 )
 
 const unmarshalError = "decode data into"
@@ -14,41 +16,224 @@ const unmarshalError = "decode data into"
 // Unmarshal decodes the encoded data and stores the result in the value pointed to by v.
 // If v is nil or not a pointer, Unmarshal returns a decoder error.
 func (e *engine[T]) Unmarshal(data []byte, v any) (err error) {
+	return e.unmarshalWith(data, v, decodeOptions{})
+}
+
+// UnmarshalSpans is like Unmarshal with the engine e but reports the offsets of the value of every field in the data
+// to the spans function, the path of a field is the names of the fields it is nested in and its own name
+// separated by dots. Spans are reported when the library splits the data itself, see Config.ComponentSeparator.
+// It returns ErrUnsupported if e doesn't have the UnmarshalSpans method.
+func UnmarshalSpans(e Engine, data []byte, v any, spans func(path string, start, end int)) error {
+	if u, ok := e.(spanner); ok {
+		return u.UnmarshalSpans(data, v, spans)
+	}
+	return unsupported(e, "UnmarshalSpans")
+}
+
+// spanner is implemented by the engines reporting the offsets of the decoded values, see UnmarshalSpans.
+type spanner interface {
+	UnmarshalSpans(data []byte, v any, spans func(path string, start, end int)) error
+}
+
+// UnmarshalSpans is like Unmarshal but reports the offsets of the values of the fields in the data to the spans
+// function, see the function UnmarshalSpans.
+func (e *engine[T]) UnmarshalSpans(data []byte, v any, spans func(path string, start, end int)) error {
+	return e.unmarshalWith(data, v, decodeOptions{spans: spans})
+}
+
+// UnmarshalNoCopy is like Unmarshal with the engine e but doesn't copy the data before decoding, the caller guarantees
+// that the data isn't modified until it returns. The Tag mustn't modify the data it gets either.
+// An engine without the UnmarshalNoCopy method decodes the data with Unmarshal.
+func UnmarshalNoCopy(e Engine, data []byte, v any) error {
+	if u, ok := e.(interface{ UnmarshalNoCopy([]byte, any) error }); ok {
+		return u.UnmarshalNoCopy(data, v)
+	}
+	return e.Unmarshal(data, v)
+}
+
+// UnmarshalNoCopy is like Unmarshal but decodes the data without copying it, see the function UnmarshalNoCopy.
+func (e *engine[T]) UnmarshalNoCopy(data []byte, v any) error {
+	return e.unmarshalWith(data, v, decodeOptions{noCopy: true})
+}
+
+// UnmarshalZero is like Unmarshal with the engine e but sets the value pointed to by v to its zero value
+// before decoding, like Config.ZeroBeforeDecoding does for every call. An engine without the UnmarshalZero method
+// decodes the data with Unmarshal into the value set to zero.
+func UnmarshalZero(e Engine, data []byte, v any) error {
+	if u, ok := e.(interface{ UnmarshalZero([]byte, any) error }); ok {
+		return u.UnmarshalZero(data, v)
+	}
+	zero(reflect.ValueOf(v))
+	return e.Unmarshal(data, v)
+}
+
+// UnmarshalZero is like Unmarshal but sets the value pointed to by v to zero first, see the function UnmarshalZero.
+func (e *engine[T]) UnmarshalZero(data []byte, v any) error {
+	return e.unmarshalWith(data, v, decodeOptions{zero: true})
+}
+
+// zero sets the value the pointer v points to to its zero value, other values are left as they are.
+func zero(v reflect.Value) {
+	if v.Kind() == reflect.Pointer && !v.IsNil() {
+		v.Elem().Set(reflect.Zero(v.Type().Elem()))
+	}
+}
+
+// decodeOptions are the options of decoding a single value.
+type decodeOptions struct {
+	noCopy bool                              // the data isn't copied, see UnmarshalNoCopy
+	zero   bool                              // the value is set to zero first, see UnmarshalZero
+	stats  *Stats                            // collected by a Decoder, see Decoder.CollectStats
+	spans  func(path string, start, end int) // see UnmarshalSpans
+	rest   *[]byte                           // gets the data left after decoding, see Dispatch
+}
+
+func (e *engine[T]) unmarshalWith(data []byte, v any, opts decodeOptions) error {
+	return e.unmarshalValue(data, reflect.ValueOf(v), opts)
+}
+
+func (e *engine[T]) unmarshalValue(data []byte, v reflect.Value, opts decodeOptions) error {
 	s := e.newDecodeState()
 	defer decodeStatePool.Put(s)
 
-	s.data = make([]byte, len(data))
-	copy(s.data, data)
+	if opts.noCopy {
+		s.data = data
+	} else {
+		s.data = make([]byte, len(data))
+		copy(s.data, data)
+	}
+	s.input, s.rebuilt = s.data, nil
+	s.pos = -1
+	s.decodeOptions = opts
+	s.paths = opts.spans != nil || opts.stats != nil || s.logger != nil
 
+	if opts.zero || s.zeroFirst {
+		zero(v)
+	}
 	s.unmarshal(v)
+	if opts.rest != nil {
+		*opts.rest = s.data
+	} else if s.err == nil && s.noTrailing {
+		s.checkTrailing(v.Type())
+	}
+	if s.err != nil && s.logger != nil && !s.logged {
+		s.logError(s.err)
+	}
+	if s.errs != nil {
+		return errors.Join(append(s.errs, s.err)...)
+	}
 	return s.err
 }
 
+// checkTrailing sets the error if data is left after the struct a value of the type t points to is decoded,
+// see Config.DisallowTrailingData.
+func (s *decodeState[T]) checkTrailing(t reflect.Type) {
+	if !s.split || len(s.data) == 0 || t.Kind() != reflect.Pointer || !s.isComposite(t.Elem()) {
+		return
+	}
+
+	trailing := s.data
+	if len(trailing) > 32 {
+		trailing = trailing[:32]
+	}
+	s.err = fmt.Errorf("%s: %w at offset %d: %q", s.Name(), ErrTrailingData, len(s.input)-len(s.data), trailing)
+}
+
+// Validate checks that the encoded data can be decoded with the engine e into a value of the type of the prototype,
+// or of the type it points to, without populating the prototype. It returns the error Unmarshal would return
+// for the data. An engine without the Validate method decodes the data with Unmarshal.
+func Validate(e Engine, data []byte, prototype any) error {
+	if v, ok := e.(interface{ Validate([]byte, any) error }); ok {
+		return v.Validate(data, prototype)
+	}
+	return validate(e, data, prototype)
+}
+
+// Validate decodes the encoded data into a new value of the type of the prototype, or of the type it points to,
+// and discards it. It returns the error Unmarshal would return for the data.
+func (e *engine[T]) Validate(data []byte, prototype any) error {
+	return validate(e, data, prototype)
+}
+
+// validate decodes the data with the engine e into a new value of the type of the prototype and discards it.
+func validate(e Engine, data []byte, prototype any) error {
+	if prototype == nil {
+		return fmt.Errorf("%s: %w", NameOf(e), ErrNilInterface)
+	}
+	return e.Unmarshal(data, reflect.New(elemType(prototype)).Interface())
+}
+
 type decodeState[T any] struct {
 	*engine[T]
+	*settings // taken once per value, see Reconfigure
 	context[T]
 	*bytes.Buffer
-	data []byte // copy of input
+	data    []byte    // copy of input
+	input   []byte    // the whole copy of input, data is its tail
+	rebuilt []rebuilt // the data rebuilt from the values of the input, see offset
+	decodeOptions
+	errs    []error // the errors of the fields, see Config.AllErrorsWhenDecoding
+	decoded int     // the total size of the values written by Tag.Decode, see Config.MaxDecodedSize
+	logged  bool    // the error is logged, see fail
+	pos     int     // the offset of the value being decoded in the input, -1 if it isn't a part of the input
 }
 
 var decodeStatePool sync.Pool
 
 func (e *engine[T]) newDecodeState() *decodeState[T] {
-	if p := decodeStatePool.Get(); p != nil {
-		s := p.(*decodeState[T])
-		s.err = nil
+	// The pool is shared by all engines, a state is bound to the engine that takes it.
+	if s, ok := decodeStatePool.Get().(*decodeState[T]); ok {
+		s.engine = e
+		s.settings = e.load()
+		s.Reset()
+		s.context = context[T]{maxDepth: s.settings.maxDepth}
+		s.errs, s.logged = nil, false
+		s.decoded = 0
 		return s
 	}
 
-	return &decodeState[T]{engine: e, Buffer: new(bytes.Buffer)}
+	settings := e.load()
+	return &decodeState[T]{engine: e, settings: settings, context: context[T]{maxDepth: settings.maxDepth}, Buffer: new(bytes.Buffer)}
+}
+
+func (s *decodeState[T]) unmarshal(rv reflect.Value) {
+	if err := s.value(rv); err != nil {
+		// The error of a top-level value that isn't a struct is of its type.
+		if s.structName == "" && rv.IsValid() && !errors.Is(err, errExist) {
+			s.field.typ = rv.Type()
+			if s.field.typ.Kind() == reflect.Pointer {
+				s.field.typ = s.field.typ.Elem()
+			}
+		}
+		s.fail(err)
+	}
+}
+
+// fail sets the error of the current field unless it is set already and logs it once, see Config.Logger.
+// A nested struct fails before the context of the enclosing one is restored, see context.nest.
+func (s *decodeState[T]) fail(err error) {
+	if !errors.Is(err, errExist) {
+		s.setDecodeError(err)
+	}
+	if s.logger != nil && !s.logged {
+		s.logError(s.err)
+		s.logged = true
+	}
 }
 
-func (s *decodeState[T]) unmarshal(v any) {
-	if err := s.reflectValue(reflect.ValueOf(v)); err != nil {
-		if !errors.Is(err, errExist) {
-			s.setError(s.Name(), unmarshalError, err)
+// value decodes the top-level value. A value that isn't composite is passed to Tag.Decode first
+// like the value of a field, as it is passed to Tag.Encode when encoding.
+func (s *decodeState[T]) value(v reflect.Value) error {
+	if v.Kind() == reflect.Pointer && !v.IsNil() && !s.isComposite(v.Type().Elem()) {
+		data := s.data
+		if s.split && !s.isList(v.Type().Elem()) {
+			data = s.release(data)
+		}
+		if err := s.decodeValue(data, s.isList(v.Type().Elem())); err != nil {
+			return err
 		}
 	}
+	return s.reflectValue(v)
 }
 
 func (s *decodeState[T]) reflectValue(v reflect.Value) error {
@@ -57,11 +242,9 @@ func (s *decodeState[T]) reflectValue(v reflect.Value) error {
 
 type decoderFunc[T any] func(*decodeState[T], reflect.Value) error
 
-var decoderCache sync.Map // map[reflect.Type]decoderFunc[T]
-
 // cache uses a cache to avoid repeated work.
 func (s *decodeState[T]) cache(t reflect.Type) decoderFunc[T] {
-	if c, ok := decoderCache.Load(t); ok {
+	if c, ok := s.decoders.Load(t); ok {
 		return c.(decoderFunc[T])
 	}
 
@@ -73,7 +256,7 @@ func (s *decodeState[T]) cache(t reflect.Type) decoderFunc[T] {
 		f  decoderFunc[T]
 	)
 	wg.Add(1)
-	c, loaded := decoderCache.LoadOrStore(t, decoderFunc[T](func(s *decodeState[T], v reflect.Value) error {
+	c, loaded := s.decoders.LoadOrStore(t, decoderFunc[T](func(s *decodeState[T], v reflect.Value) error {
 		wg.Wait()
 		return f(s, v)
 	}))
@@ -84,7 +267,7 @@ func (s *decodeState[T]) cache(t reflect.Type) decoderFunc[T] {
 	// Compute the real encoder and replace the indirect func with it.
 	_, f = s.typeCoders(t)
 	wg.Done()
-	decoderCache.Store(t, f)
+	s.decoders.Store(t, f)
 	return f
 }
 
@@ -98,9 +281,10 @@ func (s *decodeState[T]) removePrefixBytes(b []byte) error {
 }
 
 func (f *structFields[T]) decode(s *decodeState[T], v reflect.Value, unwrap bool) (err error) {
-	var sep bool
-
-	s.structName = v.Type().Name()
+	if s.noEmptyStructs && f.count() == 0 && f.extras() == nil {
+		s.err = fmt.Errorf("%s: %w: %s", s.Name(), ErrNoFields, v.Type())
+		return errExist
+	}
 
 	if unwrap {
 		if err = s.removePrefixBytes(s.structOpener); err != nil {
@@ -108,20 +292,64 @@ func (f *structFields[T]) decode(s *decodeState[T], v reflect.Value, unwrap bool
 		}
 	}
 
-	for _, s.field = range *f {
-		if s.data = bytes.TrimSpace(s.data); s.data == nil || unwrap && bytes.HasPrefix(s.data, s.structCloser) {
-			break
+	kt, keyed := any(s.Tag).(KeyedTag)
+	if keyed && s.split {
+		if err = f.keyed(s, kt, v, unwrap); err != nil {
+			return
+		}
+	}
+
+	if err = f.decodeFields(s, v, unwrap); err != nil {
+		return
+	}
+	if s.split && !keyed {
+		if err = f.decodeExtras(s, v, unwrap); err != nil {
+			return
+		}
+	}
+
+	if unwrap {
+		if err = s.removePrefixBytes(s.structCloser); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// decodeFields decodes the values of the fields of the struct, the fields of embedded structs included.
+func (f *structFields[T]) decodeFields(s *decodeState[T], v reflect.Value, unwrap bool) (err error) {
+	var sep bool
+
+	structName := s.enter(v.Type())
+	defer func() {
+		if err == nil {
+			s.leave(structName)
+		}
+	}()
+	separator := s.separator(s.depth)
+
+	for i := range *f {
+		if s.field = (*f)[i]; s.field.extras {
+			continue
+		}
+		// When the library splits the data itself, spaces may be a part of a value.
+		if !s.split {
+			s.data = bytes.TrimSpace(s.data)
+		}
+		if s.data == nil || unwrap && bytes.HasPrefix(s.data, s.structCloser) {
+			return f.decodeDefaults(s, v, i)
 		}
 
 		if sep {
-			if err = s.removePrefixBytes(s.valueSeparator); err != nil {
+			if err = s.removePrefixBytes(separator); err != nil {
 				return
 			}
 		}
-		sep = s.removeSeparator
+		sep = s.removeSeparator && !s.split
 
 		s.Reset()
-		rv := v.Field(s.field.index)
+		rv := s.field.value(v)
 
 		if s.field.embedded != nil {
 			if rv.Kind() == reflect.Pointer {
@@ -132,34 +360,368 @@ func (f *structFields[T]) decode(s *decodeState[T], v reflect.Value, unwrap bool
 				rv = rv.Elem()
 			}
 
-			if err = s.field.embedded.decode(s, rv, false); err != nil {
+			if err = s.field.embedded.decodeFields(s, rv, false); err != nil {
 				return
 			}
 			continue
 		}
 
-		if err = s.Decode(s.field.name, s.field.meta, s.data, s); err != nil {
-			return
+		if s.split {
+			value := s.cut(separator)
+			if s.spans != nil {
+				s.span(value)
+			}
+			if s.field.composite {
+				if len(value) == 0 {
+					if ok, err := s.decodeDefault(v, rv); err != nil {
+						return err
+					} else if ok {
+						continue
+					}
+				}
+				if err = s.decodeField(rv, value); err == nil {
+					err = s.field.set(v, rv)
+				}
+				// The errors of the fields of the value are collected by its decoder,
+				// an error already set is of the structure of the data and stops decoding.
+				if errors.Is(err, errExist) {
+					return
+				}
+				if err = s.collect(err); err != nil {
+					return
+				}
+				continue
+			}
+
+			// The elements of a list are released by its decoder.
+			if !s.field.list {
+				value = s.release(value)
+			}
+			err = s.decodeValue(value, s.field.list)
+		} else {
+			err = s.decodeValue(s.data, s.field.list)
+		}
+		if err != nil {
+			if err = s.collect(err); err != nil {
+				return
+			}
+			continue
 		}
 
 		if s.Len() == 0 {
+			if s.stats != nil {
+				s.stats.null(s.fieldPath())
+			}
+			if ok, err := s.decodeDefault(v, rv); err != nil {
+				return err
+			} else if ok {
+				continue
+			}
+			if s.emptySlices && rv.Kind() == reflect.Slice && rv.IsNil() {
+				rv.Set(reflect.MakeSlice(rv.Type(), 0, 0))
+				if err = s.field.set(v, rv); err != nil {
+					return
+				}
+			}
 			continue
 		}
 
-		if err = s.field.decoder(s, rv); err != nil {
-			return
+		n := s.Len()
+		if s.allErrors {
+			err = s.decodeFieldCopy(rv)
+		} else {
+			err = s.decodeField(rv, nil)
+		}
+		if err == nil {
+			err = s.field.set(v, rv)
+		}
+		if err != nil {
+			if err = s.collect(err); err != nil {
+				return
+			}
+			continue
+		}
+		if s.stats != nil {
+			s.stats.add(s.fieldPath(), n, rv)
 		}
 	}
 
-	if unwrap {
-		if err = s.removePrefixBytes(s.structCloser); err != nil {
-			return
+	return
+}
+
+// decodeFieldCopy decodes the value of the current field into a copy of rv and stores it in rv if it succeeds,
+// so that the field that fails is left as it is, see Config.AllErrorsWhenDecoding.
+func (s *decodeState[T]) decodeFieldCopy(rv reflect.Value) error {
+	cp := reflect.New(rv.Type()).Elem()
+	cp.Set(rv)
+	if err := s.decodeField(cp, nil); err != nil {
+		return err
+	}
+	rv.Set(cp)
+	return nil
+}
+
+// collect records the error of the current field and returns nil, so that decoding goes on,
+// see Config.AllErrorsWhenDecoding. Otherwise, it returns the error.
+func (s *decodeState[T]) collect(err error) error {
+	if err == nil || !s.allErrors {
+		return err
+	}
+	s.fail(err)
+	s.errs, s.err, s.logged = append(s.errs, s.err), nil, false
+	return nil
+}
+
+// span reports the offsets of the value of the current field in the input to the spans function,
+// nothing if the value isn't a part of the input.
+func (s *decodeState[T]) span(value []byte) {
+	start := s.offset(value)
+	if start < 0 {
+		return
+	}
+	s.spans(s.fieldPath(), start, start+len(value))
+}
+
+// offset returns the offset of the value in the input, or -1 if the value isn't a part of it.
+// A value of the data rebuilt from the values of the input is mapped back to the input, see rebuilt.
+func (s *decodeState[T]) offset(value []byte) int {
+	if start := within(s.input, value); start >= 0 {
+		return start
+	}
+	for _, r := range s.rebuilt {
+		at := within(r.data, value)
+		if at < 0 {
+			continue
 		}
+		for _, p := range r.parts {
+			if at >= p.at && at+len(value) <= p.at+p.size {
+				return p.start + at - p.at
+			}
+		}
+		return -1
+	}
+	return -1
+}
+
+// within returns the offset of the value in the data, or -1 if the value isn't a part of it.
+func within(data, value []byte) int {
+	// A part of the data shares its array, the value ends where its capacity does.
+	start := cap(data) - cap(value)
+	if start < 0 || start > len(data) || cap(value) == 0 || &data[:cap(data)][start] != &value[:1][0] {
+		return -1
 	}
+	return start
+}
 
+// rebuilt is the data rebuilt from the values of the input, e.g. the reordered values of keyed data.
+type rebuilt struct {
+	data  []byte
+	parts []moved
+}
+
+// add appends the value to the data, start is its offset in the input or -1 if it isn't a part of it.
+func (r *rebuilt) add(start int, value []byte) {
+	if start >= 0 {
+		r.parts = append(r.parts, moved{at: len(r.data), start: start, size: len(value)})
+	}
+	r.data = append(r.data, value...)
+}
+
+// moved is a value of the input moved to the offset at of the rebuilt data, start is its offset in the input.
+type moved struct {
+	at, start, size int
+}
+
+// setDecodeError sets the error of the current field, a *DecodeError with the position
+// of the value of the field in the input if it is known.
+func (s *decodeState[T]) setDecodeError(err error) {
+	s.setError(s.Name(), unmarshalError, err)
+	de, ok := s.err.(*DecodeError)
+	if !ok || s.pos < 0 {
+		return
+	}
+
+	before := s.input[:s.pos]
+	de.Offset = s.pos
+	de.Line = bytes.Count(before, []byte{'\n'}) + 1
+	de.Column = s.pos - bytes.LastIndexByte(before, '\n')
+}
+
+// decodeValue passes the data to Tag.Decode of the current field
+// and normalizes the value Tag.Decode writes.
+func (s *decodeState[T]) decodeValue(in []byte, list bool) error {
+	s.pos = s.offset(in)
+	if err := s.Decode(s.field.key, s.field.meta, in, s); err != nil {
+		return err
+	}
+	if err := s.checkValueSize(); err != nil {
+		return err
+	}
+
+	if tf := s.field.transform; tf != nil && s.Len() != 0 {
+		decode := tf.Decode
+		// The value converted back is bounded while it is converted, not only checked after.
+		if lt, ok := tf.(LimitedTransform); ok && s.maxValueSize > 0 {
+			decode = func(dst, p []byte) ([]byte, error) { return lt.DecodeLimit(dst, p, s.maxValueSize) }
+		}
+		if err := s.rewrite(decode); err != nil {
+			return err
+		}
+		if err := s.checkValueSize(); err != nil {
+			return err
+		}
+	}
+	// The elements of a list are converted by its decoder.
+	if cs := s.field.charsetOf(s.charset); cs != nil && !list {
+		if err := s.rewrite(cs.Decode); err != nil {
+			return err
+		}
+	}
+	if s.unescapesControls() && !list && bytes.IndexByte(s.Bytes(), '\\') >= 0 {
+		if err := s.rewrite(unescapeControls); err != nil {
+			return err
+		}
+	}
+
+	normalize := s.field.normalize
+	if normalize == nil {
+		normalize = s.normalize
+	}
+	if normalize != nil && s.Len() != 0 {
+		value := normalize(s.Bytes())
+		s.Reset()
+		s.Write(value)
+	}
+
+	return s.checkDecodedSize()
+}
+
+// cut returns the value at the beginning of the data up to the separator and removes it
+// with the separator from the data. Values of nested structs wrapped with the StructOpener
+// and the StructCloser and slices wrapped with the SliceOpener and the SliceCloser are never cut inside,
+// nor a byte following the EscapeChar. If there is no separator, the value ends at the StructCloser
+// of the enclosing struct or the SliceCloser of the enclosing slice, which stays in the data,
+// or takes the rest of the data, then the data becomes nil.
+func (s *decodeState[T]) cut(separator []byte) (value []byte) {
+	var depth int
+	for i := 0; i < len(s.data); {
+		if s.escape != 0 && s.data[i] == s.escape {
+			i += 2
+			if i > len(s.data) {
+				i = len(s.data)
+			}
+			continue
+		}
+
+		if n, d := s.nesting(s.data[i:], depth); n != 0 {
+			if depth+d < 0 {
+				value, s.data = s.data[:i], s.data[i:]
+				return
+			}
+			depth += d
+			i += n
+			continue
+		}
+
+		if depth == 0 && len(separator) != 0 && bytes.HasPrefix(s.data[i:], separator) {
+			value, s.data = s.data[:i], s.data[i+len(separator):]
+			return
+		}
+		i++
+	}
+	value, s.data = s.data, nil
 	return
 }
 
+// rewrite replaces the value written by Tag.Decode with the value converted by the function f.
+func (s *decodeState[T]) rewrite(f func(dst, p []byte) ([]byte, error)) error {
+	value, err := f(nil, s.Bytes())
+	if err != nil {
+		return err
+	}
+	s.Reset()
+	s.Write(value)
+	return nil
+}
+
+// convert returns the value converted from the charset of the current field to UTF-8, see Charset,
+// with its escaped control characters restored, see ControlCharsEscape.
+func (s *decodeState[T]) convert(p []byte) ([]byte, error) {
+	if cs := s.field.charsetOf(s.charset); cs != nil {
+		var err error
+		if p, err = cs.Decode(nil, p); err != nil {
+			return nil, err
+		}
+	}
+	if s.unescapesControls() && bytes.IndexByte(p, '\\') >= 0 {
+		return unescapeControls(nil, p)
+	}
+	return p, nil
+}
+
+// unescapesControls reports whether the values of the current field have their control characters escaped,
+// see ControlCharsEscape.
+func (s *decodeState[T]) unescapesControls() bool {
+	return s.controlChars == ControlCharsEscape && !s.field.binary()
+}
+
+// unescapeControls appends the value to dst with the control characters and the backslashes escaped
+// by ControlCharsEscape restored.
+func unescapeControls(dst, p []byte) ([]byte, error) {
+	for i := 0; i < len(p); i++ {
+		switch {
+		case p[i] != '\\' || i+1 == len(p):
+		case p[i+1] == '\\':
+			i++
+		case p[i+1] == 'x' && i+3 < len(p) && isHex(p[i+2]) && isHex(p[i+3]):
+			dst = append(dst, unhex(p[i+2])<<4|unhex(p[i+3]))
+			i += 3
+			continue
+		}
+		dst = append(dst, p[i])
+	}
+	return dst, nil
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func unhex(c byte) byte {
+	switch {
+	case c <= '9':
+		return c - '0'
+	case c <= 'F':
+		return c - 'A' + 10
+	}
+	return c - 'a' + 10
+}
+
+// release removes the EscapeChar preceding escaped bytes of the value.
+func (s *decodeState[T]) release(value []byte) []byte {
+	if s.escape == 0 || bytes.IndexByte(value, s.escape) < 0 {
+		return value
+	}
+
+	released := make([]byte, 0, len(value))
+	for i := 0; i < len(value); i++ {
+		if value[i] == s.escape && i+1 < len(value) {
+			i++
+		}
+		released = append(released, value[i])
+	}
+	return released
+}
+
+// decodeFrom decodes the value v from the data instead of the remaining input.
+func (s *decodeState[T]) decodeFrom(data []byte, v reflect.Value) error {
+	rest := s.data
+	s.data = data
+	err := s.field.decoder(s, v)
+	s.data = rest
+	return err
+}
+
 func unmarshalerDecoder[T any](s *decodeState[T], v reflect.Value) error {
 	rv := reflect.New(v.Type())
 
@@ -176,6 +738,18 @@ func unmarshalerDecoder[T any](s *decodeState[T], v reflect.Value) error {
 	return nil
 }
 
+func binaryUnmarshalerDecoder[T any](s *decodeState[T], v reflect.Value) error {
+	rv := reflect.New(v.Type())
+
+	// The buffer is reused, the value mustn't retain it.
+	if err := rv.Interface().(encoding.BinaryUnmarshaler).UnmarshalBinary(append([]byte(nil), s.Bytes()...)); err != nil {
+		return err
+	}
+
+	v.Set(rv.Elem())
+	return nil
+}
+
 func boolDecoder[T any](s *decodeState[T], v reflect.Value) error {
 	r, err := strconv.ParseBool(s.String())
 	v.SetBool(r)
@@ -188,6 +762,16 @@ func intDecoder[T any](s *decodeState[T], v reflect.Value) error {
 	return err
 }
 
+// durationDecoder reads a time.Duration from integer nanoseconds or from a string, see Config.DurationAsString.
+func durationDecoder[T any](s *decodeState[T], v reflect.Value) error {
+	if !s.durationString {
+		return intDecoder(s, v)
+	}
+	r, err := time.ParseDuration(s.String())
+	v.SetInt(int64(r))
+	return err
+}
+
 func uintDecoder[T any](s *decodeState[T], v reflect.Value) error {
 	r, err := strconv.ParseUint(s.String(), 10, bitSize(v.Kind()))
 	v.SetUint(r)
@@ -201,6 +785,10 @@ func floatDecoder[T any](s *decodeState[T], v reflect.Value) error {
 }
 
 func interfaceDecoder[T any](s *decodeState[T], v reflect.Value) error {
+	if s.nilInterface == NilInterfaceLiteral && bytes.Equal(s.Bytes(), s.nilLiteral) {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
 	if v.IsNil() {
 		s.err = ErrNilInterface
 		return errExist
@@ -209,13 +797,16 @@ func interfaceDecoder[T any](s *decodeState[T], v reflect.Value) error {
 }
 
 func pointerDecoder[T any](s *decodeState[T], v reflect.Value) error {
-	if v.IsNil() {
+	// The pointer passed to Unmarshal can't be replaced.
+	if v.IsNil() || s.pointers == PointerReplace && v.CanSet() {
 		rv := reflect.New(v.Type().Elem())
 		if err := s.reflectValue(rv.Elem()); err != nil {
 			return err
 		}
 		if !isEmptyValue(rv.Elem()) {
 			v.Set(rv)
+		} else if !v.IsNil() {
+			v.Set(reflect.Zero(v.Type()))
 		}
 		return nil
 	}
@@ -223,12 +814,345 @@ func pointerDecoder[T any](s *decodeState[T], v reflect.Value) error {
 }
 
 func bytesDecoder[T any](s *decodeState[T], v reflect.Value) error {
-	v.SetBytes(s.Bytes())
+	// The buffer is reused, the value mustn't share it.
+	v.SetBytes(append([]byte(nil), s.Bytes()...))
+	return nil
+}
+
+func byteArrayDecoder[T any](s *decodeState[T], v reflect.Value) error {
+	if s.Len() != v.Len() {
+		return ErrArrayLength
+	}
+	reflect.Copy(v, reflect.ValueOf(s.Bytes()))
 	return nil
 }
 
+// sliceDecoder splits the list written by Tag.Decode into elements at the ElementSeparator
+// and decodes them into a new slice or array. The list must be wrapped with the SliceOpener and the SliceCloser.
 func sliceDecoder[T any](s *decodeState[T], v reflect.Value) error {
-	return nil // TODO
+	list := s.Bytes()
+	if !bytes.HasPrefix(list, s.sliceOpener) || !bytes.HasSuffix(list[len(s.sliceOpener):], s.sliceCloser) {
+		s.err = fmt.Errorf("%s: %w", s.Name(), ErrInvalidFormat)
+		return errExist
+	}
+	list = list[len(s.sliceOpener) : len(list)-len(s.sliceCloser)]
+
+	elements, err := s.cutAll(append([]byte(nil), list...), s.elementSeparator)
+	if err != nil {
+		return err
+	}
+	if err = s.checkElements(len(elements)); err != nil {
+		return err
+	}
+
+	// Elements that are lists themselves are released by their sliceDecoder.
+	release := !s.isList(v.Type().Elem())
+
+	rv, err := newSequence(v.Type(), len(elements))
+	if err != nil {
+		return err
+	}
+
+	for i, element := range elements {
+		if release {
+			if element, err = s.convert(s.release(element)); err != nil {
+				return err
+			}
+		}
+
+		s.Reset()
+		if s.Write(element); s.Len() == 0 {
+			continue
+		}
+
+		if err := s.reflectValue(rv.Index(i)); err != nil {
+			return err
+		}
+	}
+
+	v.Set(rv)
+	return nil
+}
+
+// mapDecoder strips the MapOpener and the MapCloser from the list written by Tag.Decode, splits it into entries at the EntrySeparator and the entries into keys
+// and values at the KeyValueSeparator, and stores them in the map, a nil map is allocated.
+func mapDecoder[T any](s *decodeState[T], v reflect.Value) error {
+	list := s.Bytes()
+	if !bytes.HasPrefix(list, s.mapOpener) || !bytes.HasSuffix(list[len(s.mapOpener):], s.mapCloser) {
+		s.err = fmt.Errorf("%s: %w", s.Name(), ErrInvalidFormat)
+		return errExist
+	}
+	list = list[len(s.mapOpener) : len(list)-len(s.mapCloser)]
+
+	entries, err := s.cutAll(append([]byte(nil), list...), s.entrySeparator)
+	if err != nil {
+		return err
+	}
+	if err = s.checkElements(len(entries)); err != nil {
+		return err
+	}
+
+	t, set := mapSetter(v)
+
+	// Values that are lists themselves are released by their decoder.
+	release := !s.isList(t.Elem())
+
+	for _, entry := range entries {
+		key, value := s.cutFrom(entry, s.keyValueSeparator)
+		if value == nil {
+			s.err = fmt.Errorf("%s: %w", s.Name(), ErrInvalidFormat)
+			return errExist
+		}
+
+		if key, err = s.convert(s.release(key)); err != nil {
+			return err
+		}
+		kv := reflect.New(t.Key()).Elem()
+		if err = decodeKey(key, kv); err != nil {
+			return err
+		}
+
+		if release {
+			if value, err = s.convert(s.release(value)); err != nil {
+				return err
+			}
+		}
+
+		ev := reflect.New(t.Elem()).Elem()
+		s.Reset()
+		if s.Write(value); s.Len() != 0 {
+			if err = s.reflectValue(ev); err != nil {
+				return err
+			}
+		}
+
+		set(kv, ev)
+	}
+
+	return nil
+}
+
+// compositeMapDecoder decodes the entries of a map of composite values wrapped with the MapOpener
+// and the MapCloser and separated by the EntrySeparator into the map, a nil map is allocated.
+func compositeMapDecoder[T any](s *decodeState[T], v reflect.Value) error {
+	// An absent map is left nil.
+	if len(s.data) == 0 {
+		return nil
+	}
+
+	if err := s.removePrefixBytes(s.mapOpener); err != nil {
+		return err
+	}
+
+	t, set := mapSetter(v)
+
+	for n := 1; len(s.data) != 0 && (len(s.mapCloser) == 0 || !bytes.HasPrefix(s.data, s.mapCloser)); n++ {
+		if err := s.checkElements(n); err != nil {
+			return err
+		}
+		if s.split {
+			// The entries are cut like values, the enclosing data is restored after each of them.
+			entry := s.cut(s.entrySeparator)
+			rest := s.data
+			s.data = entry
+			err := s.decodeEntry(t, set)
+			s.data = rest
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := s.decodeEntry(t, set); err != nil {
+			return err
+		}
+		if len(s.entrySeparator) == 0 || !bytes.HasPrefix(s.data, s.entrySeparator) {
+			break
+		}
+		s.data = s.data[len(s.entrySeparator):]
+	}
+
+	return s.removePrefixBytes(s.mapCloser)
+}
+
+// decodeEntry decodes an entry of a map of composite values, the key and the value separated
+// by the KeyValueSeparator, and stores it in the map.
+func (s *decodeState[T]) decodeEntry(t reflect.Type, set func(key, value reflect.Value)) error {
+	key, err := s.convert(s.release(s.cut(s.keyValueSeparator)))
+	if err != nil {
+		return err
+	}
+	kv := reflect.New(t.Key()).Elem()
+	if err = decodeKey(key, kv); err != nil {
+		return err
+	}
+
+	ev := reflect.New(t.Elem()).Elem()
+	if err := s.reflectValue(ev); err != nil {
+		return err
+	}
+
+	set(kv, ev)
+	return nil
+}
+
+// mapSetter returns the type of the map and the function storing its entries, a nil map is allocated.
+// The entries of an OrderedMap are appended to it in the order they are decoded in.
+func mapSetter(v reflect.Value) (reflect.Type, func(key, value reflect.Value)) {
+	if v.Kind() == reflect.Struct {
+		m := pointerTo(v).Interface().(orderedMap)
+		return m.mapType(), m.setEntry
+	}
+
+	if v.IsNil() {
+		v.Set(reflect.MakeMap(v.Type()))
+	}
+	return v.Type(), v.SetMapIndex
+}
+
+// decodeKey decodes the key of a map.
+func decodeKey(key []byte, v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		r, err := strconv.ParseInt(string(key), 10, bitSize(v.Kind()))
+		v.SetInt(r)
+		return err
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		r, err := strconv.ParseUint(string(key), 10, bitSize(v.Kind()))
+		v.SetUint(r)
+		return err
+	default:
+		v.SetString(string(key))
+		return nil
+	}
+}
+
+// cutAll cuts the data into values at the separator, see cut.
+func (s *decodeState[T]) cutAll(data, separator []byte) ([][]byte, error) {
+	var values [][]byte
+	for len(data) != 0 {
+		n := len(data)
+		var value []byte
+		if value, data = s.cutFrom(data, separator); len(data) == n {
+			// A closer without an opener.
+			s.err = fmt.Errorf("%s: %w", s.Name(), ErrInvalidFormat)
+			return nil, errExist
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// cutFrom cuts the value from the data instead of the remaining input, see cut,
+// and returns the rest of the data.
+func (s *decodeState[T]) cutFrom(data, separator []byte) (value, rest []byte) {
+	saved := s.data
+	s.data = data
+	value, rest = s.cut(separator), s.data
+	s.data = saved
+	return
+}
+
+// compositeSliceDecoder decodes the elements of a slice of composite values wrapped with the SliceOpener
+// and the SliceCloser and separated by the ElementSeparator into a new slice or array.
+// The elements may be preceded by their count, see Config.CountElements.
+func compositeSliceDecoder[T any](s *decodeState[T], v reflect.Value) error {
+	// An absent list leaves the slice nil, see Config.EmptySliceWhenDecoding.
+	if len(s.data) == 0 && v.Kind() == reflect.Slice && !s.emptySlices {
+		return nil
+	}
+
+	if err := s.removePrefixBytes(s.sliceOpener); err != nil {
+		return err
+	}
+
+	count := -1
+	if s.countElements {
+		n, err := strconv.Atoi(string(s.release(s.cut(s.elementSeparator))))
+		if err != nil || n < 0 {
+			s.err = fmt.Errorf("%s: %w", s.Name(), ErrInvalidFormat)
+			return errExist
+		}
+		if err := s.checkElements(n); err != nil {
+			return err
+		}
+		count = n
+	}
+
+	rv := reflect.MakeSlice(reflect.SliceOf(v.Type().Elem()), 0, 0)
+	for count < 0 && len(s.data) != 0 && !s.atSliceCloser() || rv.Len() < count {
+		if err := s.checkElements(rv.Len() + 1); err != nil {
+			return err
+		}
+		rv = reflect.Append(rv, reflect.Zero(v.Type().Elem()))
+
+		if s.split {
+			// The elements are cut like values, the enclosing data is restored after each of them.
+			element := s.cut(s.elementSeparator)
+			rest := s.data
+			s.data = element
+			err := s.decodeElement(rv.Index(rv.Len() - 1))
+			s.data = rest
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := s.decodeElement(rv.Index(rv.Len() - 1)); err != nil {
+			return err
+		}
+		if rv.Len() == count || count < 0 && (len(s.elementSeparator) == 0 || !bytes.HasPrefix(s.data, s.elementSeparator)) {
+			break
+		}
+		if err := s.removePrefixBytes(s.elementSeparator); err != nil {
+			return err
+		}
+	}
+
+	if err := s.removePrefixBytes(s.sliceCloser); err != nil {
+		return err
+	}
+
+	if v.Kind() == reflect.Array {
+		array, err := newSequence(v.Type(), rv.Len())
+		if err != nil {
+			return err
+		}
+		reflect.Copy(array, rv)
+		rv = array
+	}
+
+	v.Set(rv)
+	return nil
+}
+
+// decodeElement decodes an element of a slice of composite values wrapped with the ElementOpener
+// and the ElementCloser.
+func (s *decodeState[T]) decodeElement(v reflect.Value) error {
+	if err := s.removePrefixBytes(s.elementOpener); err != nil {
+		return err
+	}
+	if err := s.reflectValue(v); err != nil {
+		return err
+	}
+	return s.removePrefixBytes(s.elementCloser)
+}
+
+// atSliceCloser reports whether the data starts with the SliceCloser.
+func (s *decodeState[T]) atSliceCloser() bool {
+	return len(s.sliceCloser) != 0 && bytes.HasPrefix(s.data, s.sliceCloser)
+}
+
+// newSequence returns a new slice of the type t with n elements, or a new array checking its length.
+func newSequence(t reflect.Type, n int) (reflect.Value, error) {
+	if t.Kind() != reflect.Array {
+		return reflect.MakeSlice(t, n, n), nil
+	}
+	if n != t.Len() {
+		return reflect.Value{}, ErrArrayLength
+	}
+	return reflect.New(t).Elem(), nil
 }
 
 func stringDecoder[T any](s *decodeState[T], v reflect.Value) error {
@@ -238,7 +1162,15 @@ func stringDecoder[T any](s *decodeState[T], v reflect.Value) error {
 
 func structDecoder[T any](s *decodeState[T], v reflect.Value) error {
 	f := s.cachedFields(v.Type())
-	return f.decode(s, v, s.wrap)
+
+	if err := s.structHooks(v.Type(), func() error {
+		return s.nest(s.fail, func() error {
+			return f.decode(s, v, s.wrap)
+		})
+	}); err != nil {
+		return err
+	}
+	return s.afterDecode(v)
 }
 
 func unsupportedTypeDecoder[T any](s *decodeState[T], _ reflect.Value) error {
@@ -252,3 +1184,9 @@ func invalidTagDecoder[T any](tag string, err error) decoderFunc[T] {
 		return errExist
 	}
 }
+
+func invalidFieldDecoder[T any](err error) decoderFunc[T] {
+	return func(s *decodeState[T], _ reflect.Value) error {
+		return err
+	}
+}
@@ -0,0 +1,333 @@
+package engine
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	type record struct {
+		A string
+		B int
+	}
+	e := newTestEngine(nil)
+
+	var tests = []struct {
+		data      string
+		prototype any
+		expect    error
+	}{
+		{
+			data:      "a,1",
+			prototype: &record{},
+		},
+		{
+			data:      "a,1",
+			prototype: record{},
+		},
+		{
+			data:      "a,x",
+			prototype: record{},
+			expect:    strconv.ErrSyntax,
+		},
+		{
+			data:      "a,1",
+			prototype: nil,
+			expect:    ErrNilInterface,
+		},
+	}
+	for _, tt := range tests {
+		// An engine without the Validate method validates the data as well.
+		for _, e := range []Engine{e, foreignEngine{e}} {
+			err := Validate(e, []byte(tt.data), tt.prototype)
+			equal(t, true, errors.Is(err, tt.expect))
+		}
+	}
+
+	// The prototype isn't populated.
+	r := record{A: "z"}
+	equal(t, nil, Validate(e, []byte("a,1"), &r))
+	equal(t, record{A: "z"}, r)
+}
+
+func TestUnmarshalSpans(t *testing.T) {
+	type inner struct{ X, Y string }
+	type record struct {
+		A  string
+		I  inner
+		Is []inner
+		B  string
+	}
+	e := newTestEngine(func(cfg *Config) { cfg.ElementSeparator = []byte("~") })
+	data := []byte("ab,x:y,p:q~r:s,zz")
+
+	type span struct {
+		path  string
+		value string
+	}
+	var got []span
+	var r record
+	equal(t, nil, UnmarshalSpans(e, data, &r, func(path string, start, end int) {
+		got = append(got, span{path, string(data[start:end])})
+	}))
+	// The elements of a slice share the paths.
+	equal(t, []span{
+		{"A", "ab"},
+		{"I", "x:y"},
+		{"I.X", "x"},
+		{"I.Y", "y"},
+		{"Is", "p:q~r:s"},
+		{"Is.X", "p"},
+		{"Is.Y", "q"},
+		{"Is.X", "r"},
+		{"Is.Y", "s"},
+		{"B", "zz"},
+	}, got)
+
+	err := UnmarshalSpans(foreignEngine{e}, data, &r, func(string, int, int) {})
+	equal(t, true, errors.Is(err, ErrUnsupported))
+}
+
+// spyTag is testTag keeping the data it gets to decode.
+type spyTag struct {
+	testTag[testMeta]
+	in *[][]byte
+}
+
+func (tag spyTag) Decode(fieldName string, meta *testMeta, in []byte, out Writer) error {
+	*tag.in = append(*tag.in, in)
+	return tag.testTag.Decode(fieldName, meta, in, out)
+}
+
+func TestUnmarshalNoCopy(t *testing.T) {
+	type record struct{ A, B string }
+	var in [][]byte
+	e := New[testMeta](spyTag{in: &in}, testConfig())
+
+	data := []byte("a,xyz")
+	var got record
+	equal(t, nil, UnmarshalNoCopy(e, data, &got))
+	equal(t, record{A: "a", B: "xyz"}, got)
+	// The tag got the data of the caller, the decoded values are still copies.
+	data[2] = 'X'
+	equal(t, "Xyz", string(in[1]))
+	equal(t, record{A: "a", B: "xyz"}, got)
+
+	in, data = nil, []byte("a,xyz")
+	equal(t, nil, e.Unmarshal(data, &got))
+	data[2] = 'X'
+	equal(t, "xyz", string(in[1]))
+
+	// An engine without the UnmarshalNoCopy method decodes a copy.
+	in, data = nil, []byte("a,xyz")
+	equal(t, nil, UnmarshalNoCopy(foreignEngine{e}, data, &got))
+	data[2] = 'X'
+	equal(t, "xyz", string(in[1]))
+}
+
+func TestDisallowTrailingData(t *testing.T) {
+	type record struct{ A, B string }
+
+	var tests = []struct {
+		disallow bool
+		data     string
+		expect   error
+	}{
+		{
+			data: "a,b,c",
+		},
+		{
+			disallow: true,
+			data:     "a,b",
+		},
+		{
+			disallow: true,
+			data:     "a,b,c,d",
+			expect:   ErrTrailingData,
+		},
+	}
+	for _, tt := range tests {
+		e := newTestEngine(func(cfg *Config) { cfg.DisallowTrailingData = tt.disallow })
+		var got record
+		err := e.Unmarshal([]byte(tt.data), &got)
+		equal(t, true, errors.Is(err, tt.expect))
+		equal(t, record{A: "a", B: "b"}, got)
+	}
+
+	// The offset and the beginning of the trailing data are reported.
+	e := newTestEngine(func(cfg *Config) { cfg.DisallowTrailingData = true })
+	err := e.Unmarshal([]byte("a,b,"+strings.Repeat("x", 40)), &record{})
+	equal(t, `test: unexpected data after the value at offset 4: "`+strings.Repeat("x", 32)+`"`, err.Error())
+}
+
+func TestDisallowEmptyStructs(t *testing.T) {
+	type skipped struct {
+		A string `test:"-"`
+		b string
+	}
+	type empty struct{}
+	type record struct {
+		A string
+		B empty
+	}
+
+	var tests = []struct {
+		disallow bool
+		value    any
+		expect   error
+	}{
+		{
+			value: &skipped{},
+		},
+		{
+			disallow: true,
+			value:    &skipped{},
+			expect:   ErrNoFields,
+		},
+		{
+			disallow: true,
+			value:    &record{},
+			expect:   ErrNoFields,
+		},
+		{
+			disallow: true,
+			value:    &party{},
+		},
+	}
+	for _, tt := range tests {
+		e := newTestEngine(func(cfg *Config) { cfg.DisallowEmptyStructs = tt.disallow })
+		err := e.Unmarshal([]byte("a,1"), tt.value)
+		equal(t, true, errors.Is(err, tt.expect))
+	}
+}
+
+func TestPointersWhenDecoding(t *testing.T) {
+	type record struct {
+		P *party
+		S *string
+	}
+
+	var tests = []struct {
+		policy PointerPolicy
+		reused bool
+		expect party
+	}{
+		{
+			// The fields missing in the data keep the values they had.
+			policy: PointerReuse,
+			reused: true,
+			expect: party{ID: "1", Agency: 2, Code: "old"},
+		},
+		{
+			policy: PointerReplace,
+			expect: party{ID: "1", Agency: 2},
+		},
+	}
+	for _, tt := range tests {
+		e := newTestEngine(func(cfg *Config) { cfg.PointersWhenDecoding = tt.policy })
+		p, s := &party{Code: "old"}, new(string)
+		got := record{P: p, S: s}
+		equal(t, nil, e.Unmarshal([]byte("1:2,s"), &got))
+		equal(t, tt.reused, got.P == p)
+		equal(t, tt.reused, got.S == s)
+		equal(t, tt.expect, *got.P)
+		equal(t, "s", *got.S)
+	}
+
+	// The nil pointers are always allocated.
+	var got record
+	equal(t, nil, newTestEngine(nil).Unmarshal([]byte("1:2,s"), &got))
+	s := "s"
+	equal(t, record{P: &party{ID: "1", Agency: 2}, S: &s}, got)
+}
+
+func TestAllErrorsWhenDecoding(t *testing.T) {
+	type record struct {
+		A int
+		B string
+		C int
+		P party
+	}
+
+	var tests = []struct {
+		all    bool
+		expect []string
+		value  record
+	}{
+		{
+			expect: []string{"A"},
+		},
+		{
+			// The fields that fail are left as they are.
+			all:    true,
+			expect: []string{"A", "C", "Agency"},
+			value:  record{A: 7, B: "b", P: party{ID: "1", Code: "c"}},
+		},
+	}
+	for _, tt := range tests {
+		e := newTestEngine(func(cfg *Config) { cfg.AllErrorsWhenDecoding = tt.all })
+		got := record{A: 7}
+		err := e.Unmarshal([]byte("x,b,y,1:z:c"), &got)
+
+		errs := []error{err}
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			errs = joined.Unwrap()
+		}
+		var fields []string
+		for _, err := range errs {
+			var de *DecodeError
+			equal(t, true, errors.As(err, &de))
+			equal(t, true, errors.Is(err, strconv.ErrSyntax))
+			fields = append(fields, de.FieldName)
+		}
+		equal(t, tt.expect, fields)
+		if tt.all {
+			equal(t, tt.value, got)
+		}
+	}
+
+	// An error of the structure of the data stops decoding, the following fields aren't decoded.
+	e := newTestEngine(func(cfg *Config) {
+		cfg.AllErrorsWhenDecoding = true
+		cfg.StructOpener, cfg.StructCloser, cfg.UnwrapWhenDecoding = []byte("{"), []byte("}"), true
+	})
+	type pair struct{ X, Y int }
+	var got struct {
+		A    int
+		P    pair
+		Y, Z int
+	}
+	err := e.Unmarshal([]byte("{1,{2:3:9},x,y}"), &got)
+	equal(t, true, errors.Is(err, ErrInvalidFormat))
+	_, joined := err.(interface{ Unwrap() []error })
+	equal(t, false, joined)
+}
+
+func TestZeroBeforeDecoding(t *testing.T) {
+	type record struct {
+		A string
+		B int
+		C []int
+	}
+	reused := func() *record {
+		return &record{A: "old", B: 7, C: []int{1}}
+	}
+
+	e := newTestEngine(nil)
+	got := reused()
+	equal(t, nil, e.Unmarshal([]byte("new"), got))
+	equal(t, &record{A: "new", B: 7, C: []int{1}}, got)
+
+	for _, e := range []Engine{e, foreignEngine{e}} {
+		got = reused()
+		equal(t, nil, UnmarshalZero(e, []byte("new"), got))
+		equal(t, &record{A: "new"}, got)
+	}
+
+	e = newTestEngine(func(cfg *Config) { cfg.ZeroBeforeDecoding = true })
+	got = reused()
+	equal(t, nil, e.Unmarshal([]byte("new"), got))
+	equal(t, &record{A: "new"}, got)
+}
@@ -0,0 +1,125 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrDefaultType is returned when the default value of a field is of a type that can't be assigned to the field.
+var ErrDefaultType = errors.New("the default value can't be assigned to the field")
+
+// Defaulter is the interface implemented by a parsed tag, a *T of the engine Tag,
+// that gives its field a default value applied when decoding if the field is absent:
+// Tag.Decode writes no value for it, or the data ends before it.
+type Defaulter interface {
+	// DefaultValue returns the default value of the field, nil if there is none. A []byte is decoded like a value
+	// written by Tag.Decode, a value of another type is assigned to the field as is.
+	DefaultValue() any
+}
+
+// decodeDefault applies the default value of the current field to rv, the value of the field of the struct v.
+// It reports whether the field has a default value.
+func (s *decodeState[T]) decodeDefault(v, rv reflect.Value) (bool, error) {
+	if s.field.def == nil {
+		return false, nil
+	}
+
+	switch def := s.field.def.(type) {
+	case []byte:
+		if s.field.composite {
+			if err := s.decodeFrom(def, rv); err != nil {
+				return true, err
+			}
+			break
+		}
+		s.Reset()
+		s.Write(def)
+		if err := s.field.decoder(s, rv); err != nil {
+			return true, err
+		}
+	default:
+		dv := reflect.ValueOf(def)
+		if !dv.Type().AssignableTo(rv.Type()) {
+			s.err = fmt.Errorf("%s: %w: %s to struct field %s.%s of type %s",
+				s.Name(), ErrDefaultType, dv.Type(), s.structName, s.field.name, rv.Type())
+			return true, errExist
+		}
+		rv.Set(dv)
+	}
+	return true, s.field.set(v, rv)
+}
+
+// decodeDefaults applies the default values of the fields of the struct absent from the data,
+// starting from the field at the index, the fields of embedded structs included.
+func (f *structFields[T]) decodeDefaults(s *decodeState[T], v reflect.Value, index int) error {
+	for _, s.field = range (*f)[index:] {
+		if s.field.extras {
+			continue
+		}
+
+		rv := s.field.value(v)
+		if s.field.embedded != nil {
+			if rv.Kind() == reflect.Pointer {
+				if rv.IsNil() {
+					continue
+				}
+				rv = rv.Elem()
+			}
+			if err := s.field.embedded.decodeDefaults(s, rv, 0); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if _, err := s.decodeDefault(v, rv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BeforeEncoder is the interface implemented by structs that refresh their computed or derived fields
+// before their fields are encoded. The error it returns fails encoding.
+type BeforeEncoder interface {
+	// BeforeEncode is called before the fields of the struct are encoded.
+	BeforeEncode() error
+}
+
+var beforeEncoderType = reflect.TypeOf((*BeforeEncoder)(nil)).Elem()
+
+// beforeEncode calls the hook of the struct v and returns the struct to encode, a copy of v
+// if it isn't addressable, so that the fields the hook sets are encoded, see BeforeEncoder.
+func beforeEncode(v reflect.Value) (reflect.Value, error) {
+	if !reflect.PointerTo(v.Type()).Implements(beforeEncoderType) {
+		return v, nil
+	}
+	p := pointerTo(v)
+	if err := p.Interface().(BeforeEncoder).BeforeEncode(); err != nil {
+		return v, err
+	}
+	return p.Elem(), nil
+}
+
+// AfterDecoder is the interface implemented by structs that apply defaults or check their invariants
+// after their fields are decoded. The error it returns fails decoding, see also Config.AfterDecode.
+type AfterDecoder interface {
+	// AfterDecode is called after the fields of the struct are decoded.
+	AfterDecode() error
+}
+
+var afterDecoderType = reflect.TypeOf((*AfterDecoder)(nil)).Elem()
+
+// afterDecode calls the hooks of the decoded struct v, see AfterDecoder and Config.AfterDecode.
+func (s *decodeState[T]) afterDecode(v reflect.Value) error {
+	p := pointerTo(v)
+	if p.Type().Implements(afterDecoderType) {
+		if err := p.Interface().(AfterDecoder).AfterDecode(); err != nil {
+			return err
+		}
+	}
+	if s.decodeHook != nil {
+		return s.decodeHook(p.Interface())
+	}
+	return nil
+}
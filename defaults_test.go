@@ -0,0 +1,148 @@
+package engine
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// defaultMeta gives its field the default value of the tag: the encoded value following "=",
+// or the value of the type of the field following "int=", see Defaulter.
+type defaultMeta struct {
+	def any
+}
+
+func (m *defaultMeta) parse(tagValue string) (bool, error) {
+	if v, ok := strings.CutPrefix(tagValue, "int="); ok {
+		m.def = len(v)
+	} else if v, ok = strings.CutPrefix(tagValue, "="); ok {
+		m.def = []byte(v)
+	} else if tagValue == "string" {
+		m.def = "string"
+	}
+	return false, nil
+}
+
+func (m *defaultMeta) DefaultValue() any {
+	return m.def
+}
+
+func TestDefaulter(t *testing.T) {
+	type record struct {
+		A string `test:"=a"`
+		B int    `test:"=12"`
+		P party  `test:"=1:2:3"`
+		N int    `test:"int=xxx"`
+		S string
+	}
+	e := newEngineOf[defaultMeta](nil)
+
+	var tests = []struct {
+		data   string
+		expect record
+	}{
+		{
+			data:   "x,1,i:0:c,5,s",
+			expect: record{A: "x", B: 1, P: party{ID: "i", Code: "c"}, N: 5, S: "s"},
+		},
+		{
+			data:   ",,,,",
+			expect: record{A: "a", B: 12, P: party{ID: "1", Agency: 2, Code: "3"}, N: 3},
+		},
+		{
+			// The fields after the end of the data are absent as well.
+			data:   "x",
+			expect: record{A: "x", B: 12, P: party{ID: "1", Agency: 2, Code: "3"}, N: 3},
+		},
+	}
+	for _, tt := range tests {
+		var got record
+		equal(t, nil, e.Unmarshal([]byte(tt.data), &got))
+		equal(t, tt.expect, got)
+	}
+
+	// The default value of another type must be assignable to the field.
+	var got struct {
+		N int `test:"string"`
+	}
+	equal(t, true, errors.Is(e.Unmarshal([]byte(""), &got), ErrDefaultType))
+}
+
+var errUnchecked = errors.New("unchecked")
+
+// checked defaults its Code and rejects the Agency 0 after it is decoded, and fills its Total before it is encoded.
+type checked struct {
+	Agency int
+	Code   string
+	Total  int
+}
+
+func (c *checked) AfterDecode() error {
+	if c.Agency == 0 {
+		return errUnchecked
+	}
+	if c.Code == "" {
+		c.Code = "def"
+	}
+	return nil
+}
+
+func (c *checked) BeforeEncode() error {
+	if c.Agency < 0 {
+		return errUnchecked
+	}
+	c.Total = c.Agency * 10
+	return nil
+}
+
+func TestAfterDecoder(t *testing.T) {
+	type record struct {
+		A string
+		C checked
+	}
+	var decoded []string
+	e := newTestEngine(func(cfg *Config) {
+		cfg.AfterDecode = func(v any) error {
+			decoded = append(decoded, reflect.TypeOf(v).String())
+			return nil
+		}
+	})
+
+	var got record
+	equal(t, nil, e.Unmarshal([]byte("a,1::5"), &got))
+	equal(t, record{A: "a", C: checked{Agency: 1, Code: "def", Total: 5}}, got)
+	// The hook of the config is called for the nested struct first.
+	equal(t, []string{"*engine.checked", "*engine.record"}, decoded)
+
+	err := e.Unmarshal([]byte("a,0:c:5"), &got)
+	equal(t, true, errors.Is(err, errUnchecked))
+
+	e = newTestEngine(func(cfg *Config) {
+		cfg.AfterDecode = func(any) error { return errUnchecked }
+	})
+	err = e.Unmarshal([]byte("a,1::5"), &got)
+	equal(t, true, errors.Is(err, errUnchecked))
+}
+
+func TestBeforeEncoder(t *testing.T) {
+	type record struct {
+		A string
+		C checked
+	}
+	e := newTestEngine(nil)
+
+	b, err := e.Marshal(record{A: "a", C: checked{Agency: 2, Code: "c"}})
+	equal(t, nil, err)
+	equal(t, "a,2:c:20", string(b))
+
+	// The hook of an addressable struct changes it.
+	value := &checked{Agency: 3}
+	b, err = e.Marshal(value)
+	equal(t, nil, err)
+	equal(t, "3,,30", string(b))
+	equal(t, 30, value.Total)
+
+	_, err = e.Marshal(record{C: checked{Agency: -1}})
+	equal(t, true, errors.Is(err, errUnchecked))
+}
@@ -0,0 +1,30 @@
+package engine
+
+import (
+	"reflect"
+)
+
+// Delegator is the interface implemented by a parsed tag, a *T of the engine Tag, of a field whose value
+// is itself encoded with another engine, e.g. a CSV record inside a field of a fixed-width record.
+// The value encoded by the inner engine is passed to Tag.Encode like any other value,
+// and the value written by Tag.Decode is decoded by the inner engine.
+type Delegator interface {
+	// Delegate returns the engine encoding and decoding the value of the field.
+	Delegate() Engine
+}
+
+func delegateEncoder[T any](inner Engine) encoderFunc[T] {
+	return func(s *encodeState[T], v reflect.Value) error {
+		p, err := inner.Marshal(v.Interface())
+		if err != nil {
+			return err
+		}
+		return s.encodeValue(p)
+	}
+}
+
+func delegateDecoder[T any](inner Engine) decoderFunc[T] {
+	return func(s *decodeState[T], v reflect.Value) error {
+		return inner.Unmarshal(s.Bytes(), pointerTo(v).Interface())
+	}
+}
@@ -0,0 +1,40 @@
+package engine
+
+import (
+	"testing"
+)
+
+// delegateMeta delegates its field to an engine separating the values with the value of the tag, see Delegator.
+type delegateMeta struct {
+	inner Engine
+}
+
+func (m *delegateMeta) parse(tagValue string) (bool, error) {
+	if tagValue != "" {
+		m.inner = newTestEngine(func(cfg *Config) { cfg.ValueSeparator = []byte(tagValue) })
+	}
+	return false, nil
+}
+
+func (m *delegateMeta) Delegate() Engine {
+	return m.inner
+}
+
+func TestDelegator(t *testing.T) {
+	type record struct {
+		A     string
+		Party party `test:";"`
+		Agent party
+		B     string
+	}
+	e := newEngineOf[delegateMeta](nil)
+
+	value := record{A: "a", Party: party{ID: "1", Agency: 9, Code: "c"}, Agent: party{ID: "2"}, B: "b"}
+	b, err := e.Marshal(value)
+	equal(t, nil, err)
+	equal(t, "a,1;9;c,2:0:,b", string(b))
+
+	var got record
+	equal(t, nil, e.Unmarshal(b, &got))
+	equal(t, value, got)
+}
@@ -0,0 +1,179 @@
+package engine
+
+import (
+	"reflect"
+	"strings"
+)
+
+// FieldDescriber is the interface implemented by types that aren't structs but consist of fields,
+// e.g. a map-backed document. The fields it describes are encoded and decoded like struct fields.
+type FieldDescriber interface {
+	// Fields returns the descriptors of the fields of the value in the order they are processed.
+	Fields() []FieldDescriptor
+}
+
+// FieldDescriptor describes a field of a FieldDescriber.
+type FieldDescriptor struct {
+	// Name is the name of the field.
+	Name string
+	// Tag is the tag of the field, the engine tag is looked up and parsed like the tag of a struct field.
+	Tag reflect.StructTag
+	// Type is the type of the value of the field.
+	Type reflect.Type
+	// Get returns the value of the field, nil means the zero value.
+	// If it is nil, the field is encoded as the zero value.
+	Get func() any
+	// Set sets the value of the field when decoding.
+	// If it is nil, the decoded value of the field is discarded.
+	Set func(v any) error
+}
+
+var describerType = reflect.TypeOf((*FieldDescriber)(nil)).Elem()
+
+// describedKey is the key of the fields described by a FieldDescriber in the field cache. The values of a type
+// may describe different fields, so the names and the tags of the descriptors are a part of the key.
+type describedKey struct {
+	typ       reflect.Type
+	dialect   string
+	signature string
+}
+
+// described is an entry of the field cache, the fields of the descriptors of the types.
+type described[T any] struct {
+	types  []reflect.Type
+	fields structFields[T]
+}
+
+// describedFields returns a list of fields described by the FieldDescriber.
+func (e *engine[T]) describedFields(d FieldDescriber) structFields[T] {
+	descriptors := d.Fields()
+	cached := e.cachedDescribed(reflect.Indirect(reflect.ValueOf(d)).Type(), descriptors)
+
+	// The cached fields are shared, the getters and the setters are of the value.
+	fields := make(structFields[T], len(cached))
+	copy(fields, cached)
+	for i := range fields {
+		fd := descriptors[fields[i].index]
+		fields[i].get, fields[i].put = descriptorGetter(fd), descriptorSetter(fd)
+	}
+	return fields
+}
+
+// cachedDescribed is like describedTypeFields but uses a cache to avoid repeated work.
+func (e *engine[T]) cachedDescribed(t reflect.Type, descriptors []FieldDescriptor) structFields[T] {
+	key := describedKey{typ: t}
+	if e.dialect != nil {
+		key.dialect = e.dialect.Name
+	}
+
+	var signature strings.Builder
+	types := make([]reflect.Type, len(descriptors))
+	for i, fd := range descriptors {
+		signature.WriteString(fd.Name)
+		signature.WriteByte(0)
+		signature.WriteString(string(fd.Tag))
+		signature.WriteByte(0)
+		types[i] = fd.Type
+	}
+	key.signature = signature.String()
+
+	c, ok := e.fields.Load(key)
+	if !ok {
+		c, _ = e.fields.LoadOrStore(key, described[T]{types: types, fields: e.describedTypeFields(t.Name(), descriptors)})
+	}
+	if c := c.(described[T]); sameTypes(c.types, types) {
+		return c.fields
+	}
+	// The descriptors of the same names and tags but other types aren't cached.
+	return e.describedTypeFields(t.Name(), descriptors)
+}
+
+// sameTypes reports whether the lists of types are equal.
+func sameTypes(a, b []reflect.Type) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// describedTypeFields returns a list of fields of the descriptors without their getters and setters.
+func (e *engine[T]) describedTypeFields(name string, descriptors []FieldDescriptor) structFields[T] {
+	fields := make(structFields[T], 0, len(descriptors))
+
+	for i, fd := range descriptors {
+		fld := field[T]{
+			index: i,
+			name:  fd.Name,
+			typ:   fd.Type,
+		}
+
+		if skip, err := e.parseTag(&fld, name, fd.Tag); skip {
+			continue
+		} else if err != nil {
+			return append(fields, fld)
+		}
+
+		e.setCoders(&fld, fd.Type)
+		fields = append(fields, fld)
+	}
+
+	e.sortFields(fields)
+
+	return fields
+}
+
+// descriptorGetter returns the getter of the described field, the zero value if its Get is nil or returns nil.
+func descriptorGetter(fd FieldDescriptor) func(reflect.Value) reflect.Value {
+	return func(reflect.Value) reflect.Value {
+		if fd.Get == nil {
+			return reflect.Zero(fd.Type)
+		}
+		rv := reflect.ValueOf(fd.Get())
+		if !rv.IsValid() {
+			return reflect.Zero(fd.Type)
+		}
+		return rv.Convert(fd.Type)
+	}
+}
+
+// descriptorSetter returns the setter of the described field, that discards the value if its Set is nil.
+func descriptorSetter(fd FieldDescriptor) func(v, rv reflect.Value) error {
+	return func(_, rv reflect.Value) error {
+		if fd.Set == nil {
+			return nil
+		}
+		return fd.Set(rv.Interface())
+	}
+}
+
+func describerEncoder[T any](s *encodeState[T], v reflect.Value) error {
+	v, err := beforeEncode(v)
+	if err != nil {
+		return err
+	}
+	f := s.describedFields(pointerTo(v).Interface().(FieldDescriber))
+
+	return s.structHooks(v.Type(), func() error {
+		return s.nest(s.fail, func() error {
+			return f.encode(s, v, s.wrap)
+		})
+	})
+}
+
+func describerDecoder[T any](s *decodeState[T], v reflect.Value) error {
+	f := s.describedFields(pointerTo(v).Interface().(FieldDescriber))
+
+	if err := s.structHooks(v.Type(), func() error {
+		return s.nest(s.fail, func() error {
+			return f.decode(s, v, s.wrap)
+		})
+	}); err != nil {
+		return err
+	}
+	return s.afterDecode(v)
+}
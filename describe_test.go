@@ -0,0 +1,132 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+)
+
+// document is a map-backed FieldDescriber, its "extra" field is described only if the map holds it.
+type document map[string]any
+
+func (d *document) Fields() []FieldDescriptor {
+	if *d == nil {
+		*d = document{}
+	}
+	m := *d
+
+	fields := []FieldDescriptor{
+		{
+			Name: "name",
+			Type: reflect.TypeOf(""),
+			Get:  func() any { return m["name"] },
+			Set:  func(v any) error { m["name"] = v; return nil },
+		},
+		{
+			Name: "age",
+			Tag:  `test:"age"`,
+			Type: reflect.TypeOf(0),
+			Get:  func() any { return m["age"] },
+			Set:  func(v any) error { m["age"] = v; return nil },
+		},
+		{
+			Name: "version",
+			Type: reflect.TypeOf(0),
+			Get:  nil,
+			Set:  nil,
+		},
+	}
+	if _, ok := m["extra"]; ok {
+		fields = append(fields, FieldDescriptor{
+			Name: "extra",
+			Type: reflect.TypeOf(""),
+			Get:  func() any { return m["extra"] },
+			Set:  func(v any) error { m["extra"] = v; return nil },
+		})
+	}
+	return fields
+}
+
+func TestFieldDescriber(t *testing.T) {
+	e := newTestEngine(nil)
+
+	var tests = []struct {
+		value  document
+		data   string
+		expect document
+	}{
+		{
+			value:  document{"name": "bob", "age": 3},
+			data:   "bob,3,0",
+			expect: document{"name": "bob", "age": 3},
+		},
+		{
+			value:  document{"age": 4},
+			data:   ",4,0",
+			expect: document{"age": 4},
+		},
+		{
+			value:  document{"name": "ann", "age": 5, "extra": "x"},
+			data:   "ann,5,0,x",
+			expect: document{"name": "ann", "age": 5, "extra": "x"},
+		},
+	}
+	for _, tt := range tests {
+		b, err := e.Marshal(&tt.value)
+		equal(t, nil, err)
+		equal(t, tt.data, string(b))
+
+		// The descriptors depend on the value decoded into.
+		got := document{}
+		if _, ok := tt.value["extra"]; ok {
+			got["extra"] = ""
+		}
+		equal(t, nil, e.Unmarshal(b, &got))
+		equal(t, tt.expect, got)
+	}
+
+	// The value of a field without Set is discarded.
+	var got document
+	equal(t, nil, e.Unmarshal([]byte("jim,7,9"), &got))
+	equal(t, document{"name": "jim", "age": 7}, got)
+
+	type wrapper struct {
+		ID  string
+		Doc document
+	}
+	b, err := e.Marshal(wrapper{ID: "a", Doc: document{"name": "bob", "age": 3}})
+	equal(t, nil, err)
+	equal(t, "a,bob:3:0", string(b))
+
+	var w wrapper
+	equal(t, nil, e.Unmarshal(b, &w))
+	equal(t, wrapper{ID: "a", Doc: document{"name": "bob", "age": 3}}, w)
+}
+
+// countedMeta counts the parsed tags.
+type countedMeta struct{}
+
+var parsedTags int
+
+func (*countedMeta) parse(string) (bool, error) {
+	parsedTags++
+	return false, nil
+}
+
+func TestFieldDescriberCache(t *testing.T) {
+	e := newEngineOf[countedMeta](nil)
+
+	parsedTags = 0
+	for i := 0; i < 3; i++ {
+		_, err := e.Marshal(&document{"name": "bob", "age": 3})
+		equal(t, nil, err)
+	}
+	equal(t, 1, parsedTags)
+
+	// Other descriptors of the type are cached separately.
+	for i := 0; i < 3; i++ {
+		b, err := e.Marshal(&document{"extra": "x"})
+		equal(t, nil, err)
+		equal(t, ",0,0,x", string(b))
+	}
+	equal(t, 2, parsedTags)
+}
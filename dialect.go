@@ -0,0 +1,97 @@
+package engine
+
+import (
+	"reflect"
+)
+
+// Dialect remaps the struct tags an engine reads, so that the same structs can be encoded and decoded
+// in the dialects of different tenants, see WithDialect.
+type Dialect struct {
+	// Name identifies the dialect, the fields of structs are cached per dialect name.
+	Name string
+	// TagKey is the key of the struct tags to read instead of the name of the Tag, e.g. "tenant".
+	// If it is empty, the name of the Tag is used.
+	TagKey string
+	// Tags replaces the values of the tags of fields, a key is the name of a field or the name qualified
+	// with the name of its struct as "Struct.Field" to remap the field of a single struct.
+	// A field without a tag gets the value.
+	Tags map[string]string
+}
+
+// WithDialect returns the engine e reading struct tags remapped by the dialect d,
+// ErrUnsupported if e doesn't have the WithDialect method.
+func WithDialect(e Engine, d Dialect) (Engine, error) {
+	if w, ok := e.(*wrapped); ok {
+		inner, err := WithDialect(w.inner, d)
+		if err != nil {
+			return nil, err
+		}
+		return w.rewrap(inner), nil
+	}
+	w, ok := implementation[interface{ WithDialect(Dialect) Engine }](e)
+	if !ok {
+		return nil, unsupported(e, "WithDialect")
+	}
+	return w.WithDialect(d), nil
+}
+
+// WithDialect returns the engine that reads struct tags remapped by the dialect.
+// It shares the Tag and the caches with the engine and starts with its current settings,
+// the profiles of the engine are available with the dialect as well.
+func (e *engine[T]) WithDialect(d Dialect) Engine {
+	if d.Tags != nil {
+		tags := make(map[string]string, len(d.Tags))
+		for name, tag := range d.Tags {
+			tags[name] = tag
+		}
+		d.Tags = tags
+	}
+
+	de := e.withDialect(&d)
+	de.profiles = make(map[string]*engine[T], len(e.profiles))
+	for name, p := range e.profiles {
+		de.profiles[name] = p.withDialect(&d)
+	}
+	return de
+}
+
+func (e *engine[T]) withDialect(d *Dialect) *engine[T] {
+	de := &engine[T]{
+		Tag:            e.Tag,
+		marshaller:     e.marshaller,
+		unmarshaler:    e.unmarshaler,
+		binary:         e.binary,
+		fieldLess:      e.fieldLess,
+		nameMapper:     e.nameMapper,
+		requireTag:     e.requireTag,
+		dialect:        d,
+		fields:         e.fields,
+		encoders:       e.encoders,
+		decoders:       e.decoders,
+		customEncoders: e.customEncoders,
+		customDecoders: e.customDecoders,
+		interceptors:   e.interceptors,
+	}
+	de.current.Store(e.load())
+	return de
+}
+
+// lookupTag returns the value of the engine tag of the field of the struct, remapped by the dialect if any.
+func (e *engine[T]) lookupTag(structName, fieldName string, structTag reflect.StructTag) (string, bool) {
+	if e.dialect == nil {
+		return structTag.Lookup(e.Name())
+	}
+
+	if tag, ok := e.dialect.Tags[structName+"."+fieldName]; ok {
+		return tag, true
+	}
+	if tag, ok := e.dialect.Tags[fieldName]; ok {
+		return tag, true
+	}
+
+	key := e.dialect.TagKey
+	if key == "" {
+		key = e.Name()
+	}
+	return structTag.Lookup(key)
+}
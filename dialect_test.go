@@ -0,0 +1,83 @@
+package engine
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// dialectTag writes the values as "name=value" with the values of the tags as the names,
+// the decoding drops the names.
+type dialectTag struct {
+	testTag[testMeta]
+}
+
+func (dialectTag) Encode(fieldName string, meta *testMeta, in []byte, out Writer) error {
+	if meta != nil && meta.value != "" {
+		fieldName = meta.value
+	}
+	if _, err := out.WriteString(fieldName + "="); err != nil {
+		return err
+	}
+	_, err := out.Write(in)
+	return err
+}
+
+func (dialectTag) Decode(_ string, _ *testMeta, in []byte, out Writer) error {
+	_, value, _ := bytes.Cut(in, []byte("="))
+	_, err := out.Write(value)
+	return err
+}
+
+type tenant struct {
+	Name string `test:"name" custom:"nm"`
+	Age  int    `test:"age"`
+	City string
+}
+
+func TestWithDialect(t *testing.T) {
+	e := New[testMeta](dialectTag{}, testConfig())
+
+	var tests = []struct {
+		dialect *Dialect
+		expect  string
+	}{
+		{
+			dialect: nil,
+			expect:  "name=x,age=1,City=c",
+		},
+		{
+			dialect: &Dialect{Name: "a", Tags: map[string]string{"tenant.Age": "years", "City": "town"}},
+			expect:  "name=x,years=1,town=c",
+		},
+		{
+			dialect: &Dialect{Name: "b", TagKey: "custom", Tags: map[string]string{"Age": "-"}},
+			expect:  "nm=x,City=c",
+		},
+	}
+	for _, tt := range tests {
+		de := e
+		if tt.dialect != nil {
+			var err error
+			de, err = WithDialect(e, *tt.dialect)
+			equal(t, nil, err)
+		}
+		b, err := de.Marshal(tenant{Name: "x", Age: 1, City: "c"})
+		equal(t, nil, err)
+		equal(t, tt.expect, string(b))
+
+		var got tenant
+		equal(t, nil, de.Unmarshal(b, &got))
+		b, err = de.Marshal(got)
+		equal(t, nil, err)
+		equal(t, tt.expect, string(b))
+	}
+
+	// The engine and the dialects share the cache but not the fields.
+	b, err := e.Marshal(tenant{Name: "x", Age: 1, City: "c"})
+	equal(t, nil, err)
+	equal(t, "name=x,age=1,City=c", string(b))
+
+	_, err = WithDialect(foreignEngine{e}, Dialect{Name: "a"})
+	equal(t, true, errors.Is(err, ErrUnsupported))
+}
@@ -0,0 +1,86 @@
+package engine
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// A Dispatcher decodes payloads that start with a header selecting the engine that decodes the rest of them,
+// see Dispatch.
+type Dispatcher struct {
+	e      streamer
+	err    error // the engine can't decode the headers, see Dispatch
+	header reflect.Type
+	key    func(header any) string
+	routes map[string]route
+}
+
+// route is the engine decoding the rest of payloads with a header key and the type of the value it decodes.
+type route struct {
+	e   Engine
+	typ reflect.Type
+}
+
+// Dispatch returns a dispatcher that decodes the header of payloads with the engine e into a new value
+// of the type of the prototype, or of the type it points to. The key function returns the key
+// of the engine registered to decode the rest of a payload from the header, a pointer to the header value.
+// The header must be decoded with the library splitting the data itself, see Config.ComponentSeparator,
+// the rest of a payload starts after the separator following the last field of the header.
+// The dispatcher of an engine without the Dispatch method returns ErrUnsupported.
+func Dispatch(e Engine, prototype any, key func(header any) string) *Dispatcher {
+	if d, ok := implementation[dispatcher](e); ok {
+		return d.Dispatch(prototype, key)
+	}
+	return &Dispatcher{err: unsupported(e, "Dispatch"), routes: make(map[string]route)}
+}
+
+// dispatcher is implemented by the engines decoding the headers of payloads, see Dispatch.
+type dispatcher interface {
+	Dispatch(prototype any, key func(header any) string) *Dispatcher
+}
+
+// Dispatch returns a dispatcher that decodes the header of payloads with the engine, see the function Dispatch.
+func (e *engine[T]) Dispatch(prototype any, key func(header any) string) *Dispatcher {
+	return &Dispatcher{e: e, header: elemType(prototype), key: key, routes: make(map[string]route)}
+}
+
+// Register registers the engine decoding the rest of payloads with the header key into new values
+// of the type of the prototype, or of the type it points to.
+func (d *Dispatcher) Register(key string, e Engine, prototype any) {
+	d.routes[key] = route{e: e, typ: elemType(prototype)}
+}
+
+// Decode decodes the header of the data and the rest of it with the engine the header selects.
+// It returns pointers to the new values of the header and the rest.
+func (d *Dispatcher) Decode(data []byte) (header, value any, err error) {
+	if d.err != nil {
+		return nil, nil, d.err
+	}
+
+	var rest []byte
+	header = reflect.New(d.header).Interface()
+	if err = d.e.unmarshalWith(data, header, decodeOptions{rest: &rest}); err != nil {
+		return nil, nil, err
+	}
+
+	key := d.key(header)
+	r, ok := d.routes[key]
+	if !ok {
+		return header, nil, fmt.Errorf("%s: %w: %q", d.e.Name(), ErrNoEngine, key)
+	}
+
+	value = reflect.New(r.typ).Interface()
+	if err = UnmarshalNoCopy(r.e, rest, value); err != nil {
+		return header, nil, err
+	}
+	return header, value, nil
+}
+
+// elemType returns the type of the prototype, or of the type it points to.
+func elemType(prototype any) reflect.Type {
+	t := reflect.TypeOf(prototype)
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
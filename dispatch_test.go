@@ -0,0 +1,68 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+)
+
+type envelope struct {
+	Kind string
+}
+
+type purchase struct {
+	ID  string
+	Qty int
+}
+
+type invoice struct {
+	ID     string
+	Amount int
+	Paid   bool
+}
+
+func TestDispatch(t *testing.T) {
+	e := newTestEngine(nil)
+	key := func(header any) string { return header.(*envelope).Kind }
+	d := Dispatch(e, envelope{}, key)
+	d.Register("ORD", newTestEngine(nil), purchase{})
+	d.Register("INV", newTestEngine(func(cfg *Config) { cfg.ValueSeparator = []byte(";") }), &invoice{})
+
+	var tests = []struct {
+		data   string
+		header any
+		value  any
+		err    error
+	}{
+		{
+			data:   "ORD,1,5",
+			header: &envelope{Kind: "ORD"},
+			value:  &purchase{ID: "1", Qty: 5},
+		},
+		{
+			data:   "INV,2;10;true",
+			header: &envelope{Kind: "INV"},
+			value:  &invoice{ID: "2", Amount: 10, Paid: true},
+		},
+		{
+			data:   "XYZ,1",
+			header: &envelope{Kind: "XYZ"},
+			err:    ErrNoEngine,
+		},
+	}
+	for _, tt := range tests {
+		header, value, err := d.Decode([]byte(tt.data))
+		equal(t, true, errors.Is(err, tt.err))
+		equal(t, tt.header, header)
+		if tt.err == nil {
+			equal(t, tt.value, value)
+		}
+	}
+
+	// The rest of payloads is decoded by any engine, but the header needs the Dispatch method.
+	d.Register("ORD", foreignEngine{e}, purchase{})
+	_, value, err := d.Decode([]byte("ORD,1,5"))
+	equal(t, nil, err)
+	equal(t, &purchase{ID: "1", Qty: 5}, value)
+	_, _, err = Dispatch(foreignEngine{e}, envelope{}, key).Decode([]byte("ORD,1,5"))
+	equal(t, true, errors.Is(err, ErrUnsupported))
+}
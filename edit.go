@@ -0,0 +1,149 @@
+package engine
+
+import (
+	"bytes"
+	"strings"
+)
+
+// An Edit re-encodes a value decoded for editing, see NewEdit.
+type Edit struct {
+	e        editor
+	v        any
+	data     []byte
+	spans    []span // of the top-level fields in the data
+	original []byte // the value encoded as it was decoded
+	encoded  []span // of the top-level fields in the original
+}
+
+// span is the offsets of the value of a field.
+type span struct {
+	path       string
+	start, end int
+}
+
+// editor is implemented by the engine to encode a value reporting the offsets of its fields.
+type editor interface {
+	marshalSpans(v any) ([]byte, []span, error)
+}
+
+// NewEdit decodes the data with the engine e into the value pointed to by v for editing, the value can be changed
+// and re-encoded with Edit.Encode. It returns ErrUnsupported if e doesn't have the Edit method.
+func NewEdit(e Engine, data []byte, v any) (*Edit, error) {
+	if ed, ok := e.(editable); ok {
+		return ed.Edit(data, v)
+	}
+	return nil, unsupported(e, "Edit")
+}
+
+// editable is implemented by the engines decoding values for editing, see NewEdit.
+type editable interface {
+	Edit(data []byte, v any) (*Edit, error)
+}
+
+// Edit decodes the data into the value pointed to by v for editing, the value can be changed
+// and re-encoded with Edit.Encode. It needs the library to split the data itself, see Config.ComponentSeparator.
+func (e *engine[T]) Edit(data []byte, v any) (*Edit, error) {
+	data = append([]byte(nil), data...)
+
+	var spans []span
+	if err := e.UnmarshalSpans(data, v, func(path string, start, end int) {
+		if !strings.Contains(path, ".") {
+			spans = append(spans, span{path: path, start: start, end: end})
+		}
+	}); err != nil {
+		return nil, err
+	}
+
+	original, encoded, err := e.marshalSpans(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Edit{e: e, v: v, data: data, spans: spans, original: original, encoded: encoded}, nil
+}
+
+// Encode encodes the edited value. The top-level fields whose encoded values didn't change keep their bytes
+// from the data, so incidental formatting is preserved, and the others are replaced with their new encoded values.
+// If the edit changed the set of the fields, e.g. an empty value was omitted, or the values of the fields
+// don't follow their order in the data, e.g. of keyed data, the value is encoded as a whole.
+func (ed *Edit) Encode() ([]byte, error) {
+	edited, spans, err := ed.e.marshalSpans(ed.v)
+	if err != nil {
+		return nil, err
+	}
+
+	if !samePaths(ed.spans, ed.encoded) || !samePaths(ed.spans, spans) || !inOrder(ed.spans) {
+		return edited, nil
+	}
+
+	out := make([]byte, 0, len(ed.data))
+	last := 0
+	for i, sp := range ed.spans {
+		out = append(out, ed.data[last:sp.start]...)
+		if value := edited[spans[i].start:spans[i].end]; bytes.Equal(value, ed.original[ed.encoded[i].start:ed.encoded[i].end]) {
+			out = append(out, ed.data[sp.start:sp.end]...)
+		} else {
+			out = append(out, value...)
+		}
+		last = sp.end
+	}
+	return append(out, ed.data[last:]...), nil
+}
+
+// marshalSpans is like Marshal but returns the offsets of the top-level fields in the encoded data as well.
+func (e *engine[T]) marshalSpans(v any) ([]byte, []span, error) {
+	s := e.newEncodeState()
+	defer encodeStatePool.Put(s)
+
+	var spans []span
+	s.paths = true
+	s.spans = func(path string, start, end int) {
+		if !strings.Contains(path, ".") {
+			spans = append(spans, span{path: path, start: start, end: end})
+		}
+	}
+
+	if s.marshal(v); s.err != nil {
+		return nil, nil, s.err
+	}
+
+	// Drop the spans of the empty values trimmed at the end, see Config.TrimTrailingEmpty.
+	for len(spans) != 0 && spans[len(spans)-1].end > s.Len() {
+		spans = spans[:len(spans)-1]
+	}
+	// The values of keyed data are reported without their keys when decoding, see KeyedTag.
+	if kt, ok := any(e.Tag).(KeyedTag); ok {
+		for i, sp := range spans {
+			value := s.Bytes()[sp.start:sp.end]
+			if _, rest, ok := kt.CutKey(value); ok {
+				if at := within(value, rest); at >= 0 {
+					spans[i].start, spans[i].end = sp.start+at, sp.start+at+len(rest)
+				}
+			}
+		}
+	}
+	return append([]byte(nil), s.Bytes()...), spans, nil
+}
+
+// samePaths reports whether the spans are of the same fields.
+func samePaths(a, b []span) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].path != b[i].path {
+			return false
+		}
+	}
+	return true
+}
+
+// inOrder reports whether the spans follow each other in the data.
+func inOrder(spans []span) bool {
+	for i := 1; i < len(spans); i++ {
+		if spans[i].start < spans[i-1].end {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,54 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEdit(t *testing.T) {
+	type inner struct{ X, Y string }
+	type record struct {
+		A string
+		I inner
+		N int
+		B string `test:"omitempty"`
+	}
+	e := newTestEngine(nil)
+
+	var r record
+	ed, err := NewEdit(e, []byte(" ab,x:y,007,zz"), &r)
+	equal(t, nil, err)
+	equal(t, record{" ab", inner{"x", "y"}, 7, "zz"}, r)
+
+	var tests = []struct {
+		edit   func(r *record)
+		expect string
+	}{
+		{
+			edit:   func(*record) {},
+			expect: " ab,x:y,007,zz",
+		},
+		{
+			edit:   func(r *record) { r.B = "new" },
+			expect: " ab,x:y,007,new",
+		},
+		{
+			edit:   func(r *record) { r.I.Y = "Q" },
+			expect: " ab,x:Q,007,new",
+		},
+		{
+			// The fields changed, the value is encoded as a whole.
+			edit:   func(r *record) { r.B = "" },
+			expect: " ab,x:Q,7",
+		},
+	}
+	for _, tt := range tests {
+		tt.edit(&r)
+		b, err := ed.Encode()
+		equal(t, nil, err)
+		equal(t, tt.expect, string(b))
+	}
+
+	_, err = NewEdit(foreignEngine{e}, []byte(" ab,x:y,007,zz"), &r)
+	equal(t, true, errors.Is(err, ErrUnsupported))
+}
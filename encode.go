@@ -2,11 +2,13 @@ package engine
 
 import (
 	"bytes"
+	"encoding"
 	"errors"
 	"fmt"
 	"reflect"
 	"strconv"
 	"sync"
+	"time"
 )
 
 const marshalError = "encode data from"
@@ -14,53 +16,164 @@ const marshalError = "encode data from"
 // Marshal encodes the value v and returns the encoded data.
 // If v is nil, Marshal returns an encoder error.
 func (e *engine[T]) Marshal(v any) (out []byte, err error) {
+	return e.MarshalAppend(nil, v)
+}
+
+// MarshalAppend appends the value v encoded with the engine e to dst and returns the extended buffer,
+// so that the caller can reuse its buffer. If encoding fails, dst is returned unchanged with the error.
+// An engine without the MarshalAppend method encodes the value with Marshal.
+func MarshalAppend(e Engine, dst []byte, v any) ([]byte, error) {
+	if m, ok := e.(appender); ok {
+		return m.MarshalAppend(dst, v)
+	}
+	data, err := e.Marshal(v)
+	if err != nil {
+		return dst, err
+	}
+	return append(dst, data...), nil
+}
+
+// appender is implemented by the engines appending the encoded values to a buffer, see MarshalAppend.
+type appender interface {
+	MarshalAppend(dst []byte, v any) ([]byte, error)
+}
+
+// MarshalAppend appends the encoded value v to dst and returns the extended buffer.
+// If encoding fails, dst is returned unchanged with the error.
+func (e *engine[T]) MarshalAppend(dst []byte, v any) ([]byte, error) {
 	s := e.newEncodeState()
 	defer encodeStatePool.Put(s)
 
-	s.marshal(v)
-	return s.Bytes(), s.err
+	if s.marshal(v); s.err != nil {
+		return dst, s.err
+	}
+	// The buffer goes back to the pool, the encoded data mustn't share it.
+	return append(dst, s.Bytes()...), nil
+}
+
+// MarshalIndent is like Marshal but starts a new line after every struct opener and separator and before
+// every struct closer, the line begins with the prefix followed by one copy of the indent per nesting depth,
+// so that the output of a text format encoded with the engine e is human-readable. The output decodes only
+// if the format ignores the whitespace around the values.
+// It returns ErrUnsupported if e doesn't have the MarshalIndent method.
+func MarshalIndent(e Engine, v any, prefix, indent string) ([]byte, error) {
+	if m, ok := e.(indenter); ok {
+		return m.MarshalIndent(v, prefix, indent)
+	}
+	return nil, unsupported(e, "MarshalIndent")
+}
+
+// indenter is implemented by the engines indenting the encoded values, see MarshalIndent.
+type indenter interface {
+	MarshalIndent(v any, prefix, indent string) ([]byte, error)
+}
+
+// MarshalIndent is like Marshal but indents the output, see the function MarshalIndent.
+func (e *engine[T]) MarshalIndent(v any, prefix, indent string) ([]byte, error) {
+	s := e.newEncodeState()
+	defer encodeStatePool.Put(s)
+
+	s.prefix, s.indent = []byte(prefix), append([]byte{}, indent...)
+	if s.marshal(v); s.err != nil {
+		return nil, s.err
+	}
+	return append([]byte(nil), s.Bytes()...), nil
 }
 
 type encodeState[T any] struct {
 	*engine[T]
+	*settings // taken once per value, see Reconfigure
 	context[T]
 	*bytes.Buffer // accumulated output
 	scratch       [64]byte
+	escaped       []byte
+	controlled    []byte // the value with its control characters handled, see Config.ControlCharsWhenEncoding
+	validated     []byte // the value restricted to the output character set, see OutputCharsetValidator
+	converted     []byte // the value in the charset of the field, see Charset
+	transformed   []byte // the value transformed, see Transform
+	prefix        []byte // begins every line of the indented output, see MarshalIndent
+	indent        []byte // repeated per nesting depth, the output is indented unless it is nil
+	list          []byte // elements of the slice being encoded
+	listing       bool
+	spans         func(path string, start, end int) // reports the offsets of the fields in the output
+	chunk         func(p []byte) error              // takes the output in chunks, see ChunkedEncoder
+	chunkSize     int
+	hold          int // the number of the structs being encoded whose output may still change, see yield
+	ptrLevel      int
+	ptrSeen       map[seenPointer]int  // the pointers being encoded and the depths of their structs, see pointerEncoder
+	nilSeen       map[reflect.Type]int // the types of the nil pointers being encoded as zero values, see encodeNil
+}
+
+// seenPointer is a pointer being encoded, the type tells a struct from its first field.
+type seenPointer struct {
+	ptr uintptr
+	typ reflect.Type
 }
 
+// startDetectingCyclesAfter is the nesting depth of pointers after which the pointers being encoded are tracked
+// to detect cycles, so that the values that are nested less deeply don't pay for it.
+const startDetectingCyclesAfter = 1000
+
 var encodeStatePool sync.Pool
 
 func (e *engine[T]) newEncodeState() *encodeState[T] {
-	if p := encodeStatePool.Get(); p != nil {
-		s := p.(*encodeState[T])
+	// The pool is shared by all engines, a state is bound to the engine that takes it.
+	if s, ok := encodeStatePool.Get().(*encodeState[T]); ok {
+		s.engine = e
+		s.settings = e.load()
 		s.Reset()
-		s.err = nil
+		s.context = context[T]{maxDepth: s.settings.maxDepth}
+		s.list, s.listing = nil, false
+		s.spans = nil
+		s.chunk, s.chunkSize, s.hold = nil, 0, 0
+		s.ptrLevel = 0
+		clear(s.ptrSeen)
+		clear(s.nilSeen)
+		s.prefix, s.indent = nil, nil
 		return s
 	}
 
-	return &encodeState[T]{engine: e, Buffer: new(bytes.Buffer)}
+	settings := e.load()
+	return &encodeState[T]{engine: e, settings: settings, context: context[T]{maxDepth: settings.maxDepth}, Buffer: new(bytes.Buffer)}
 }
 
 func (s *encodeState[T]) marshal(v any) {
-	if err := s.reflectValue(reflect.ValueOf(v)); err != nil {
-		if !errors.Is(err, errExist) {
-			s.setError(s.Name(), marshalError, err)
+	s.marshalValue(reflect.ValueOf(v))
+}
+
+func (s *encodeState[T]) marshalValue(rv reflect.Value) {
+	if err := s.reflectValue(rv); err != nil {
+		// The error of a top-level value that isn't a struct is of its type.
+		if s.structName == "" && rv.IsValid() && !errors.Is(err, errExist) {
+			s.field.typ = rv.Type()
 		}
+		s.fail(err)
 		s.Reset()
 	}
 }
 
+// fail sets the error of the current field unless it is set already.
+// A nested struct fails before the context of the enclosing one is restored, see context.nest.
+func (s *encodeState[T]) fail(err error) {
+	if !errors.Is(err, errExist) {
+		s.setEncodeError(err)
+	}
+}
+
+// setEncodeError sets the error of the current field.
+func (s *encodeState[T]) setEncodeError(err error) {
+	s.setError(s.Name(), marshalError, err)
+}
+
 func (s *encodeState[T]) reflectValue(v reflect.Value) error {
 	return s.cache(v.Type())(s, v)
 }
 
 type encoderFunc[T any] func(*encodeState[T], reflect.Value) error
 
-var encoderCache sync.Map // map[reflect.Type]encoderFunc[T]
-
 // cache uses a cache to avoid repeated work.
 func (s *encodeState[T]) cache(t reflect.Type) encoderFunc[T] {
-	if c, ok := encoderCache.Load(t); ok {
+	if c, ok := s.encoders.Load(t); ok {
 		return c.(encoderFunc[T])
 	}
 
@@ -72,7 +185,7 @@ func (s *encodeState[T]) cache(t reflect.Type) encoderFunc[T] {
 		f  encoderFunc[T]
 	)
 	wg.Add(1)
-	c, loaded := encoderCache.LoadOrStore(t, encoderFunc[T](func(s *encodeState[T], v reflect.Value) error {
+	c, loaded := s.encoders.LoadOrStore(t, encoderFunc[T](func(s *encodeState[T], v reflect.Value) error {
 		wg.Wait()
 		return f(s, v)
 	}))
@@ -83,7 +196,7 @@ func (s *encodeState[T]) cache(t reflect.Type) encoderFunc[T] {
 	// Compute the real encoder and replace the indirect func with it.
 	f, _ = s.typeCoders(t)
 	wg.Done()
-	encoderCache.Store(t, f)
+	s.encoders.Store(t, f)
 	return f
 }
 
@@ -97,44 +210,158 @@ func valueFromPtr(v reflect.Value) reflect.Value {
 func (f *structFields[T]) encode(s *encodeState[T], v reflect.Value, wrap bool) (err error) {
 	var sep bool
 
-	s.structName = v.Type().Name()
+	structName := s.enter(v.Type())
+	defer func() {
+		if err == nil {
+			s.leave(structName)
+		}
+	}()
+	separator := s.separator(s.depth)
+
+	// The separators of the empty values may be dropped, see yield.
+	if s.trimTrailing {
+		s.hold++
+		defer func() { s.hold-- }()
+	}
 
 	if wrap {
 		s.Write(s.structOpener)
+		s.newline(s.depth)
 	}
 
+	// end is the end of the last non-empty value.
+	end := s.Len()
+
 	for _, s.field = range *f {
-		rv := v.Field(s.field.index)
+		if s.field.extras {
+			continue
+		}
+
+		rv := s.field.value(v)
 
 		// Ignore the field if empty values can be omitted.
-		if s.field.omitEmpty && isEmptyValue(rv) {
+		if s.field.omitEmpty && s.isEmpty(rv) {
+			continue
+		}
+		if s.nilInterface == NilInterfaceSkip && rv.Kind() == reflect.Interface && rv.IsNil() {
 			continue
 		}
 
 		if sep {
-			s.Write(s.valueSeparator)
+			s.Write(separator)
+			s.newline(s.depth)
 		}
-		sep = s.separate
+		sep = len(separator) != 0
 
+		start := s.Len()
 		if s.field.embedded != nil {
-			if err = s.field.embedded.encode(s, valueFromPtr(rv), false); err != nil {
-				return
+			if rv.Kind() == reflect.Pointer {
+				rv = valueFromPtr(rv)
 			}
-			continue
+			err = s.field.embedded.encode(s, rv, false)
+		} else {
+			err = s.encodeField(rv)
+		}
+		if err != nil {
+			return
 		}
 
-		if err = s.field.encoder(s, rv); err != nil {
+		if s.spans != nil && s.field.embedded == nil {
+			s.spans(s.fieldPath(), start, s.Len())
+		}
+		if s.Len() > start {
+			end = s.Len()
+		}
+		if err = s.yield(); err != nil {
 			return
 		}
 	}
 
+	if f.encodeExtras(s, v, sep) {
+		end = s.Len()
+	}
+
+	// Drop the separators of the empty values at the end of the struct.
+	if s.trimTrailing {
+		s.Truncate(end)
+	}
+
 	if wrap {
+		s.newline(s.depth - 1)
 		s.Write(s.structCloser)
 	}
 
 	return
 }
 
+// newline starts a new line of the indented output at the nesting depth, see MarshalIndent.
+func (s *encodeState[T]) newline(depth int) {
+	if s.indent == nil {
+		return
+	}
+	s.WriteByte('\n')
+	s.Write(s.prefix)
+	for i := 0; i < depth; i++ {
+		s.Write(s.indent)
+	}
+}
+
+// encodeValue canonicalizes and escapes the encoded value of the current field and passes it to Tag.Encode,
+// or appends it to the list when it is an element of a slice.
+func (s *encodeState[T]) encodeValue(p []byte) error {
+	if s.canonicalize != nil {
+		p = s.canonicalize(p)
+	}
+	p, err := s.convert(p)
+	if err != nil {
+		return err
+	}
+	if tf := s.field.transform; tf != nil {
+		if s.transformed, err = tf.Encode(s.transformed[:0], p); err != nil {
+			return err
+		}
+		p = s.transformed
+	}
+	if s.escape != 0 {
+		s.escaped = s.escapeValue(s.escaped[:0], p)
+		p = s.escaped
+	}
+	if s.listing {
+		s.list = append(s.list, p...)
+		return nil
+	}
+	return s.Encode(s.field.key, s.field.meta, p, s.Buffer)
+}
+
+// escapeValue appends the value to dst inserting the EscapeChar before every separator, opener, closer
+// and EscapeChar, see Config.EscapeChar.
+func (s *settings) escapeValue(dst, p []byte) []byte {
+	for i := 0; i < len(p); {
+		if n := s.special(p[i:]); n != 0 {
+			dst = append(append(dst, s.escape), p[i:i+n]...)
+			i += n
+			continue
+		}
+		dst = append(dst, p[i])
+		i++
+	}
+	return dst
+}
+
+// special returns the length of the separator, opener, closer or EscapeChar at the beginning of p,
+// or 0 if there is none.
+func (s *settings) special(p []byte) int {
+	if p[0] == s.escape {
+		return 1
+	}
+	for _, b := range s.specials {
+		if bytes.HasPrefix(p, b) {
+			return len(b)
+		}
+	}
+	return 0
+}
+
 func marshallerEncoder[T any](s *encodeState[T], v reflect.Value) error {
 	tmp := reflect.ValueOf(v.Interface())
 	v = reflect.New(v.Type())
@@ -150,52 +377,347 @@ func marshallerEncoder[T any](s *encodeState[T], v reflect.Value) error {
 		return err
 	}
 
-	return s.Encode(s.field.name, s.field.meta, p, s.Buffer)
+	return s.encodeValue(p)
+}
+
+func binaryMarshalerEncoder[T any](s *encodeState[T], v reflect.Value) error {
+	p, err := pointerTo(v).Interface().(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		return err
+	}
+	return s.encodeValue(p)
 }
 
 func boolEncoder[T any](s *encodeState[T], v reflect.Value) error {
-	return s.Encode(s.field.name, s.field.meta, strconv.AppendBool(s.scratch[:0], v.Bool()), s.Buffer)
+	return s.encodeValue(strconv.AppendBool(s.scratch[:0], v.Bool()))
 }
 
 func intEncoder[T any](s *encodeState[T], v reflect.Value) error {
-	return s.Encode(s.field.name, s.field.meta, strconv.AppendInt(s.scratch[:0], v.Int(), 10), s.Buffer)
+	return s.encodeValue(strconv.AppendInt(s.scratch[:0], v.Int(), 10))
+}
+
+// durationEncoder writes a time.Duration as integer nanoseconds or as a string, see Config.DurationAsString.
+func durationEncoder[T any](s *encodeState[T], v reflect.Value) error {
+	if !s.durationString {
+		return intEncoder(s, v)
+	}
+	return s.encodeValue(append(s.scratch[:0], time.Duration(v.Int()).String()...))
 }
 
 func uintEncoder[T any](s *encodeState[T], v reflect.Value) error {
-	return s.Encode(s.field.name, s.field.meta, strconv.AppendUint(s.scratch[:0], v.Uint(), 10), s.Buffer)
+	return s.encodeValue(strconv.AppendUint(s.scratch[:0], v.Uint(), 10))
 }
 
 func floatEncoder[T any](s *encodeState[T], v reflect.Value) error {
-	return s.Encode(s.field.name, s.field.meta, strconv.AppendFloat(s.scratch[:0], v.Float(), 'g', -1, bitSize(v.Kind())), s.Buffer)
+	return s.encodeValue(strconv.AppendFloat(s.scratch[:0], v.Float(), 'g', -1, bitSize(v.Kind())))
 }
 
 func interfaceEncoder[T any](s *encodeState[T], v reflect.Value) error {
 	if v.IsNil() {
+		switch s.nilInterface {
+		case NilInterfaceSkip:
+			return s.encodeValue(nil)
+		case NilInterfaceLiteral:
+			return s.encodeValue(s.nilLiteral)
+		}
 		s.err = ErrNilInterface
 		return errExist
 	}
 	return s.reflectValue(v.Elem())
 }
 
+// pointerEncoder encodes the value the pointer points to, see encodeNil for a nil pointer.
+// It fails with ErrCycle if the value holds the pointer itself, see startDetectingCyclesAfter.
 func pointerEncoder[T any](s *encodeState[T], v reflect.Value) error {
+	if v.IsNil() {
+		return s.encodeNil(v)
+	}
+
+	if s.ptrLevel++; s.ptrLevel > startDetectingCyclesAfter {
+		ptr := seenPointer{ptr: v.Pointer(), typ: v.Type()}
+		if depth, ok := s.ptrSeen[ptr]; ok {
+			// The path of the error holds the outermost struct and a single turn of the cycle.
+			s.structs = append(s.structs[:1], s.structs[depth:]...)
+			return fmt.Errorf("%w via %s", ErrCycle, v.Type())
+		}
+		if s.ptrSeen == nil {
+			s.ptrSeen = make(map[seenPointer]int)
+		}
+		s.ptrSeen[ptr] = len(s.structs)
+		defer delete(s.ptrSeen, ptr)
+	}
+	err := s.reflectValue(v.Elem())
+	s.ptrLevel--
+	return err
+}
+
+// encodeNil encodes the nil pointer v, see NilPointerPolicy. It fails with ErrCycle if v is within
+// the zero value of the type it points to.
+func (s *encodeState[T]) encodeNil(v reflect.Value) error {
+	if s.nilPointers == NilPointerEmpty {
+		return s.encodeValue(nil)
+	}
+	typ := v.Type()
+	if depth, ok := s.nilSeen[typ]; ok {
+		// The path of the error holds the outermost struct and a single turn of the cycle.
+		s.structs = append(s.structs[:1], s.structs[depth:]...)
+		return fmt.Errorf("%w via nil %s, see NilPointerEmpty", ErrCycle, typ)
+	}
+	if s.nilSeen == nil {
+		s.nilSeen = make(map[reflect.Type]int)
+	}
+	s.nilSeen[typ] = len(s.structs)
+	defer delete(s.nilSeen, typ)
 	return s.reflectValue(valueFromPtr(v))
 }
 
 func bytesEncoder[T any](s *encodeState[T], v reflect.Value) error {
-	return s.Encode(s.field.name, s.field.meta, v.Bytes(), s.Buffer)
+	return s.encodeValue(v.Bytes())
+}
+
+func byteArrayEncoder[T any](s *encodeState[T], v reflect.Value) error {
+	p := make([]byte, v.Len())
+	reflect.Copy(reflect.ValueOf(p), v)
+	return s.encodeValue(p)
 }
 
+// sliceEncoder joins the encoded elements of a slice into a list wrapped with the SliceOpener and the SliceCloser.
 func sliceEncoder[T any](s *encodeState[T], v reflect.Value) error {
-	return nil // TODO
+	return s.encodeList(s.sliceOpener, s.sliceCloser, func() error {
+		for i := 0; i < v.Len(); i++ {
+			if i != 0 {
+				s.list = append(s.list, s.elementSeparator...)
+			}
+			if err := s.reflectValue(v.Index(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// mapEncoder joins the encoded entries of a map into a list wrapped with the MapOpener and the MapCloser.
+// An entry is the key and the value separated by the KeyValueSeparator, see KeyOrder for the order of the entries.
+func mapEncoder[T any](s *encodeState[T], v reflect.Value) error {
+	keys, m := s.mapEntries(v)
+
+	return s.encodeList(s.mapOpener, s.mapCloser, func() error {
+		for i, key := range keys {
+			if i != 0 {
+				s.list = append(s.list, s.entrySeparator...)
+			}
+
+			p, err := s.encodeKey(key)
+			if err != nil {
+				return err
+			}
+			if s.escape != 0 {
+				s.list = s.escapeValue(s.list, p)
+			} else {
+				s.list = append(s.list, p...)
+			}
+			s.list = append(s.list, s.keyValueSeparator...)
+
+			if err := s.reflectValue(m.MapIndex(key)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// compositeMapEncoder writes the entries of a map of composite values one after another, separated
+// by the EntrySeparator, and the map wrapped with the MapOpener and the MapCloser.
+func compositeMapEncoder[T any](s *encodeState[T], v reflect.Value) error {
+	s.Write(s.mapOpener)
+
+	keys, m := s.mapEntries(v)
+	for i, key := range keys {
+		if i != 0 {
+			s.Write(s.entrySeparator)
+		}
+
+		p, err := s.encodeKey(key)
+		if err != nil {
+			return err
+		}
+		if s.escape != 0 {
+			s.escaped = s.escapeValue(s.escaped[:0], p)
+			p = s.escaped
+		}
+		s.Write(p)
+		s.Write(s.keyValueSeparator)
+
+		if err := s.reflectValue(m.MapIndex(key)); err != nil {
+			return err
+		}
+		if err := s.yield(); err != nil {
+			return err
+		}
+	}
+
+	s.Write(s.mapCloser)
+	return nil
+}
+
+// mapEntries returns the keys of the map in the order of the current field, see KeyOrder, and the map
+// holding the values. The keys of an OrderedMap are in its order and the values are held by its inner map.
+func (s *encodeState[T]) mapEntries(v reflect.Value) ([]reflect.Value, reflect.Value) {
+	if v.Kind() == reflect.Struct {
+		return pointerTo(v).Interface().(orderedMap).entries()
+	}
+
+	keys := v.MapKeys()
+	if s.field.keyOrder != KeysUnordered {
+		sortKeys(keys)
+	}
+	return keys, v
+}
+
+// encodeKey returns the encoded key of a map in the charset of the current field.
+func (s *encodeState[T]) encodeKey(key reflect.Value) ([]byte, error) {
+	switch key.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return s.convert(strconv.AppendInt(s.scratch[:0], key.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return s.convert(strconv.AppendUint(s.scratch[:0], key.Uint(), 10))
+	default:
+		return s.convert(append(s.scratch[:0], key.String()...))
+	}
+}
+
+// convert returns the UTF-8 value with its control characters handled, see Config.ControlCharsWhenEncoding,
+// restricted to the output character set of the current field, see OutputCharsetValidator,
+// and converted to the charset of the current field, see Charset.
+func (s *encodeState[T]) convert(p []byte) ([]byte, error) {
+	var err error
+	if s.controlChars != ControlCharsKeep && !s.field.binary() {
+		if p, err = s.controls(p); err != nil {
+			return nil, err
+		}
+	}
+	if v := s.field.outputCharsetOf(s.outputCharset); v != nil {
+		if s.validated, err = v.Validate(s.validated[:0], p); err != nil {
+			return nil, err
+		}
+		p = s.validated
+	}
+	cs := s.field.charsetOf(s.charset)
+	if cs == nil {
+		return p, nil
+	}
+	s.converted, err = cs.Encode(s.converted[:0], p)
+	return s.converted, err
+}
+
+// controls returns the value with its control characters stripped or escaped, or ErrControlChar,
+// see Config.ControlCharsWhenEncoding.
+func (s *encodeState[T]) controls(p []byte) ([]byte, error) {
+	escape := s.controlChars == ControlCharsEscape
+	i := indexControl(p, escape)
+	if i < 0 {
+		return p, nil
+	}
+	if s.controlChars == ControlCharsError {
+		return nil, fmt.Errorf("%w: \\x%02X at %d", ErrControlChar, p[i], i)
+	}
+
+	const hex = "0123456789ABCDEF"
+	s.controlled = append(s.controlled[:0], p[:i]...)
+	for _, c := range p[i:] {
+		switch {
+		case escape && c == '\\':
+			s.controlled = append(s.controlled, '\\', '\\')
+		case !isControl(c):
+			s.controlled = append(s.controlled, c)
+		case escape:
+			s.controlled = append(s.controlled, '\\', 'x', hex[c>>4], hex[c&0xF])
+		}
+	}
+	return s.controlled, nil
+}
+
+// indexControl returns the index of the first control character of the value, or of the first backslash
+// if it is escaped as well, or -1 if there is none.
+func indexControl(p []byte, backslash bool) int {
+	for i, c := range p {
+		if isControl(c) || backslash && c == '\\' {
+			return i
+		}
+	}
+	return -1
+}
+
+func isControl(c byte) bool {
+	return c < 0x20 || c == 0x7F
+}
+
+// encodeList encodes the elements of a list with the function f, which appends them to s.list,
+// and wraps the list with the opener and the closer. The list is passed to Tag.Encode as the value of the field,
+// or appended to the enclosing list.
+func (s *encodeState[T]) encodeList(opener, closer []byte, f func() error) error {
+	outer, nested := s.list, s.listing
+	s.list, s.listing = append([]byte(nil), opener...), true
+
+	if err := f(); err != nil {
+		return err
+	}
+
+	list := append(s.list, closer...)
+	s.list, s.listing = outer, nested
+
+	if nested {
+		s.list = append(s.list, list...)
+		return nil
+	}
+	return s.Encode(s.field.key, s.field.meta, list, s.Buffer)
+}
+
+// compositeSliceEncoder writes the elements of a slice of composite values one after another
+// wrapped with the ElementOpener and the ElementCloser, and the slice wrapped with the SliceOpener
+// and the SliceCloser. The elements may be preceded by their count, see Config.CountElements.
+func compositeSliceEncoder[T any](s *encodeState[T], v reflect.Value) error {
+	s.Write(s.sliceOpener)
+
+	if s.countElements {
+		s.Write(strconv.AppendInt(s.scratch[:0], int64(v.Len()), 10))
+		s.Write(s.elementSeparator)
+	}
+
+	for i := 0; i < v.Len(); i++ {
+		if i != 0 {
+			s.Write(s.elementSeparator)
+		}
+		s.Write(s.elementOpener)
+		if err := s.reflectValue(v.Index(i)); err != nil {
+			return err
+		}
+		s.Write(s.elementCloser)
+		if err := s.yield(); err != nil {
+			return err
+		}
+	}
+
+	s.Write(s.sliceCloser)
+	return nil
 }
 
 func stringEncoder[T any](s *encodeState[T], v reflect.Value) error {
-	return s.Encode(s.field.name, s.field.meta, append(s.scratch[:0], v.String()...), s.Buffer)
+	return s.encodeValue(append(s.scratch[:0], v.String()...))
 }
 
 func structEncoder[T any](s *encodeState[T], v reflect.Value) error {
+	v, err := beforeEncode(v)
+	if err != nil {
+		return err
+	}
 	f := s.cachedFields(v.Type())
-	return f.encode(s, reflect.ValueOf(v.Interface()), s.wrap)
+
+	return s.structHooks(v.Type(), func() error {
+		return s.nest(s.fail, func() error {
+			return f.encode(s, reflect.ValueOf(v.Interface()), s.wrap)
+		})
+	})
 }
 
 func unsupportedTypeEncoder[T any](s *encodeState[T], _ reflect.Value) error {
@@ -209,3 +731,9 @@ func invalidTagEncoder[T any](tag string, err error) encoderFunc[T] {
 		return nil
 	}
 }
+
+func invalidFieldEncoder[T any](err error) encoderFunc[T] {
+	return func(s *encodeState[T], _ reflect.Value) error {
+		return err
+	}
+}
@@ -0,0 +1,387 @@
+package engine
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMarshalAppend(t *testing.T) {
+	type record struct{ A, B string }
+	e := newTestEngine(nil)
+
+	dst := make([]byte, 0, 64)
+	dst = append(dst, "head;"...)
+	b, err := MarshalAppend(e, dst, record{"a", "b"})
+	equal(t, nil, err)
+	equal(t, "head;a,b", string(b))
+	// The buffer of the caller is reused.
+	equal(t, &dst[:1][0], &b[0])
+
+	// An engine without the MarshalAppend method appends the data it marshals.
+	b, err = MarshalAppend(foreignEngine{e}, dst, record{"c", "d"})
+	equal(t, nil, err)
+	equal(t, "head;c,d", string(b))
+	equal(t, &dst[:1][0], &b[0])
+
+	b, err = MarshalAppend(e, nil, record{"c", "d"})
+	equal(t, nil, err)
+	equal(t, "c,d", string(b))
+
+	// The results of Marshal don't share the pooled buffer.
+	first, err := e.Marshal(record{"a", "b"})
+	equal(t, nil, err)
+	_, err = e.Marshal(record{"x", "y"})
+	equal(t, nil, err)
+	equal(t, "a,b", string(first))
+
+	b, err = MarshalAppend(e, dst, make(chan int))
+	equal(t, true, err != nil)
+	equal(t, "head;", string(b))
+}
+
+// version is encoded as two bytes by its binary marshaller.
+type version struct {
+	major, minor byte
+}
+
+func (v version) MarshalBinary() ([]byte, error) {
+	return []byte{v.major, v.minor}, nil
+}
+
+func (v *version) UnmarshalBinary(data []byte) error {
+	if len(data) != 2 {
+		return errors.New("invalid version")
+	}
+	v.major, v.minor = data[0], data[1]
+	return nil
+}
+
+func TestPreferBinaryMarshaler(t *testing.T) {
+	type record struct {
+		V  version
+		Vs []version
+		P  *version
+		S  string
+	}
+	e := newTestEngine(func(cfg *Config) {
+		cfg.ElementSeparator, cfg.EscapeChar = []byte("~"), '\\'
+		cfg.PreferBinaryMarshaler = true
+	})
+
+	value := record{V: version{'A', 'B'}, Vs: []version{{'1', '2'}, {'~', ','}}, P: &version{'x', 'y'}, S: "s"}
+	b, err := e.Marshal(value)
+	equal(t, nil, err)
+	equal(t, `AB,12~\~\,,xy,s`, string(b))
+
+	var got record
+	equal(t, nil, e.Unmarshal(b, &got))
+	equal(t, value, got)
+
+	_, err = Compile(e, reflect.TypeOf(record{}))
+	equal(t, nil, err)
+
+	equal(t, true, e.Unmarshal([]byte("ABC,,,s"), &got) != nil)
+}
+
+func TestDurationAsString(t *testing.T) {
+	type record struct {
+		D  time.Duration
+		Ds []time.Duration
+	}
+	value := record{D: 90 * time.Second, Ds: []time.Duration{time.Millisecond, 0}}
+
+	var tests = []struct {
+		asString bool
+		expect   string
+	}{
+		{
+			expect: "90000000000,1000000~0",
+		},
+		{
+			asString: true,
+			expect:   "1m30s,1ms~0s",
+		},
+	}
+	for _, tt := range tests {
+		e := newTestEngine(func(cfg *Config) {
+			cfg.ElementSeparator = []byte("~")
+			cfg.DurationAsString = tt.asString
+		})
+		b, err := e.Marshal(value)
+		equal(t, nil, err)
+		equal(t, tt.expect, string(b))
+
+		var got record
+		equal(t, nil, e.Unmarshal(b, &got))
+		equal(t, value, got)
+	}
+
+	e := newTestEngine(func(cfg *Config) { cfg.DurationAsString = true })
+	var got record
+	equal(t, true, e.Unmarshal([]byte("90,"), &got) != nil)
+}
+
+func TestNilInterfaceWhenEncoding(t *testing.T) {
+	type record struct {
+		A any
+		B any
+		L []any
+	}
+	value := record{B: "b", L: []any{1, nil}}
+
+	var tests = []struct {
+		policy NilInterfacePolicy
+		expect string
+		err    error
+	}{
+		{
+			policy: NilInterfaceError,
+			err:    ErrNilInterface,
+		},
+		{
+			policy: NilInterfaceSkip,
+			expect: "b,1~",
+		},
+		{
+			policy: NilInterfaceLiteral,
+			expect: "NULL,b,1~NULL",
+		},
+	}
+	for _, tt := range tests {
+		e := newTestEngine(func(cfg *Config) {
+			cfg.ElementSeparator = []byte("~")
+			cfg.NilInterfaceWhenEncoding, cfg.NilLiteral = tt.policy, []byte("NULL")
+		})
+		b, err := e.Marshal(value)
+		equal(t, true, errors.Is(err, tt.err))
+		equal(t, tt.expect, string(b))
+	}
+
+	// The literal leaves the interface value nil when decoding.
+	e := newTestEngine(func(cfg *Config) {
+		cfg.NilInterfaceWhenEncoding, cfg.NilLiteral = NilInterfaceLiteral, []byte("NULL")
+	})
+	a, b := "a", "b"
+	got := record{A: &a, B: &b}
+	equal(t, nil, e.Unmarshal([]byte("NULL,x"), &got))
+	equal(t, nil, got.A)
+	equal(t, "x", b)
+}
+
+// code is zero when it's negative, as well as when it's 0.
+type code int
+
+func (c code) IsZero() bool {
+	return c < 0
+}
+
+// level is code with IsZero of a pointer receiver.
+type level int
+
+func (l *level) IsZero() bool {
+	return *l < 0
+}
+
+func TestOmitEmptyIsZero(t *testing.T) {
+	type record struct {
+		C code   `test:"omitempty"`
+		L level  `test:"omitempty"`
+		P *code  `test:"omitempty"`
+		N int    `test:"omitempty"`
+		S string `test:"omitempty"`
+	}
+	e := newKeyEngine[testMeta](nil)
+
+	// A non-nil pointer is empty only if IsZero reports so.
+	zero, unset := code(0), code(-1)
+	var tests = []struct {
+		value  record
+		expect string
+	}{
+		{
+			value:  record{C: -1, L: -1, P: &unset, S: "s"},
+			expect: "S=s",
+		},
+		{
+			value:  record{C: 1, L: 2, P: &zero},
+			expect: "C=1,L=2,P=0",
+		},
+	}
+	for _, tt := range tests {
+		b, err := e.Marshal(tt.value)
+		equal(t, nil, err)
+		equal(t, tt.expect, string(b))
+	}
+}
+
+func TestIsEmpty(t *testing.T) {
+	type record struct {
+		A string `test:"omitempty"`
+		B party  `test:"omitempty"`
+		C int    `test:"omitempty"`
+		D string
+	}
+	e := newKeyEngine[testMeta](func(cfg *Config) {
+		cfg.IsEmpty = func(v reflect.Value) bool {
+			if v.Kind() == reflect.String {
+				return strings.TrimSpace(v.String()) == ""
+			}
+			return v.IsZero()
+		}
+	})
+
+	var tests = []struct {
+		value  record
+		expect string
+	}{
+		{
+			value:  record{A: "  ", D: " "},
+			expect: "D= ",
+		},
+		{
+			value:  record{A: "a", B: party{Agency: 1}, C: 2},
+			expect: "A=a,ID=:Agency=1:Code=,C=2,D=",
+		},
+	}
+	for _, tt := range tests {
+		b, err := e.Marshal(tt.value)
+		equal(t, nil, err)
+		equal(t, tt.expect, string(b))
+	}
+}
+
+func TestMarshalIndent(t *testing.T) {
+	e := newTestEngine(func(cfg *Config) {
+		cfg.StructOpener, cfg.StructCloser, cfg.UnwrapWhenDecoding = []byte("{"), []byte("}"), true
+	})
+	value := nameAndAddress{Qualifier: "BY", Party: party{ID: "1", Agency: 9}, Agent: &party{}, Name: "ACME"}
+
+	b, err := MarshalIndent(e, value, "> ", "  ")
+	equal(t, nil, err)
+	equal(t, "{\n>   BY,\n>   {\n>     1:\n>     9:\n>     \n>   },\n>   {\n>     :\n>     0:\n>     \n>   },\n>   ACME\n> }", string(b))
+
+	// Marshal doesn't indent the output after MarshalIndent, whose state goes back to the pool.
+	b, err = e.Marshal(value)
+	equal(t, nil, err)
+	equal(t, "{BY,{1:9:},{:0:},ACME}", string(b))
+
+	b, err = MarshalIndent(e, value, "", "")
+	equal(t, nil, err)
+	equal(t, "{\nBY,\n{\n1:\n9:\n\n},\n{\n:\n0:\n\n},\nACME\n}", string(b))
+
+	_, err = MarshalIndent(foreignEngine{e}, value, "", "")
+	equal(t, true, errors.Is(err, ErrUnsupported))
+}
+
+func TestMarshalCycle(t *testing.T) {
+	type node struct {
+		Name string
+		Next *node `test:"omitempty"`
+	}
+	e := newTestEngine(nil)
+
+	value := &node{Name: "a", Next: &node{Name: "b"}}
+	value.Next.Next = value
+	_, err := e.Marshal(value)
+	equal(t, true, errors.Is(err, ErrCycle))
+	// The path holds a single turn of the cycle.
+	var encodeErr *EncodeError
+	equal(t, true, errors.As(err, &encodeErr))
+	equal(t, "node.Next.Next.Next", encodeErr.Path)
+
+	// A value shared by the fields isn't a cycle.
+	shared := &node{Name: "c"}
+	b, err := e.Marshal(struct{ A, B *node }{shared, shared})
+	equal(t, nil, err)
+	equal(t, "c,c", string(b))
+
+	// The zero value of a recursive type is endless, a nil pointer of the type fails rather than being expanded.
+	type list struct {
+		Name string
+		Next *list
+	}
+	last := &list{Name: "c"}
+	for _, value := range []any{&list{}, struct{ A, B *list }{last, last}} {
+		_, err = e.Marshal(value)
+		equal(t, true, errors.Is(err, ErrCycle))
+	}
+
+	// The nil pointers of other types are encoded as the zero values.
+	type pair struct{ A, B *item }
+	b, err = e.Marshal(pair{B: &item{N: 1}})
+	equal(t, nil, err)
+	equal(t, "0:0,1:0", string(b))
+
+	// The nil pointers encoded as empty values end the recursive values.
+	e = newTestEngine(func(cfg *Config) { cfg.NilPointerWhenEncoding = NilPointerEmpty })
+	b, err = e.Marshal(&list{})
+	equal(t, nil, err)
+	equal(t, ",", string(b))
+
+	b, err = e.Marshal(struct{ A, B *list }{last, last})
+	equal(t, nil, err)
+	equal(t, "c:,c:", string(b))
+
+	b, err = e.Marshal(pair{B: &item{N: 1}})
+	equal(t, nil, err)
+	equal(t, ",1:0", string(b))
+}
+
+func TestControlCharsWhenEncoding(t *testing.T) {
+	type record struct {
+		A string
+		N int `test:"2"`
+	}
+	value := record{A: "a\x00b\n", N: 0x0A00}
+
+	var tests = []struct {
+		policy ControlCharPolicy
+		expect string
+		err    error
+	}{
+		{policy: ControlCharsKeep, expect: "a\x00b\n,\x0A\x00"},
+		{policy: ControlCharsStrip, expect: "ab,\x0A\x00"},
+		{policy: ControlCharsEscape, expect: "a\\x00b\\x0A,\x0A\x00"},
+		{policy: ControlCharsError, err: ErrControlChar},
+	}
+	for _, tt := range tests {
+		e := newEngineOf[widthMeta](func(cfg *Config) { cfg.ControlCharsWhenEncoding = tt.policy })
+		b, err := e.Marshal(value)
+		equal(t, true, errors.Is(err, tt.err))
+		equal(t, tt.expect, string(b))
+	}
+
+	// The error tells the character found and its offset in the value.
+	e := newEngineOf[widthMeta](func(cfg *Config) { cfg.ControlCharsWhenEncoding = ControlCharsError })
+	_, err := e.Marshal(value)
+	equal(t, true, strings.HasSuffix(err.Error(), "the value contains a control character: \\x00 at 1"))
+}
+
+func TestControlCharsEscapeRoundTrip(t *testing.T) {
+	type record struct {
+		A    string
+		Tags []string
+	}
+	e := newTestEngine(func(cfg *Config) {
+		listConfig(cfg)
+		cfg.EscapeChar = 0
+		cfg.ControlCharsWhenEncoding = ControlCharsEscape
+	})
+
+	// A literal \x0A and a backslash don't decode to the characters escaped like them.
+	value := record{A: "\\x0A\n\\", Tags: []string{"\\x00", "\x00"}}
+	b, err := e.Marshal(value)
+	equal(t, nil, err)
+	equal(t, `\\x0A\x0A\\,(\\x00~\x00)`, string(b))
+
+	var got record
+	equal(t, nil, e.Unmarshal(b, &got))
+	equal(t, value, got)
+
+	// A backslash that isn't an escape is decoded as it is.
+	equal(t, nil, e.Unmarshal([]byte(`a\b\x1,()`), &got))
+	equal(t, `a\b\x1`, got.A)
+}
@@ -1,10 +1,17 @@
 package engine
 
 import (
+	"bytes"
+	"fmt"
+	"log/slog"
 	"reflect"
+	"sync"
+	"sync/atomic"
 )
 
 // Engine represents the main functions that the package implements.
+// The engines returned by New have more methods, they are reached through the functions
+// of the package taking an Engine, e.g. NameOf, so that an Engine implemented elsewhere needn't have them.
 type Engine interface {
 	// Marshal encodes the value v and returns the encoded data.
 	Marshal(v any) ([]byte, error)
@@ -63,30 +70,658 @@ type Config struct {
 	ValueSeparator []byte
 	// RemoveSeparatorWhenDecoding this flag tells the library whether to remove the ValueSeparator.
 	RemoveSeparatorWhenDecoding bool
+	// ComponentSeparator a byte array separating the components of a composite value, a nested struct field.
+	// Will be automatically added when encoding.
+	// If it is set, the library splits the data itself when decoding: Tag.Decode receives a single value
+	// cut at the ValueSeparator, or at the ComponentSeparator inside a composite value,
+	// and is not called for composite values.
+	ComponentSeparator []byte
+	// Separators byte arrays separating values of structs by nesting depth: the first one separates
+	// the fields of a top-level struct, the second one the components of its composite fields and so on,
+	// structs nested deeper use the last one. Will be automatically added when encoding.
+	// If it is set, it replaces the ValueSeparator and the ComponentSeparator,
+	// and the library splits the data itself when decoding, like with the ComponentSeparator.
+	Separators [][]byte
+	// SliceOpener a byte array that denotes the beginning of a slice.
+	// Will be automatically added when encoding.
+	SliceOpener []byte
+	// SliceCloser a byte array that denotes the end of a slice.
+	// Will be automatically added when encoding.
+	SliceCloser []byte
+	// ElementSeparator a byte array separating the elements of a slice.
+	// Will be automatically added when encoding.
+	// The elements of a slice of values are joined into a list that is passed to Tag.Encode as a single value,
+	// the elements of a slice of structs are written one after another like composite values.
+	// Arrays are handled like slices, except arrays of bytes, which are handled like []byte.
+	ElementSeparator []byte
+	// ElementOpener a byte array that denotes the beginning of an element of a slice of structs.
+	// Will be automatically added when encoding.
+	ElementOpener []byte
+	// ElementCloser a byte array that denotes the end of an element of a slice of structs.
+	// Will be automatically added when encoding.
+	ElementCloser []byte
+	// CountElements this flag tells the library to write the number of the elements of a slice of structs
+	// followed by the ElementSeparator before them, and to decode as many elements as it says.
+	CountElements bool
+	// MapOpener a byte array that denotes the beginning of a map, e.g. "{".
+	// Will be automatically added when encoding. Maps nested in lists or maps must be wrapped.
+	MapOpener []byte
+	// MapCloser a byte array that denotes the end of a map, e.g. "}".
+	// Will be automatically added when encoding.
+	MapCloser []byte
+	// KeyValueSeparator a byte array separating the key and the value of a map entry, e.g. "=".
+	// Will be automatically added when encoding.
+	// The entries of a map, sorted by keys by default, are joined into a list that is passed to Tag.Encode
+	// as a single value. The entries of a map of structs are written like the elements of a slice of structs.
+	// Keys must be of string or integer kinds, see KeyOrderer for the order of the entries.
+	KeyValueSeparator []byte
+	// EntrySeparator a byte array separating the entries of a map, e.g. "&".
+	// Will be automatically added when encoding.
+	EntrySeparator []byte
+	// EscapeChar a byte that is automatically inserted when encoding before every separator,
+	// StructOpener, StructCloser, SliceOpener, SliceCloser, KeyValueSeparator, EntrySeparator, RecordSeparator
+	// and EscapeChar inside a value, e.g. '?' in EDIFACT or '\\' in HL7.
+	// When the library splits the data itself, see ComponentSeparator, escaped bytes don't end a value
+	// and the EscapeChar is removed from the value before it is passed to Tag.Decode.
+	// Otherwise, the Tag is responsible for it when decoding. Zero means there is no escaping.
+	EscapeChar byte
+	// TrimTrailingEmpty this flag tells the library to drop empty values at the end of a struct
+	// together with their separators when encoding, so that no trailing separators are left.
+	TrimTrailingEmpty bool
+	// EmptySliceWhenDecoding this flag tells the library to decode an absent list, an empty value of a slice field,
+	// to an empty non-nil slice. Otherwise, the slice is left nil. An empty list wrapped with the SliceOpener
+	// and the SliceCloser is present and always decodes to an empty non-nil slice.
+	EmptySliceWhenDecoding bool
+	// KeepEmptySlices this flag tells the library to omit only nil slices of the fields with omitempty,
+	// empty non-nil slices are encoded as empty lists. Otherwise, both are omitted.
+	KeepEmptySlices bool
+	// IsEmpty reports whether the value of a field with omitempty is empty, so that the field is omitted,
+	// e.g. a string of whitespace or a struct of zero values. It replaces the built-in check,
+	// which treats zero values, empty containers and the values whose IsZero method reports true as empty.
+	IsEmpty func(v reflect.Value) bool
+	// DisallowTrailingData this flag tells the library to return ErrTrailingData from Unmarshal when data is left
+	// after the struct is decoded, e.g. extra values, to catch framing bugs. Otherwise, it is ignored.
+	// It takes effect when the library splits the data itself, see ComponentSeparator.
+	DisallowTrailingData bool
+	// DisallowUnknownFields this flag tells the library to return ErrUnknownField when a struct has more values
+	// than fields, or a header record has columns matching no field, see Decoder.ReadHeader.
+	// A struct with an Extras field captures the values instead.
+	// It takes effect when the library splits the data itself, see ComponentSeparator.
+	DisallowUnknownFields bool
+	// DisallowEmptyStructs this flag tells the library to return ErrNoFields when decoding into a struct
+	// that has no fields to decode: neither exported nor embedded ones, or all of them skipped by their tags.
+	// It usually means the wrong tag key or the wrong type of the value.
+	DisallowEmptyStructs bool
+	// ZeroBeforeDecoding this flag tells the library to set the value pointed to by the argument of Unmarshal
+	// to its zero value before decoding, so that the fields absent from the data don't keep the values they had
+	// when the values are reused. Otherwise, they are left as they are, see UnmarshalZero for a single call.
+	ZeroBeforeDecoding bool
+	// AllErrorsWhenDecoding this flag tells the library to go on decoding the fields of a struct after a field fails,
+	// and to return the errors of all the fields joined with errors.Join, so that all the problems of a document
+	// can be reported at once. The fields that fail are left as they are. Errors in the structure of the data,
+	// e.g. a missing StructCloser, still stop decoding.
+	AllErrorsWhenDecoding bool
+	// CaseInsensitiveKeys this flag tells the library to match the keys of keyed data to the keys of the fields
+	// regardless of case if none of them matches exactly, see KeyedTag.
+	CaseInsensitiveKeys bool
+	// DurationAsString this flag tells the library to encode time.Duration values with Duration.String, e.g. "1m30s",
+	// and to decode them with time.ParseDuration. Otherwise, they are integer nanoseconds.
+	DurationAsString bool
+	// MaxDepth is the maximum nesting depth of structs when encoding and decoding, the top-level struct is
+	// at depth 1, so that deeply nested or adversarial values fail with ErrMaxDepth instead of exhausting the stack.
+	// The structs of the elements of slices and maps are nested in the struct holding them.
+	// If it is 0, the depth isn't limited.
+	MaxDepth int
+	// MaxValueSize is the maximum size in bytes of a value written by Tag.Decode, and of the value after its Transform,
+	// so that the engines decoding untrusted data bound the memory they use. A larger value fails with ErrLimit,
+	// a LimitedTransform fails as soon as it exceeds the limit. If it is 0, the size isn't limited.
+	MaxValueSize int
+	// MaxElements is the maximum number of the elements of a slice or the entries of a map when decoding,
+	// including the count of the elements, see CountElements. More elements fail with ErrLimit.
+	// If it is 0, the number isn't limited.
+	MaxElements int
+	// MaxDecodedSize is the maximum total size in bytes of the values written by Tag.Decode when decoding a value,
+	// see MaxValueSize. If it is 0, the size isn't limited.
+	MaxDecodedSize int
+	// MaxRecordSize is the maximum size in bytes of a record a Decoder reads from the stream, up to
+	// the RecordSeparator or from a frame, see Framing, so that a longer record is rejected before it is buffered,
+	// while the other limits are checked when it is decoded. It fails with ErrLimit and is skipped,
+	// so that the next record can be read. If it is 0, the size isn't limited.
+	MaxRecordSize int
+	// YearPivot is the two-digit year from which the years of the dates carrying two digits of the year,
+	// the layouts with "06" of the TimeFormatter fields, e.g. JulianDate, are in the 20th century when decoding,
+	// the years before it are in the 21st one. If it is 0, the pivot of the time package, 69, is used.
+	YearPivot int
+	// Charset is the charset of the values of the data, e.g. CP037 for EBCDIC mainframe files, the values
+	// are converted from and to UTF-8 one by one, see Charset and Charsetter. If it is nil, they aren't converted.
+	Charset Charset
+	// ControlCharsWhenEncoding tells the library what to do with the control characters, 0x00-0x1F and 0x7F,
+	// in the values of text when encoding, e.g. a NUL corrupting the framing of the records. By default they are
+	// written as they are, see ControlCharPolicy. The binary values, of the IntegerWidther fields, the packed
+	// and zoned numbers and the delegated fields, are written as they are. The escaped characters are restored
+	// when decoding, see ControlCharsEscape.
+	ControlCharsWhenEncoding ControlCharPolicy
+	// OutputCharsetValidator restricts the characters of the values to the character set of the format when encoding,
+	// e.g. SWIFTXOutput, the characters it doesn't allow are transliterated or fail encoding, see OutputCharsetValidator
+	// and OutputCharsetter. If it is nil, the characters aren't restricted.
+	OutputCharsetValidator OutputCharsetValidator
+	// NilInterfaceWhenEncoding tells the library what to do with the nil interface values when encoding,
+	// by default it returns ErrNilInterface, see NilInterfacePolicy.
+	NilInterfaceWhenEncoding NilInterfacePolicy
+	// NilLiteral a byte array written instead of a nil interface value, see NilInterfaceLiteral.
+	// When decoding, it leaves the interface value nil.
+	NilLiteral []byte
+	// NilPointerWhenEncoding tells the library what to do with the nil pointers when encoding,
+	// by default it encodes the zero value of the type they point to, see NilPointerPolicy.
+	NilPointerWhenEncoding NilPointerPolicy
+	// PointersWhenDecoding tells the library whether to decode into the values the non-nil pointers point to
+	// or to replace the pointers with new ones, see PointerPolicy.
+	PointersWhenDecoding PointerPolicy
+	// AfterDecode is called with a pointer to every decoded struct after the struct implementing AfterDecoder
+	// is called, so that defaults and invariants are applied to the types that can't implement it.
+	// The error it returns fails decoding.
+	AfterDecode func(v any) error
+	// Logger receives a record of every failure to decode a value, with the attributes of the engine,
+	// the struct, the path of the field that fails and the error, so that the failures are logged
+	// consistently without wrapping every call. If it is nil, nothing is logged.
+	Logger *slog.Logger
+	// LogLevel is the level of the records of the Logger, slog.LevelError if it is nil.
+	LogLevel slog.Leveler
+	// Normalize is applied to the value of every field written by Tag.Decode before it is parsed,
+	// e.g. to change the case, strip padding or collapse whitespace, see Normalizers.
+	// A field whose parsed tag implements the Normalizer interface uses it instead.
+	Normalize func(value []byte) []byte
+	// Canonicalize is applied to the encoded value of every field before it is passed to Tag.Encode,
+	// e.g. to change the case of hex digits or normalize unicode. The normalizers can be used here as well.
+	Canonicalize func(value []byte) []byte
+	// Header makes MarshalAll write the header record with the names of the columns before the records,
+	// see HeaderOptions. Nil means no header.
+	Header *HeaderOptions
+	// RecordSeparator a byte array separating the values of a stream, see Encoder and Decoder.
+	// Will be automatically added by Encoder after every value.
+	// Without it, Decoder reads a value up to the StructCloser balancing the StructOpener it begins with,
+	// if the values are wrapped, or up to the end of the stream.
+	RecordSeparator []byte
+	// Profiles are named bundles of settings selectable with Profile, e.g. "compact" and "pretty".
+	// A profile is a complete configuration, but its Marshaller, Unmarshaler, PreferBinaryMarshaler, FieldLess,
+	// FieldNameMapper, RequireTag and Profiles are always taken from the configuration of the engine.
+	Profiles map[string]Config
 	// Marshaller is used to check if a type implements a type of the Marshaller interface.
 	Marshaller reflect.Type
 	// Unmarshaler is used to check if a type implements a type of the Unmarshaler interface.
 	Unmarshaler reflect.Type
+	// PreferBinaryMarshaler this flag tells the library to encode the values of the types implementing
+	// encoding.BinaryMarshaler with it, and to decode the values of the types implementing
+	// encoding.BinaryUnmarshaler with it, in preference to the Marshaller, the Unmarshaler and the built-in coders.
+	// The encoded data is passed to Tag.Encode like any other value, e.g. for binary wire formats.
+	PreferBinaryMarshaler bool
+	// FieldLess reports whether the field a must be processed before the field b.
+	// If it is nil, fields are processed in the order they are declared in a struct.
+	// Fields of an embedded struct are ordered among themselves and keep the place of the embedded field.
+	FieldLess func(a, b FieldInfo) bool
+	// FieldNameMapper maps the name of a Go field to the name passed to Tag.Encode and Tag.Decode,
+	// e.g. SnakeCase, KebabCase or LowerCamelCase, so that the fields needn't be tagged to follow
+	// the naming convention of the format. The fields renamed by their tags keep their names, see Renamer.
+	FieldNameMapper func(fieldName string) string
+	// RequireTag this flag tells the library to skip the fields without the tag of the engine
+	// instead of processing them under their names. The fields of embedded structs are processed
+	// according to their own tags.
+	RequireTag bool
+}
+
+// FieldInfo describes a struct field for the Config.FieldLess comparator.
+type FieldInfo struct {
+	// Index is the position of the field in the struct declaration.
+	Index int
+	// Name is the name of the field.
+	Name string
+	// Type is the type of the field.
+	Type reflect.Type
+	// Tag is the value of the engine tag, empty if the field has no tag.
+	Tag string
+	// Meta is the parsed tag, a *T of the engine Tag, nil if the field has no tag.
+	Meta any
+}
+
+// NilInterfacePolicy tells the library what to do with the nil interface values when encoding,
+// see Config.NilInterfaceWhenEncoding.
+type NilInterfacePolicy int
+
+const (
+	// NilInterfaceError makes encoding of a nil interface value fail with ErrNilInterface.
+	NilInterfaceError NilInterfacePolicy = iota
+	// NilInterfaceSkip omits a nil interface field like an empty field with the omitempty option,
+	// and writes a nil interface element of a slice or a map as an empty value.
+	NilInterfaceSkip
+	// NilInterfaceLiteral writes a nil interface value as the Config.NilLiteral.
+	NilInterfaceLiteral
+)
+
+// NilPointerPolicy tells the library what to do with the nil pointers when encoding,
+// see Config.NilPointerWhenEncoding.
+type NilPointerPolicy int
+
+const (
+	// NilPointerZero encodes a nil pointer as the zero value of the type it points to. The zero value
+	// of a recursive type is endless, a nil pointer within the zero value of its own type fails with ErrCycle.
+	NilPointerZero NilPointerPolicy = iota
+	// NilPointerEmpty encodes a nil pointer as an empty value, so that the nil pointers of a recursive type,
+	// e.g. the last node of a list, end the value.
+	NilPointerEmpty
+)
+
+// ControlCharPolicy tells the library what to do with the control characters in the values when encoding,
+// see Config.ControlCharsWhenEncoding.
+type ControlCharPolicy int
+
+const (
+	// ControlCharsKeep writes the control characters as they are.
+	ControlCharsKeep ControlCharPolicy = iota
+	// ControlCharsStrip removes the control characters from the values.
+	ControlCharsStrip
+	// ControlCharsEscape replaces every control character with \xNN, its code in two hex digits, e.g. \x00 for NUL,
+	// and every backslash with \\, so that the values are restored when decoding. A backslash followed
+	// by anything else is decoded as it is.
+	ControlCharsEscape
+	// ControlCharsError makes encoding of a value with a control character fail with ErrControlChar.
+	ControlCharsError
+)
+
+// PointerPolicy tells the library what to do with the non-nil pointers when decoding,
+// see Config.PointersWhenDecoding. Nil pointers are always set to new values, unless the decoded values are empty.
+type PointerPolicy int
+
+const (
+	// PointerReuse decodes into the values the pointers point to, so that decoding repeatedly into the same value
+	// allocates nothing for them. The values pointed to may be shared, e.g. with the previous results.
+	PointerReuse PointerPolicy = iota
+	// PointerReplace decodes into new values and replaces the pointers, so that the values they pointed to
+	// are never changed, or sets them to nil if the decoded values are empty.
+	PointerReplace
+)
+
+// FieldsByName is a Config.FieldLess comparator that orders fields by their names.
+func FieldsByName(a, b FieldInfo) bool {
+	return a.Name < b.Name
 }
 
 type engine[T any] struct {
 	Tag[T]
-	wrap, separate, removeSeparator            bool
-	structOpener, structCloser, valueSeparator []byte
-	marshaller, unmarshaler                    reflect.Type
+	marshaller, unmarshaler reflect.Type
+	binary                  bool // see Config.PreferBinaryMarshaler
+	fieldLess               func(a, b FieldInfo) bool
+	nameMapper              func(fieldName string) string // see Config.FieldNameMapper
+	requireTag              bool                          // see Config.RequireTag
+	profiles                map[string]*engine[T]
+	dialect                 *Dialect
+	fields                  *sync.Map    // map[reflect.Type or dialectKey]structFields[T] and map[describedKey]described[T], shared with profiles and dialects
+	encoders, decoders      *sync.Map    // map[reflect.Type]encoderFunc[T] and decoderFunc[T], shared as well
+	customEncoders          *sync.Map    // map[reflect.Type or reflect.Kind]EncoderFunc, shared as well
+	customDecoders          *sync.Map    // map[reflect.Type or reflect.Kind]DecoderFunc, shared as well
+	interceptors            *fieldHooks  // shared as well, see RegisterFieldInterceptor
+	current                 atomic.Value // *settings, replaced as a whole by Reconfigure
+	mu                      sync.Mutex   // serializes Reconfigure
+}
+
+// settings are derived from a Config, they may differ between the profiles of an engine.
+type settings struct {
+	config                       Config
+	wrap, removeSeparator, split bool
+	trimTrailing                 bool
+	emptySlices, keepEmptySlices bool
+	emptiness                    func(v reflect.Value) bool // see Config.IsEmpty
+	durationString               bool
+	maxDepth                     int
+	maxValueSize, maxElements    int
+	maxDecodedSize               int
+	maxRecordSize                int
+	yearPivot                    int
+	charset                      Charset
+	controlChars                 ControlCharPolicy
+	outputCharset                OutputCharsetValidator
+	nilInterface                 NilInterfacePolicy
+	nilLiteral                   []byte
+	nilPointers                  NilPointerPolicy
+	pointers                     PointerPolicy
+	decodeHook                   func(v any) error
+	noTrailing, disallowUnknown  bool
+	noEmptyStructs, foldKeys     bool
+	allErrors, zeroFirst         bool
+	logger                       *slog.Logger
+	logLevel                     slog.Leveler
+	header                       *HeaderOptions
+	structOpener, structCloser   []byte
+	sliceOpener, sliceCloser     []byte
+	elementSeparator             []byte
+	elementOpener, elementCloser []byte
+	countElements                bool
+	pairs                        [][2][]byte // openers and closers of nested values, see nesting
+	mapOpener, mapCloser         []byte
+	keyValueSeparator            []byte
+	entrySeparator               []byte
+	recordSeparator              []byte
+	separators, specials         [][]byte
+	escape                       byte
+	normalize, canonicalize      func([]byte) []byte
 }
 
 // New returns a new entity that implements the Engine interface.
 func New[T any](tag Tag[T], cfg Config) Engine {
-	return &engine[T]{
-		Tag:             tag,
-		wrap:            (len(cfg.StructOpener) != 0 || len(cfg.StructCloser) != 0) && cfg.UnwrapWhenDecoding,
-		separate:        len(cfg.ValueSeparator) != 0,
-		removeSeparator: len(cfg.ValueSeparator) != 0 && cfg.RemoveSeparatorWhenDecoding,
-		structOpener:    cfg.StructOpener,
-		structCloser:    cfg.StructCloser,
-		valueSeparator:  cfg.ValueSeparator,
-		marshaller:      cfg.Marshaller,
-		unmarshaler:     cfg.Unmarshaler,
+	cfg = cfg.clone()
+	e := &engine[T]{
+		Tag:            tag,
+		marshaller:     cfg.Marshaller,
+		unmarshaler:    cfg.Unmarshaler,
+		binary:         cfg.PreferBinaryMarshaler,
+		fieldLess:      cfg.FieldLess,
+		nameMapper:     cfg.FieldNameMapper,
+		requireTag:     cfg.RequireTag,
+		profiles:       make(map[string]*engine[T], len(cfg.Profiles)),
+		fields:         new(sync.Map),
+		encoders:       new(sync.Map),
+		decoders:       new(sync.Map),
+		customEncoders: new(sync.Map),
+		customDecoders: new(sync.Map),
+		interceptors:   new(fieldHooks),
+	}
+	e.current.Store(newSettings(cfg))
+
+	// Profiles share everything with the engine but the settings.
+	for name, pc := range cfg.Profiles {
+		pc.Marshaller, pc.Unmarshaler, pc.FieldLess, pc.Profiles = cfg.Marshaller, cfg.Unmarshaler, cfg.FieldLess, nil
+		pc.PreferBinaryMarshaler, pc.FieldNameMapper, pc.RequireTag = cfg.PreferBinaryMarshaler, cfg.FieldNameMapper, cfg.RequireTag
+		p := &engine[T]{
+			Tag:            e.Tag,
+			marshaller:     e.marshaller,
+			unmarshaler:    e.unmarshaler,
+			binary:         e.binary,
+			fieldLess:      e.fieldLess,
+			nameMapper:     e.nameMapper,
+			requireTag:     e.requireTag,
+			profiles:       e.profiles,
+			fields:         e.fields,
+			encoders:       e.encoders,
+			decoders:       e.decoders,
+			customEncoders: e.customEncoders,
+			customDecoders: e.customDecoders,
+			interceptors:   e.interceptors,
+		}
+		p.current.Store(newSettings(pc))
+		e.profiles[name] = p
+	}
+
+	return e
+}
+
+// MarshalT encodes the value v with the engine, like Engine.Marshal, with the type of v checked at compile time.
+func MarshalT[V any](e Engine, v V) ([]byte, error) {
+	return e.Marshal(v)
+}
+
+// UnmarshalT decodes the encoded data with the engine, like Engine.Unmarshal, and returns the decoded value.
+func UnmarshalT[V any](e Engine, data []byte) (V, error) {
+	var v V
+	err := e.Unmarshal(data, &v)
+	return v, err
+}
+
+func newSettings(cfg Config) *settings {
+	s := &settings{
+		config:            cfg,
+		wrap:              (len(cfg.StructOpener) != 0 || len(cfg.StructCloser) != 0) && cfg.UnwrapWhenDecoding,
+		removeSeparator:   len(cfg.ValueSeparator) != 0 && cfg.RemoveSeparatorWhenDecoding,
+		split:             len(cfg.ComponentSeparator) != 0 || len(cfg.Separators) != 0,
+		structOpener:      cfg.StructOpener,
+		structCloser:      cfg.StructCloser,
+		sliceOpener:       cfg.SliceOpener,
+		sliceCloser:       cfg.SliceCloser,
+		elementSeparator:  cfg.ElementSeparator,
+		elementOpener:     cfg.ElementOpener,
+		elementCloser:     cfg.ElementCloser,
+		countElements:     cfg.CountElements,
+		mapOpener:         cfg.MapOpener,
+		mapCloser:         cfg.MapCloser,
+		keyValueSeparator: cfg.KeyValueSeparator,
+		entrySeparator:    cfg.EntrySeparator,
+		recordSeparator:   cfg.RecordSeparator,
+		separators:        [][]byte{cfg.ValueSeparator},
+		escape:            cfg.EscapeChar,
+		trimTrailing:      cfg.TrimTrailingEmpty,
+		emptySlices:       cfg.EmptySliceWhenDecoding,
+		keepEmptySlices:   cfg.KeepEmptySlices,
+		emptiness:         cfg.IsEmpty,
+		durationString:    cfg.DurationAsString,
+		maxDepth:          cfg.MaxDepth,
+		maxValueSize:      cfg.MaxValueSize,
+		maxElements:       cfg.MaxElements,
+		maxDecodedSize:    cfg.MaxDecodedSize,
+		maxRecordSize:     cfg.MaxRecordSize,
+		yearPivot:         cfg.YearPivot,
+		charset:           cfg.Charset,
+		controlChars:      cfg.ControlCharsWhenEncoding,
+		outputCharset:     cfg.OutputCharsetValidator,
+		nilInterface:      cfg.NilInterfaceWhenEncoding,
+		nilPointers:       cfg.NilPointerWhenEncoding,
+		nilLiteral:        cfg.NilLiteral,
+		pointers:          cfg.PointersWhenDecoding,
+		decodeHook:        cfg.AfterDecode,
+		noTrailing:        cfg.DisallowTrailingData,
+		disallowUnknown:   cfg.DisallowUnknownFields,
+		noEmptyStructs:    cfg.DisallowEmptyStructs,
+		foldKeys:          cfg.CaseInsensitiveKeys,
+		allErrors:         cfg.AllErrorsWhenDecoding,
+		zeroFirst:         cfg.ZeroBeforeDecoding,
+		logger:            cfg.Logger,
+		logLevel:          cfg.LogLevel,
+		header:            cfg.Header,
+		normalize:         cfg.Normalize,
+		canonicalize:      cfg.Canonicalize,
+	}
+	if len(cfg.Separators) != 0 {
+		s.separators = cfg.Separators
+	} else if s.split {
+		s.separators = append(s.separators, cfg.ComponentSeparator)
+	}
+	for _, b := range append([][]byte{cfg.StructOpener, cfg.StructCloser, cfg.SliceOpener, cfg.SliceCloser, cfg.ElementSeparator,
+		cfg.ElementOpener, cfg.ElementCloser, cfg.MapOpener, cfg.MapCloser, cfg.KeyValueSeparator, cfg.EntrySeparator, cfg.RecordSeparator}, s.separators...) {
+		if len(b) != 0 {
+			s.specials = append(s.specials, b)
+		}
+	}
+	if s.wrap {
+		s.pairs = append(s.pairs, [2][]byte{cfg.StructOpener, cfg.StructCloser})
+	}
+	s.pairs = append(s.pairs, [2][]byte{cfg.SliceOpener, cfg.SliceCloser}, [2][]byte{cfg.ElementOpener, cfg.ElementCloser},
+		[2][]byte{cfg.MapOpener, cfg.MapCloser})
+	return s
+}
+
+// nesting returns the length of the opener or the closer of a nested value at the beginning of p
+// and the change of the nesting depth, 1 or -1, or zeros if there is none.
+// At the depth other than 0 closers are looked for first, so an opener may be the same as its closer.
+func (s *settings) nesting(p []byte, depth int) (n, d int) {
+	if depth != 0 {
+		for _, pair := range s.pairs {
+			if len(pair[1]) != 0 && bytes.HasPrefix(p, pair[1]) {
+				return len(pair[1]), -1
+			}
+		}
+	}
+	for _, pair := range s.pairs {
+		if len(pair[0]) != 0 && bytes.HasPrefix(p, pair[0]) {
+			return len(pair[0]), 1
+		}
+	}
+	for _, pair := range s.pairs {
+		if len(pair[1]) != 0 && bytes.HasPrefix(p, pair[1]) {
+			return len(pair[1]), -1
+		}
+	}
+	return 0, 0
+}
+
+// Profile returns the engine e with the settings of the named profile, see Config.Profiles.
+// The profile shares the Tag and the caches with the engine. It is false if there is no such profile
+// or e doesn't have the Profile method.
+func Profile(e Engine, name string) (Engine, bool) {
+	if w, ok := e.(*wrapped); ok {
+		p, ok := Profile(w.inner, name)
+		if !ok {
+			return nil, false
+		}
+		return w.rewrap(p), true
+	}
+	if p, ok := implementation[interface {
+		Profile(name string) (Engine, bool)
+	}](e); ok {
+		return p.Profile(name)
+	}
+	return nil, false
+}
+
+// Profile returns the engine with the settings of the named profile, see Config.Profiles.
+func (e *engine[T]) Profile(name string) (Engine, bool) {
+	if p, ok := e.profiles[name]; ok {
+		return p, true
+	}
+	return nil, false
+}
+
+// load returns the current settings of the engine.
+func (e *engine[T]) load() *settings {
+	return e.current.Load().(*settings)
+}
+
+// Reconfigure updates the configuration of the engine e at runtime without losing its caches.
+// The update function changes a copy of the current configuration, then the settings derived from it
+// replace the current ones atomically, values being encoded or decoded keep the settings they started with.
+// Marshaller, Unmarshaler, PreferBinaryMarshaler, FieldLess, FieldNameMapper, RequireTag and Profiles
+// can't be changed, their changes are ignored. It returns ErrUnsupported if e doesn't have the Reconfigure method.
+func Reconfigure(e Engine, update func(cfg *Config)) error {
+	r, ok := implementation[interface{ Reconfigure(func(*Config)) }](e)
+	if !ok {
+		return unsupported(e, "Reconfigure")
+	}
+	r.Reconfigure(update)
+	return nil
+}
+
+// Reconfigure updates the configuration of the engine at runtime without losing its caches.
+func (e *engine[T]) Reconfigure(update func(cfg *Config)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	old := e.load().config
+	cfg := old.clone()
+	update(&cfg)
+
+	// These are baked into the caches.
+	cfg.Marshaller, cfg.Unmarshaler, cfg.FieldLess, cfg.Profiles = old.Marshaller, old.Unmarshaler, old.FieldLess, old.Profiles
+	cfg.PreferBinaryMarshaler, cfg.FieldNameMapper, cfg.RequireTag = old.PreferBinaryMarshaler, old.FieldNameMapper, old.RequireTag
+	e.current.Store(newSettings(cfg.clone()))
+}
+
+// Config returns a copy of the current configuration of the engine, see Reconfigure.
+func (e *engine[T]) Config() Config {
+	return e.load().Config()
+}
+
+// separator returns the separator of values of a struct at the nesting depth, starting from 1.
+// Structs nested deeper than the configured separators use the last one.
+func (s *settings) separator(depth int) []byte {
+	if depth > len(s.separators) {
+		depth = len(s.separators)
+	}
+	if depth < 1 {
+		depth = 1
+	}
+	return s.separators[depth-1]
+}
+
+// NameOf returns the name of the tag the engine e works with, or "" if e doesn't have the Name method.
+func NameOf(e Engine) string {
+	if n, ok := implementation[interface{ Name() string }](e); ok {
+		return n.Name()
+	}
+	return ""
+}
+
+// ConfigOf returns a copy of the current configuration of the engine e, see Reconfigure,
+// false if e doesn't have the Config method, e.g. it isn't returned by New.
+func ConfigOf(e Engine) (Config, bool) {
+	if c, ok := implementation[interface{ Config() Config }](e); ok {
+		return c.Config(), true
+	}
+	return Config{}, false
+}
+
+// Config returns a copy of the configuration the settings are derived from.
+func (s *settings) Config() Config {
+	return s.config.clone()
+}
+
+// implementation returns the engine e as the interface I of its optional methods, or the engine it wraps
+// if e doesn't implement I, see Wrap. It is false if none of them implements I.
+func implementation[I any](e Engine) (I, bool) {
+	for {
+		if i, ok := e.(I); ok {
+			return i, true
+		}
+		u, ok := e.(interface{ Unwrap() Engine })
+		if !ok {
+			var zero I
+			return zero, false
+		}
+		e = u.Unwrap()
+	}
+}
+
+// unsupported returns ErrUnsupported for the operation op of the engine e without the optional method.
+func unsupported(e Engine, op string) error {
+	return fmt.Errorf("%T: %w: %s", e, ErrUnsupported, op)
+}
+
+// clone returns a copy of the configuration that doesn't share byte arrays with the original.
+func (c Config) clone() Config {
+	c.StructOpener = cloneBytes(c.StructOpener)
+	c.StructCloser = cloneBytes(c.StructCloser)
+	c.ValueSeparator = cloneBytes(c.ValueSeparator)
+	c.ComponentSeparator = cloneBytes(c.ComponentSeparator)
+	c.SliceOpener = cloneBytes(c.SliceOpener)
+	c.SliceCloser = cloneBytes(c.SliceCloser)
+	c.ElementSeparator = cloneBytes(c.ElementSeparator)
+	c.ElementOpener = cloneBytes(c.ElementOpener)
+	c.ElementCloser = cloneBytes(c.ElementCloser)
+	c.MapOpener = cloneBytes(c.MapOpener)
+	c.MapCloser = cloneBytes(c.MapCloser)
+	c.KeyValueSeparator = cloneBytes(c.KeyValueSeparator)
+	c.EntrySeparator = cloneBytes(c.EntrySeparator)
+	c.RecordSeparator = cloneBytes(c.RecordSeparator)
+	c.NilLiteral = cloneBytes(c.NilLiteral)
+	if c.Header != nil {
+		header := *c.Header
+		c.Header = &header
+	}
+	if c.Separators != nil {
+		separators := make([][]byte, len(c.Separators))
+		for i, separator := range c.Separators {
+			separators[i] = cloneBytes(separator)
+		}
+		c.Separators = separators
+	}
+	if c.Profiles != nil {
+		profiles := make(map[string]Config, len(c.Profiles))
+		for name, profile := range c.Profiles {
+			profiles[name] = profile.clone()
+		}
+		c.Profiles = profiles
+	}
+	return c
+}
+
+func cloneBytes(b []byte) []byte {
+	if b == nil {
+		return nil
 	}
+	return append(make([]byte, 0, len(b)), b...)
 }
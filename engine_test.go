@@ -0,0 +1,284 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// foreignEngine is an Engine implemented outside the package, it has none of the optional methods.
+type foreignEngine struct {
+	Engine
+}
+
+func TestConfigOf(t *testing.T) {
+	var cfg Config
+	e := newTestEngine(func(c *Config) { cfg = *c })
+	equal(t, "test", NameOf(e))
+
+	// The configuration is copied deeply, neither the configuration passed to New
+	// nor the copies returned share their bytes with the engine.
+	cfg.ValueSeparator[0] = ';'
+	got, ok := ConfigOf(e)
+	equal(t, true, ok)
+	equal(t, ",", string(got.ValueSeparator))
+	got.ValueSeparator[0] = ';'
+	got, _ = ConfigOf(e)
+	equal(t, ",", string(got.ValueSeparator))
+	equal(t, cfg.Marshaller, got.Marshaller)
+
+	// An engine without the optional methods has neither a name nor a configuration.
+	equal(t, "", NameOf(foreignEngine{e}))
+	_, ok = ConfigOf(foreignEngine{e})
+	equal(t, false, ok)
+}
+
+func TestProfile(t *testing.T) {
+	type record struct {
+		A string `test:"a"`
+		B string
+	}
+	e := newTestEngine(func(cfg *Config) {
+		cfg.Profiles = map[string]Config{
+			"pipe":  {ValueSeparator: []byte(" | "), ComponentSeparator: []byte(":")},
+			"upper": {ValueSeparator: []byte(","), ComponentSeparator: []byte(":"), Canonicalize: ToUpper},
+		}
+	})
+
+	var tests = []struct {
+		profile string
+		expect  string
+		decoded record
+	}{
+		{
+			profile: "pipe",
+			expect:  "a | b",
+			decoded: record{"a", "b"},
+		},
+		{
+			profile: "upper",
+			expect:  "A,B",
+			decoded: record{"A", "B"},
+		},
+	}
+	for _, tt := range tests {
+		p, ok := Profile(e, tt.profile)
+		equal(t, true, ok)
+		equal(t, "test", NameOf(p))
+
+		b, err := p.Marshal(record{"a", "b"})
+		equal(t, nil, err)
+		equal(t, tt.expect, string(b))
+
+		var got record
+		equal(t, nil, p.Unmarshal([]byte(tt.expect), &got))
+		equal(t, tt.decoded, got)
+	}
+
+	// The engine keeps its own settings.
+	b, err := e.Marshal(record{"a", "b"})
+	equal(t, nil, err)
+	equal(t, "a,b", string(b))
+
+	_, ok := Profile(e, "unknown")
+	equal(t, false, ok)
+}
+
+func TestReconfigure(t *testing.T) {
+	type record struct{ A, B string }
+	e := newTestEngine(nil)
+
+	// The values encoded concurrently use either the old or the new configuration.
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				b, err := e.Marshal(record{"a", "b"})
+				if err != nil || string(b) != "a,b" && string(b) != "a;b" {
+					t.Errorf("unexpected %q: %v", b, err)
+					return
+				}
+			}
+		}()
+	}
+	err := Reconfigure(e, func(cfg *Config) {
+		cfg.ValueSeparator = []byte(";")
+		cfg.Marshaller, cfg.FieldLess = nil, FieldsByName
+	})
+	equal(t, nil, err)
+	wg.Wait()
+
+	b, err := e.Marshal(record{"a", "b"})
+	equal(t, nil, err)
+	equal(t, "a;b", string(b))
+
+	// The settings baked into the caches are kept.
+	cfg, _ := ConfigOf(e)
+	equal(t, testConfig().Marshaller, cfg.Marshaller)
+	equal(t, true, cfg.FieldLess == nil)
+	equal(t, ";", string(cfg.ValueSeparator))
+
+	// An engine without the Reconfigure method can't be reconfigured.
+	err = Reconfigure(foreignEngine{e}, func(cfg *Config) {})
+	equal(t, true, errors.Is(err, ErrUnsupported))
+}
+
+func TestFieldCache(t *testing.T) {
+	type record struct {
+		Name string `test:"name"`
+		Age  int
+	}
+
+	var tests = []struct {
+		configure func(cfg *Config)
+		expect    string
+	}{
+		{
+			configure: nil,
+			expect:    "Name=x,Age=1",
+		},
+		{
+			configure: func(cfg *Config) { cfg.FieldLess = FieldsByName },
+			expect:    "Age=1,Name=x",
+		},
+		{
+			configure: func(cfg *Config) { cfg.RequireTag = true },
+			expect:    "Name=x",
+		},
+		{
+			configure: func(cfg *Config) { cfg.FieldNameMapper = SnakeCase },
+			expect:    "name=x,age=1",
+		},
+	}
+	// The engines of the same Tag encode the same type with their own fields.
+	for _, tt := range tests {
+		b, err := newKeyEngine[testMeta](tt.configure).Marshal(record{"x", 1})
+		equal(t, nil, err)
+		equal(t, tt.expect, string(b))
+	}
+}
+
+func TestCoderCache(t *testing.T) {
+	type point struct{ X, Y int }
+	type record struct {
+		P point
+		N int
+	}
+
+	// The engines of different Tags encode the same types with their own encoders.
+	a, b := newTestEngine(nil), New[testMeta](dialectTag{}, testConfig())
+	for i := 0; i < 2; i++ {
+		data, err := a.Marshal(record{point{1, 2}, 3})
+		equal(t, nil, err)
+		equal(t, "1:2,3", string(data))
+
+		data, err = b.Marshal(record{point{1, 2}, 3})
+		equal(t, nil, err)
+		equal(t, "X=1:Y=2,N=3", string(data))
+	}
+
+	// The encoder of an engine is shared with its profiles and dialects, but not with the other engines.
+	encodePoint := func(v reflect.Value) ([]byte, error) {
+		p := v.Interface().(point)
+		return []byte(fmt.Sprintf("%d;%d", p.X, p.Y)), nil
+	}
+	configure := func(cfg *Config) { cfg.Profiles = map[string]Config{"pipe": {ValueSeparator: []byte("|")}} }
+	shared, other := newTestEngine(configure), newTestEngine(configure)
+	equal(t, nil, RegisterEncoder(shared, reflect.TypeOf(point{}), encodePoint))
+	pipe, _ := Profile(shared, "pipe")
+	dialect, err := WithDialect(shared, Dialect{Name: "d"})
+	equal(t, nil, err)
+	for _, e := range []Engine{shared, pipe, dialect} {
+		data, err := e.Marshal(record{point{1, 2}, 3})
+		equal(t, nil, err)
+		equal(t, true, strings.HasPrefix(string(data), "1;2"))
+	}
+	data, err := other.Marshal(record{point{1, 2}, 3})
+	equal(t, nil, err)
+	equal(t, "1:2,3", string(data))
+
+	err = RegisterEncoder(foreignEngine{shared}, reflect.TypeOf(point{}), encodePoint)
+	equal(t, true, errors.Is(err, ErrUnsupported))
+}
+
+func TestMarshalT(t *testing.T) {
+	e := newTestEngine(nil)
+
+	b, err := MarshalT(e, party{ID: "1", Agency: 9, Code: "c"})
+	equal(t, nil, err)
+	equal(t, "1,9,c", string(b))
+
+	got, err := UnmarshalT[party](e, b)
+	equal(t, nil, err)
+	equal(t, party{ID: "1", Agency: 9, Code: "c"}, got)
+
+	p, err := UnmarshalT[*party](e, b)
+	equal(t, nil, err)
+	equal(t, &party{ID: "1", Agency: 9, Code: "c"}, p)
+
+	_, err = UnmarshalT[party](e, []byte("1,x,c"))
+	equal(t, true, errors.Is(err, strconv.ErrSyntax))
+}
+
+func TestRequireTag(t *testing.T) {
+	type Inner struct {
+		X string `test:"x"`
+		Y string
+	}
+	type record struct {
+		A string `test:"a"`
+		B string
+		C string `test:""`
+		Inner
+	}
+	e := newTestEngine(func(cfg *Config) { cfg.RequireTag = true })
+
+	// An empty tag is a tag, the embedded struct needs none.
+	b, err := e.Marshal(record{A: "a", B: "b", C: "c", Inner: Inner{X: "x", Y: "y"}})
+	equal(t, nil, err)
+	equal(t, "a,c,x", string(b))
+
+	var got record
+	equal(t, nil, e.Unmarshal(b, &got))
+	equal(t, record{A: "a", C: "c", Inner: Inner{X: "x"}}, got)
+}
+
+func TestMaxDepth(t *testing.T) {
+	type node struct {
+		Name string
+		Next *node `test:"omitempty"`
+	}
+	e := newTestEngine(func(cfg *Config) {
+		cfg.StructOpener, cfg.StructCloser, cfg.UnwrapWhenDecoding = []byte("("), []byte(")"), true
+		cfg.MaxDepth = 3
+	})
+	value := node{Name: "a", Next: &node{Name: "b", Next: &node{Name: "c"}}}
+
+	b, err := e.Marshal(value)
+	equal(t, nil, err)
+	equal(t, "(a,(b:(c)))", string(b))
+	var got node
+	equal(t, nil, e.Unmarshal(b, &got))
+	equal(t, value, got)
+
+	value.Next.Next.Next = &node{Name: "d"}
+	_, err = e.Marshal(value)
+	equal(t, true, errors.Is(err, ErrMaxDepth))
+	var ee *EncodeError
+	equal(t, true, errors.As(err, &ee))
+	equal(t, "node.Next.Next.Next", ee.Path)
+	// The configured depth is reported.
+	equal(t, "the value exceeds the maximum nesting depth of 3", ee.Err.Error())
+
+	err = e.Unmarshal([]byte("(a,(b:(c:(d))))"), &got)
+	equal(t, true, errors.Is(err, ErrMaxDepth))
+	var de *DecodeError
+	equal(t, true, errors.As(err, &de))
+	equal(t, true, strings.HasSuffix(de.Error(), "of 3"))
+}
@@ -0,0 +1,59 @@
+package engine
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// EncodeError describes a failure to encode a Go value, it is returned by the engines and the encoders,
+// possibly wrapped, so that callers get the failing field with errors.As instead of parsing the message.
+type EncodeError struct {
+	TagName    string       // the name of the engine tag
+	StructType string       // the name of the struct type holding the field, empty for a value that isn't a field
+	FieldName  string       // the name of the field, empty for a value that isn't a field
+	Path       string       // the path of the field from the outermost struct, e.g. "Outer.Inner.Leaf"
+	FieldType  reflect.Type // the type of the value
+	Err        error        // the cause of the failure
+}
+
+func (e *EncodeError) Error() string {
+	return errorMessage(e.TagName, marshalError, e.Path, e.FieldType, "", e.Err)
+}
+
+func (e *EncodeError) Unwrap() error {
+	return e.Err
+}
+
+// DecodeError describes a failure to decode data into a Go value, it is returned by the engines and the decoders,
+// possibly wrapped, so that callers get the failing field with errors.As instead of parsing the message.
+type DecodeError struct {
+	TagName    string       // the name of the engine tag
+	StructType string       // the name of the struct type holding the field, empty for a value that isn't a field
+	FieldName  string       // the name of the field, empty for a value that isn't a field
+	Path       string       // the path of the field from the outermost struct, e.g. "Outer.Inner.Leaf"
+	FieldType  reflect.Type // the type of the value
+	Err        error        // the cause of the failure
+	// Offset is the offset of the value in the data, counted in bytes from the start of the data passed to
+	// Engine.Unmarshal or of the record read by a Decoder. Line and Column, counted from 1, locate the value
+	// in text data. They are all zero if the position is unknown.
+	Offset, Line, Column int
+}
+
+func (e *DecodeError) Error() string {
+	var position string
+	if e.Line != 0 {
+		position = fmt.Sprintf(" at offset %d (line %d, column %d)", e.Offset, e.Line, e.Column)
+	}
+	return errorMessage(e.TagName, unmarshalError, e.Path, e.FieldType, position, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+func errorMessage(tagName, state, path string, fieldType reflect.Type, position string, err error) string {
+	if path == "" {
+		return fmt.Sprintf("%s: cannot %s Go value of type %s%s: %v", tagName, state, fieldType, position, err)
+	}
+	return fmt.Sprintf("%s: cannot %s Go struct field %s of type %s%s: %v", tagName, state, path, fieldType, position, err)
+}
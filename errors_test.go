@@ -0,0 +1,184 @@
+package engine
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var errStamp = errors.New("invalid stamp")
+
+func failStamp(reflect.Value) ([]byte, error) {
+	return nil, errStamp
+}
+
+func TestEncodeError(t *testing.T) {
+	type record struct {
+		A string
+		B stamp
+	}
+	e := newTestEngine(nil)
+	equal(t, nil, RegisterEncoder(e, reflect.TypeOf(stamp{}), failStamp))
+
+	var tests = []struct {
+		value any
+		exp   EncodeError
+		msg   string
+	}{
+		{
+			value: record{},
+			exp:   EncodeError{TagName: "test", StructType: "record", FieldName: "B", Path: "record.B", FieldType: reflect.TypeOf(stamp{}), Err: errStamp},
+			msg:   "test: cannot encode data from Go struct field record.B of type engine.stamp: invalid stamp",
+		},
+		{
+			value: stamp{},
+			exp:   EncodeError{TagName: "test", FieldType: reflect.TypeOf(stamp{}), Err: errStamp},
+			msg:   "test: cannot encode data from Go value of type engine.stamp: invalid stamp",
+		},
+	}
+	for _, tt := range tests {
+		_, err := e.Marshal(tt.value)
+		var ee *EncodeError
+		equal(t, true, errors.As(err, &ee))
+		equal(t, tt.exp, *ee)
+		equal(t, tt.msg, err.Error())
+		equal(t, true, errors.Is(err, errStamp))
+	}
+}
+
+func TestDecodeError(t *testing.T) {
+	type record struct {
+		A string
+		B int
+	}
+	e := newTestEngine(nil)
+
+	var tests = []struct {
+		data  string
+		value any
+		exp   DecodeError
+	}{
+		{
+			data:  "a,b",
+			value: new(record),
+			exp:   DecodeError{TagName: "test", StructType: "record", FieldName: "B", Path: "record.B", FieldType: reflect.TypeOf(0), Offset: 2, Line: 1, Column: 3},
+		},
+		{
+			data:  "b",
+			value: new(int),
+			exp:   DecodeError{TagName: "test", FieldType: reflect.TypeOf(0), Line: 1, Column: 1},
+		},
+	}
+	for _, tt := range tests {
+		err := e.Unmarshal([]byte(tt.data), tt.value)
+		var de *DecodeError
+		equal(t, true, errors.As(err, &de))
+		equal(t, true, de.Err != nil)
+		de.Err = nil
+		equal(t, tt.exp, *de)
+	}
+}
+
+func TestDecodeErrorPosition(t *testing.T) {
+	var tests = []struct {
+		engine Engine
+		data   string
+		msg    string
+		offset int
+		line   int
+		column int
+	}{
+		{
+			engine: newTestEngine(nil),
+			data:   "BY,1:x",
+			msg:    "test: cannot decode data into Go struct field nameAndAddress.Party.Agency of type int at offset 5 (line 1, column 6): invalid syntax",
+			offset: 5, line: 1, column: 6,
+		},
+		{
+			engine: newTestEngine(nil),
+			data:   "BY,1:2:c,\n\n2:y",
+			msg:    "test: cannot decode data into Go struct field nameAndAddress.Agent.Agency of type int at offset 13 (line 3, column 3): invalid syntax",
+			offset: 13, line: 3, column: 3,
+		},
+		{
+			// The position of a value in keyed data is its position in the data, not in the order of the fields.
+			engine: newKeyEngine[testMeta](nil),
+			data:   "Party=Agency=x:ID=1,Qualifier=BY",
+			msg:    "test: cannot decode data into Go struct field nameAndAddress.Party.Agency of type int at offset 13 (line 1, column 14): invalid syntax",
+			offset: 13, line: 1, column: 14,
+		},
+	}
+	for _, tt := range tests {
+		var got nameAndAddress
+		err := tt.engine.Unmarshal([]byte(tt.data), &got)
+		var de *DecodeError
+		equal(t, true, errors.As(err, &de))
+		equal(t, tt.msg, err.Error())
+		equal(t, tt.offset, de.Offset)
+		equal(t, tt.line, de.Line)
+		equal(t, tt.column, de.Column)
+	}
+
+	// The offsets of the values read by a Decoder are counted from the start of the record.
+	dec := NewDecoder(newTestEngine(nil), strings.NewReader("a,1\nb,x\n"))
+	_, err := decodeAll[streamed](dec)
+	var de *DecodeError
+	equal(t, true, errors.As(err, &de))
+	equal(t, 2, de.Offset)
+	equal(t, 1, de.Line)
+}
+
+func TestErrorPaths(t *testing.T) {
+	type base struct {
+		N int
+	}
+	type inner struct {
+		base
+		Code string
+	}
+	type outer struct {
+		A     string
+		Inner inner
+		L     []party
+	}
+	e := newTestEngine(func(cfg *Config) { cfg.ElementSeparator = []byte("~") })
+
+	var tests = []struct {
+		data string
+		path string
+	}{
+		{
+			data: "a,x",
+			path: "outer.Inner.base.N",
+		},
+		{
+			// The elements of a slice share the path.
+			data: "a,1:c,1:2:c~2:y:d",
+			path: "outer.L.Agency",
+		},
+	}
+	for _, tt := range tests {
+		var got outer
+		err := e.Unmarshal([]byte(tt.data), &got)
+		var de *DecodeError
+		equal(t, true, errors.As(err, &de))
+		equal(t, tt.path, de.Path)
+		equal(t, true, strings.Contains(err.Error(), " "+tt.path+" "))
+	}
+
+	type stamped struct {
+		base
+		S stamp
+	}
+	type deep struct {
+		Stamped stamped
+	}
+	equal(t, nil, RegisterEncoder(e, reflect.TypeOf(stamp{}), failStamp))
+	_, err := e.Marshal(deep{})
+	var ee *EncodeError
+	equal(t, true, errors.As(err, &ee))
+	equal(t, "deep.Stamped.S", ee.Path)
+	equal(t, "S", ee.FieldName)
+	equal(t, "stamped", ee.StructType)
+}
@@ -0,0 +1,113 @@
+package engine
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// ErrUnknownField is returned when the data has values that match no field, see Config.DisallowUnknownFields.
+var ErrUnknownField = errors.New("the value matches no field")
+
+// Extras captures the values of a struct that match no field, so that they survive a round trip.
+// A struct field of this type isn't a value itself. When decoding, it receives the values left after the last field
+// of the struct keyed by their positions among the values of the struct, e.g. "3", as they are in the data,
+// or by their keys if the Tag is a KeyedTag.
+// When encoding, they are written after the last field in the order of their positions.
+// It takes effect when the library splits the data itself, see Config.ComponentSeparator.
+type Extras map[string][]byte
+
+var extrasType = reflect.TypeOf(Extras(nil))
+
+// extras returns the Extras field of the struct, not of the embedded ones, nil if there is none.
+func (f structFields[T]) extras() *field[T] {
+	for i := range f {
+		if f[i].extras {
+			return &f[i]
+		}
+	}
+	return nil
+}
+
+// count returns the number of the values of the fields of the struct, the fields of embedded structs included.
+func (f structFields[T]) count() (n int) {
+	for _, fld := range f {
+		switch {
+		case fld.extras:
+		case fld.embedded != nil:
+			n += fld.embedded.count()
+		default:
+			n++
+		}
+	}
+	return
+}
+
+// decodeExtras decodes the values left after the last field of the struct into its Extras field,
+// or reports them if Config.DisallowUnknownFields is set. Otherwise, they are left in the data.
+func (f *structFields[T]) decodeExtras(s *decodeState[T], v reflect.Value, unwrap bool) error {
+	fld := f.extras()
+	if fld == nil && !s.disallowUnknown {
+		return nil
+	}
+
+	separator := s.separator(s.depth)
+
+	var extras Extras
+	for i := f.count(); len(s.data) != 0 && !(unwrap && bytes.HasPrefix(s.data, s.structCloser)); i++ {
+		value := s.cut(separator)
+		if fld == nil {
+			s.err = fmt.Errorf("%s: %w: value %d of struct %s", s.Name(), ErrUnknownField, i, v.Type().Name())
+			return errExist
+		}
+		if extras == nil {
+			extras = make(Extras)
+		}
+		extras[strconv.Itoa(i)] = append([]byte(nil), value...)
+	}
+
+	if fld != nil {
+		v.Field(fld.index).Set(reflect.ValueOf(extras))
+	}
+	return nil
+}
+
+// encodeExtras writes the values of the Extras field of the struct after its last field, see Extras.
+// It reports whether anything is written.
+func (f *structFields[T]) encodeExtras(s *encodeState[T], v reflect.Value, sep bool) bool {
+	fld := f.extras()
+	if fld == nil {
+		return false
+	}
+	extras := v.Field(fld.index).Interface().(Extras)
+	if len(extras) == 0 {
+		return false
+	}
+
+	keys := make([]string, 0, len(extras))
+	for key := range extras {
+		keys = append(keys, key)
+	}
+	// The positions go in numeric order before the keys of keyed data, see KeyedTag.
+	sort.Slice(keys, func(i, j int) bool {
+		a, errA := strconv.Atoi(keys[i])
+		b, errB := strconv.Atoi(keys[j])
+		if errA != nil || errB != nil {
+			return errA == nil || errB != nil && keys[i] < keys[j]
+		}
+		return a < b
+	})
+
+	separator := s.separator(s.depth)
+	for _, key := range keys {
+		if sep {
+			s.Write(separator)
+		}
+		sep = len(separator) != 0
+		s.Write(extras[key])
+	}
+	return true
+}
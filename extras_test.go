@@ -0,0 +1,77 @@
+package engine
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestExtras(t *testing.T) {
+	type inner struct {
+		X string
+		E Extras
+	}
+	type record struct {
+		A      string
+		I      inner
+		B      string
+		Extras Extras
+	}
+	value := record{
+		A:      "a",
+		I:      inner{X: "x", E: Extras{"1": []byte("y"), "2": []byte("z")}},
+		B:      "b",
+		Extras: Extras{"3": []byte("c"), "4": []byte("d")},
+	}
+
+	// The structs with an Extras field capture the values matching no field, whatever the configuration is.
+	for _, disallow := range []bool{false, true} {
+		e := newTestEngine(func(cfg *Config) { cfg.DisallowUnknownFields = disallow })
+		var got record
+		equal(t, nil, e.Unmarshal([]byte("a,x:y:z,b,c,d"), &got))
+		equal(t, value, got)
+
+		b, err := e.Marshal(got)
+		equal(t, nil, err)
+		equal(t, "a,x:y:z,b,c,d", string(b))
+	}
+}
+
+func TestDisallowUnknownFields(t *testing.T) {
+	type record struct{ A, B string }
+
+	var tests = []struct {
+		disallow bool
+		data     string
+		expect   error
+	}{
+		{
+			data: "a,b,c",
+		},
+		{
+			disallow: true,
+			data:     "a,b",
+		},
+		{
+			disallow: true,
+			data:     "a,b,c",
+			expect:   ErrUnknownField,
+		},
+	}
+	for _, tt := range tests {
+		e := newTestEngine(func(cfg *Config) { cfg.DisallowUnknownFields = tt.disallow })
+		var got record
+		err := e.Unmarshal([]byte(tt.data), &got)
+		equal(t, true, errors.Is(err, tt.expect))
+		equal(t, record{A: "a", B: "b"}, got)
+	}
+
+	// The columns of the header matching no field are reported as well.
+	e := newEngineOf[columnMeta](func(cfg *Config) {
+		cfg.RecordSeparator, cfg.DisallowUnknownFields = []byte("\n"), true
+	})
+	dec := NewDecoder(e, strings.NewReader("Name,x\nAnn,1\n"))
+	_, err := dec.ReadHeader(HeaderOptions{})
+	equal(t, nil, err)
+	equal(t, true, errors.Is(dec.Decode(&contact{}), ErrUnknownField))
+}
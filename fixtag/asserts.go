@@ -0,0 +1,35 @@
+// Code generated by format-engine. DO NOT EDIT.
+
+package fixtag
+
+import "reflect"
+
+// Marshaller is the interface implemented by types that can marshal themselves into valid FIXTAG.
+type Marshaller interface {
+	MarshalFIXTAG() ([]byte, error)
+}
+
+// IsMarshaller attempts to cast the value to FIXTAG Marshaller interface,
+// if so, returns a marshal function.
+func (e engineTag) IsMarshaller(rv reflect.Value) (func() ([]byte, error), bool) {
+	if i, ok := rv.Interface().(Marshaller); ok {
+		return i.MarshalFIXTAG, ok
+	}
+
+	return nil, false
+}
+
+// Unmarshaler is the interface implemented by types that can unmarshal FIXTAG description of themselves.
+type Unmarshaler interface {
+	UnmarshalFIXTAG([]byte) error
+}
+
+// IsUnmarshaler attempts to cast the value to FIXTAG Unmarshaler interface,
+// if so, returns an unmarshal function.
+func (e engineTag) IsUnmarshaler(rv reflect.Value) (func([]byte) error, bool) {
+	if i, ok := rv.Interface().(Unmarshaler); ok {
+		return i.UnmarshalFIXTAG, ok
+	}
+
+	return nil, false
+}
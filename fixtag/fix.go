@@ -0,0 +1,102 @@
+// Package fixtag implements encoding and decoding of FIX protocol messages,
+// "number=value" fields delimited by SOH, with BodyLength(9) and CheckSum(10) computation.
+//
+// Struct fields are bound to FIX fields with tags like `fix:"35"` or `fix:"58,omitempty"`.
+// A message must have a BeginString(8) field, BodyLength(9) and CheckSum(10) fields are optional,
+// they are computed by Marshal and verified by Unmarshal.
+package fixtag
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/gromey/format-engine"
+)
+
+var (
+	ErrNoTagNumber   = errors.New("field has no tag number")
+	ErrNoBeginString = errors.New("message has no BeginString(8) field")
+	ErrBodyLength    = errors.New("message has an invalid BodyLength(9)")
+	ErrCheckSum      = errors.New("message has an invalid CheckSum(10)")
+)
+
+// Marshal encodes the value v and returns a FIX message.
+func Marshal(v any) ([]byte, error) {
+	body, err := fix.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	// The BeginString(8) field goes first, the BodyLength(9) field follows it.
+	i := bytes.IndexByte(body, SOH)
+	if !bytes.HasPrefix(body, []byte("8=")) || i < 0 {
+		return nil, fmt.Errorf("%s: %w", engine.NameOf(fix), ErrNoBeginString)
+	}
+	begin, body := body[:i+1], body[i+1:]
+
+	msg := make([]byte, 0, len(begin)+len(body)+16)
+	msg = append(msg, begin...)
+	msg = append(msg, "9="...)
+	msg = strconv.AppendInt(msg, int64(len(body)), 10)
+	msg = append(msg, SOH)
+	msg = append(msg, body...)
+	sum := checkSum(msg)
+	msg = append(msg, "10="...)
+	msg = appendCheckSum(msg, sum)
+	return append(msg, SOH), nil
+}
+
+// Unmarshal verifies the BodyLength(9) and CheckSum(10) of the FIX message data
+// and stores the result in the value pointed to by v.
+func Unmarshal(data []byte, v any) error {
+	if err := verify(data); err != nil {
+		return fmt.Errorf("%s: %w", engine.NameOf(fix), err)
+	}
+	return fix.Unmarshal(data, v)
+}
+
+// verify checks that the message starts with BeginString(8) and BodyLength(9) fields,
+// and ends with a CheckSum(10) field, and that their values match the message.
+func verify(data []byte) error {
+	i := bytes.IndexByte(data, SOH)
+	if !bytes.HasPrefix(data, []byte("8=")) || i < 0 {
+		return ErrNoBeginString
+	}
+
+	rest := data[i+1:]
+	j := bytes.IndexByte(rest, SOH)
+	if !bytes.HasPrefix(rest, []byte("9=")) || j < 0 {
+		return ErrBodyLength
+	}
+	length, err := strconv.Atoi(string(rest[2:j]))
+	if err != nil || length < 0 || j+1+length > len(rest) {
+		return ErrBodyLength
+	}
+
+	end := len(data) - len(rest) + j + 1 + length
+	trailer := data[end:]
+	if !bytes.HasPrefix(trailer, []byte("10=")) || len(trailer) != 7 || trailer[6] != SOH {
+		return ErrBodyLength
+	}
+	if !bytes.Equal(trailer[3:6], appendCheckSum(nil, checkSum(data[:end]))) {
+		return ErrCheckSum
+	}
+
+	return nil
+}
+
+// checkSum returns the sum of all bytes modulo 256.
+func checkSum(b []byte) byte {
+	var sum byte
+	for _, c := range b {
+		sum += c
+	}
+	return sum
+}
+
+// appendCheckSum appends the checksum as three digits.
+func appendCheckSum(b []byte, sum byte) []byte {
+	return append(b, '0'+sum/100, '0'+sum/10%10, '0'+sum%10)
+}
@@ -0,0 +1,160 @@
+package fixtag
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func equal(t *testing.T, exp, got interface{}) {
+	if !reflect.DeepEqual(exp, got) {
+		t.Fatalf("Not equal:\nexp: %v\ngot: %v", exp, got)
+	}
+}
+
+// message replaces the "|" of a readable message with SOH.
+func message(s string) []byte {
+	return []byte(strings.ReplaceAll(s, "|", string(SOH)))
+}
+
+type logon struct {
+	CheckSum      string `fix:"10"`
+	MsgSeqNum     int    `fix:"34"`
+	SenderCompID  string `fix:"49"`
+	SendingTime   string `fix:"52"`
+	TargetCompID  string `fix:"56"`
+	EncryptMethod int    `fix:"98"`
+	HeartBtInt    int    `fix:"108"`
+	Text          string `fix:"58,omitempty"`
+	MsgType       string `fix:"35"`
+	BodyLength    int    `fix:"9"`
+	BeginString   string `fix:"8"`
+}
+
+var testLogon = logon{
+	BeginString:   "FIX.4.2",
+	MsgType:       "A",
+	MsgSeqNum:     177,
+	SenderCompID:  "SERVER",
+	SendingTime:   "20090107-18:15:16",
+	TargetCompID:  "CLIENT",
+	EncryptMethod: 0,
+	HeartBtInt:    30,
+}
+
+const testMessage = "8=FIX.4.2|9=65|35=A|34=177|49=SERVER|52=20090107-18:15:16|56=CLIENT|98=0|108=30|10=062|"
+
+func TestMarshal(t *testing.T) {
+	var tests = []struct {
+		value  any
+		expect string
+		err    error
+	}{
+		{
+			value:  testLogon,
+			expect: testMessage,
+		},
+		{
+			value: struct {
+				MsgType string `fix:"35"`
+				Text    string `fix:"58"`
+				Begin   string `fix:"8"`
+			}{MsgType: "0", Text: "hi", Begin: "FIX.4.4"},
+			expect: "8=FIX.4.4|9=11|35=0|58=hi|10=076|",
+		},
+		{
+			value: struct {
+				MsgType string `fix:"35"`
+			}{MsgType: "0"},
+			err: ErrNoBeginString,
+		},
+	}
+	for _, tt := range tests {
+		b, err := Marshal(tt.value)
+		equal(t, tt.err, errors.Unwrap(err))
+		if tt.err == nil {
+			equal(t, string(message(tt.expect)), string(b))
+		}
+	}
+}
+
+func Test_verify(t *testing.T) {
+	var tests = []struct {
+		data   string
+		expect error
+	}{
+		{
+			data:   testMessage,
+			expect: nil,
+		},
+		{
+			data:   "8=FIX.4.2|9=65|35=A|34=177|49=SERVER|52=20090107-18:15:16|56=CLIENT|98=0|108=30|10=063|",
+			expect: ErrCheckSum,
+		},
+		{
+			data:   "8=FIX.4.2|9=64|35=A|34=177|49=SERVER|52=20090107-18:15:16|56=CLIENT|98=0|108=30|10=062|",
+			expect: ErrBodyLength,
+		},
+		{
+			data:   "8=FIX.4.2|9=x|35=A|10=062|",
+			expect: ErrBodyLength,
+		},
+		{
+			data:   "8=FIX.4.2|35=A|10=062|",
+			expect: ErrBodyLength,
+		},
+		{
+			data:   "8=FIX.4.2|9=65|35=A|34=177|49=SERVER|52=20090107-18:15:16|56=CLIENT|98=0|108=30|",
+			expect: ErrBodyLength,
+		},
+		{
+			data:   "35=A|9=5|10=000|",
+			expect: ErrNoBeginString,
+		},
+	}
+	for _, tt := range tests {
+		equal(t, tt.expect, verify(message(tt.data)))
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	var got logon
+	equal(t, nil, Unmarshal(message(testMessage), &got))
+	expect := testLogon
+	expect.BodyLength, expect.CheckSum = 65, "062"
+	equal(t, expect, got)
+
+	b, err := Marshal(logon{BeginString: "FIX.4.2", MsgType: "5", Text: "bye"})
+	equal(t, nil, err)
+	got = logon{}
+	equal(t, nil, Unmarshal(b, &got))
+	equal(t, "bye", got.Text)
+	equal(t, "5", got.MsgType)
+
+	b[len(b)-2]++
+	equal(t, true, errors.Is(Unmarshal(b, &got), ErrCheckSum))
+}
+
+func Test_checkSum(t *testing.T) {
+	var tests = []struct {
+		data   []byte
+		expect string
+	}{
+		{
+			data:   nil,
+			expect: "000",
+		},
+		{
+			data:   []byte{200, 100},
+			expect: "044",
+		},
+		{
+			data:   []byte("8=FIX.4.4\x01"),
+			expect: "033",
+		},
+	}
+	for _, tt := range tests {
+		equal(t, tt.expect, string(appendCheckSum(nil, checkSum(tt.data))))
+	}
+}
@@ -0,0 +1,143 @@
+package fixtag
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gromey/format-engine"
+)
+
+const (
+	// SOH is the delimiter of FIX fields.
+	SOH = '\x01'
+
+	tagBeginString = 8
+	tagBodyLength  = 9
+	tagMsgType     = 35
+	tagCheckSum    = 10
+)
+
+var (
+	cfg = engine.Config{
+		// The header fields must come first and the checksum last, the rest keep the declaration order.
+		FieldLess: func(a, b engine.FieldInfo) bool {
+			return rank(a) < rank(b)
+		},
+		// WARNING: DO NOT DELETE CONFIGURATIONS BELOW!
+		Marshaller:  reflect.TypeOf((*Marshaller)(nil)).Elem(),
+		Unmarshaler: reflect.TypeOf((*Unmarshaler)(nil)).Elem(),
+	}
+	fix = engine.New[tag](&engineTag{name: "fix"}, cfg)
+)
+
+type engineTag struct {
+	name string
+	engine.Default[tag]
+}
+
+type tag struct {
+	number int
+}
+
+// Rename returns the number of the field, the engine passes it to Encode and Decode instead of the field name.
+func (t *tag) Rename() string {
+	return strconv.Itoa(t.number)
+}
+
+// Name returns the name of the tag.
+func (e engineTag) Name() string {
+	return e.name
+}
+
+// Skip returns a flag indicating that the field should be ignored.
+func (e engineTag) Skip(tagValue string) bool {
+	return tagValue == "-"
+}
+
+// Parse gets a tagValue string in the form "number[,omitempty]", parses the tagValue into tag *tag,
+// returns a flag indicating that the field is skipped if it's empty.
+func (e engineTag) Parse(tagValue string, tag *tag) (omit bool, err error) {
+	number, opts, _ := strings.Cut(tagValue, ",")
+	if tag.number, err = strconv.Atoi(number); err != nil || tag.number <= 0 {
+		return false, fmt.Errorf("invalid tag number %q", number)
+	}
+	return opts == "omitempty", nil
+}
+
+// Encode writes the value as a "number=value<SOH>" field.
+// BodyLength(9) and CheckSum(10) are skipped, Marshal computes them.
+func (e engineTag) Encode(fieldName string, tag *tag, in []byte, out engine.Writer) (err error) {
+	if tag == nil {
+		return fmt.Errorf("%s: %w", fieldName, ErrNoTagNumber)
+	}
+	if tag.number == tagBodyLength || tag.number == tagCheckSum {
+		return
+	}
+
+	if _, err = out.WriteString(fieldName); err != nil {
+		return
+	}
+	if err = out.WriteByte('='); err != nil {
+		return
+	}
+	if _, err = out.Write(in); err != nil {
+		return
+	}
+	return out.WriteByte(SOH)
+}
+
+// Decode finds the "number=value<SOH>" field in the message and writes its value.
+// Fields are looked up by number, so the message may list them in any order.
+func (e engineTag) Decode(fieldName string, tag *tag, in []byte, out engine.Writer) (err error) {
+	if tag == nil {
+		return fmt.Errorf("%s: %w", fieldName, ErrNoTagNumber)
+	}
+	if value, ok := lookup(in, fieldName); ok {
+		_, err = out.Write(value)
+	}
+	return
+}
+
+// lookup returns the value of the first field with the number.
+func lookup(msg []byte, number string) ([]byte, bool) {
+	prefix := append([]byte(number), '=')
+
+	for len(msg) != 0 {
+		field := msg
+		if i := bytes.IndexByte(msg, SOH); i >= 0 {
+			field, msg = msg[:i], msg[i+1:]
+		} else {
+			msg = nil
+		}
+
+		if bytes.HasPrefix(field, prefix) {
+			return field[len(prefix):], true
+		}
+	}
+
+	return nil, false
+}
+
+// rank places BeginString(8), BodyLength(9) and MsgType(35) at the beginning of a message
+// and CheckSum(10) at the end.
+func rank(fi engine.FieldInfo) int {
+	t, ok := fi.Meta.(*tag)
+	if !ok {
+		return 3
+	}
+	switch t.number {
+	case tagBeginString:
+		return 0
+	case tagBodyLength:
+		return 1
+	case tagMsgType:
+		return 2
+	case tagCheckSum:
+		return 4
+	default:
+		return 3
+	}
+}
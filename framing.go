@@ -0,0 +1,141 @@
+package engine
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// ErrFrame is returned when the stream doesn't follow the framing of the decoder, see Framing.
+var ErrFrame = errors.New("the data isn't a valid frame")
+
+// Framing wraps the records of a stream in transport frames, see Encoder.UseFraming and Decoder.UseFraming.
+// The frames delimit the records, so the RecordSeparator is neither written nor expected.
+type Framing interface {
+	// WriteFrame writes the record wrapped in a frame to w.
+	WriteFrame(w io.Writer, record []byte) error
+	// ReadFrame reads the next frame from r and returns the record it wraps.
+	// It returns io.EOF at the end of the stream and io.ErrUnexpectedEOF if the stream ends in the middle of a frame.
+	// If limit isn't 0, a record longer than limit bytes isn't buffered: the frame is skipped up to its end
+	// and ErrLimit is returned. A Decoder passes its Config.MaxRecordSize.
+	ReadFrame(r *bufio.Reader, limit int) ([]byte, error)
+}
+
+var (
+	// MLLP is the Minimal Lower Layer Protocol of HL7: a record is preceded by 0x0B and followed by 0x1C 0x0D.
+	MLLP = Delimited([]byte{0x0B}, []byte{0x1C, 0x0D})
+	// STXETX wraps a record in the ASCII start of text 0x02 and end of text 0x03 characters.
+	STXETX = Delimited([]byte{0x02}, []byte{0x03})
+	// LengthPrefix precedes a record with its length in 4 bytes in big-endian order.
+	LengthPrefix Framing = lengthPrefix{}
+)
+
+// Delimited returns the framing that wraps a record in the start and end byte sequences.
+// The end sequence mustn't occur in the records. It mustn't be empty.
+func Delimited(start, end []byte) Framing {
+	return delimited{start: cloneBytes(start), end: cloneBytes(end)}
+}
+
+type delimited struct {
+	start, end []byte
+}
+
+func (f delimited) WriteFrame(w io.Writer, record []byte) error {
+	frame := make([]byte, 0, len(f.start)+len(record)+len(f.end))
+	frame = append(append(append(frame, f.start...), record...), f.end...)
+	_, err := w.Write(frame)
+	return err
+}
+
+func (f delimited) ReadFrame(r *bufio.Reader, limit int) ([]byte, error) {
+	for i := range f.start {
+		c, err := r.ReadByte()
+		if err != nil {
+			if i != 0 && err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		if c != f.start[i] {
+			return nil, ErrFrame
+		}
+	}
+
+	var (
+		record  []byte
+		skipped bool
+	)
+	for {
+		c, err := r.ReadByte()
+		if err != nil {
+			if skipped && err == io.EOF {
+				return nil, limitError(limit)
+			}
+			return nil, unexpectedEOF(err)
+		}
+		if record = append(record, c); bytes.HasSuffix(record, f.end) {
+			if skipped {
+				return nil, limitError(limit)
+			}
+			return record[:len(record)-len(f.end)], nil
+		}
+		// Only the bytes that may begin the end sequence of a skipped record are kept to find its end.
+		if limit != 0 && len(record) >= limit+len(f.end) {
+			skipped = true
+			record = append(record[:0], record[len(record)-len(f.end)+1:]...)
+		}
+	}
+}
+
+type lengthPrefix struct{}
+
+func (lengthPrefix) WriteFrame(w io.Writer, record []byte) error {
+	if uint64(len(record)) > math.MaxUint32 {
+		return ErrFrame
+	}
+	frame := make([]byte, 4+len(record))
+	binary.BigEndian.PutUint32(frame, uint32(len(record)))
+	copy(frame[4:], record)
+	_, err := w.Write(frame)
+	return err
+}
+
+func (lengthPrefix) ReadFrame(r *bufio.Reader, limit int) ([]byte, error) {
+	var prefix [4]byte
+	if _, err := io.ReadFull(r, prefix[:]); err != nil {
+		return nil, err
+	}
+
+	n := int64(binary.BigEndian.Uint32(prefix[:]))
+	if limit != 0 && n > int64(limit) {
+		// The record is skipped, so that the next frame can be read.
+		if _, err := io.CopyN(io.Discard, r, n); err != nil && err != io.EOF {
+			return nil, err
+		}
+		return nil, limitError(limit)
+	}
+
+	// The record grows as it is read rather than trusting the length to allocate it at once.
+	var record bytes.Buffer
+	if _, err := io.CopyN(&record, r, n); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	return record.Bytes(), nil
+}
+
+// limitError returns the error of a record longer than the limit, see Framing.ReadFrame.
+func limitError(limit int) error {
+	return fmt.Errorf("%w: a frame of more than %d bytes", ErrLimit, limit)
+}
+
+// unexpectedEOF returns io.ErrUnexpectedEOF for the io.EOF of a stream ending in the middle of a frame.
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
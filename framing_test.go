@@ -0,0 +1,132 @@
+package engine
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestFraming(t *testing.T) {
+	var tests = []struct {
+		framing Framing
+		expect  string
+	}{
+		{
+			framing: MLLP,
+			expect:  "\x0ba,1\x1c\r\x0bb\n,2\x1c\r",
+		},
+		{
+			framing: STXETX,
+			expect:  "\x02a,1\x03\x02b\n,2\x03",
+		},
+		{
+			framing: Delimited([]byte("<<"), []byte(">>")),
+			expect:  "<<a,1>><<b\n,2>>",
+		},
+		{
+			framing: LengthPrefix,
+			expect:  "\x00\x00\x00\x03a,1\x00\x00\x00\x04b\n,2",
+		},
+	}
+	// The frames delimit the records, so the RecordSeparator may occur in them.
+	e := newTestEngine(func(cfg *Config) { cfg.RecordSeparator = []byte("\n") })
+	values := []streamed{{"a", 1}, {"b\n", 2}}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		enc := NewEncoder(e, &buf)
+		enc.UseFraming(tt.framing)
+		for _, v := range values {
+			equal(t, nil, enc.Encode(v))
+		}
+		equal(t, tt.expect, buf.String())
+
+		dec := NewDecoder(e, &buf)
+		dec.UseFraming(tt.framing)
+		got, err := decodeAll[streamed](dec)
+		equal(t, io.EOF, err)
+		equal(t, values, got)
+	}
+}
+
+func TestReadFrame(t *testing.T) {
+	var tests = []struct {
+		framing Framing
+		data    string
+		err     error
+	}{
+		{
+			framing: MLLP,
+			data:    "a,1\x1c\r",
+			err:     ErrFrame,
+		},
+		{
+			framing: MLLP,
+			data:    "\x0ba,1\x1c",
+			err:     io.ErrUnexpectedEOF,
+		},
+		{
+			framing: Delimited([]byte("<<"), []byte(">>")),
+			data:    "<",
+			err:     io.ErrUnexpectedEOF,
+		},
+		{
+			framing: LengthPrefix,
+			data:    "\x00\x00",
+			err:     io.ErrUnexpectedEOF,
+		},
+		{
+			framing: LengthPrefix,
+			data:    "\x00\x00\x00\x04a,1",
+			err:     io.ErrUnexpectedEOF,
+		},
+	}
+	e := newTestEngine(nil)
+	for _, tt := range tests {
+		dec := NewDecoder(e, strings.NewReader(tt.data))
+		dec.UseFraming(tt.framing)
+		err := dec.Decode(&streamed{})
+		equal(t, true, errors.Is(err, tt.err))
+	}
+}
+
+func TestReadFrameLimit(t *testing.T) {
+	// The length of the record isn't trusted to buffer it.
+	_, err := LengthPrefix.ReadFrame(bufio.NewReader(strings.NewReader("\xff\xff\xff\xffa,1")), 3)
+	equal(t, true, errors.Is(err, ErrLimit))
+
+	record, err := MLLP.ReadFrame(bufio.NewReader(strings.NewReader("\x0bbb,22\x1c\r")), 0)
+	equal(t, nil, err)
+	equal(t, "bb,22", string(record))
+	_, err = MLLP.ReadFrame(bufio.NewReader(strings.NewReader("\x0bbb,22")), 3)
+	equal(t, true, errors.Is(err, ErrLimit))
+
+	// The frame of the record longer than the limit is skipped, so that the next one can be read.
+	r := bufio.NewReader(strings.NewReader("\x0bbb,22\x1c\r\x0bc,3\x1c\r"))
+	_, err = MLLP.ReadFrame(r, 3)
+	equal(t, true, errors.Is(err, ErrLimit))
+	record, err = MLLP.ReadFrame(r, 3)
+	equal(t, nil, err)
+	equal(t, "c,3", string(record))
+}
+
+func TestDecoderFrameLimit(t *testing.T) {
+	e := newTestEngine(func(cfg *Config) { cfg.MaxRecordSize = 8 })
+
+	// The length of a frame beyond the limit is rejected without reading the record.
+	dec := NewDecoder(e, strings.NewReader("\xff\xff\xff\xffa,1"))
+	dec.UseFraming(LengthPrefix)
+	err := dec.Decode(&streamed{})
+	equal(t, true, errors.Is(err, ErrLimit))
+
+	// The record longer than the limit is skipped, so that the next one can be decoded.
+	dec = NewDecoder(e, strings.NewReader("\x00\x00\x00\x09aaaaaaa,1\x00\x00\x00\x03b,2"))
+	dec.UseFraming(LengthPrefix)
+	err = dec.Decode(&streamed{})
+	equal(t, true, errors.Is(err, ErrLimit))
+	var got streamed
+	equal(t, nil, dec.Decode(&got))
+	equal(t, streamed{A: "b", B: 2}, got)
+}
@@ -1,3 +1,3 @@
 module github.com/gromey/format-engine
 
-go 1.18
+go 1.21
@@ -0,0 +1,228 @@
+package engine
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ColumnNamer is the interface implemented by a parsed tag, a *T of the engine Tag,
+// that names the column of its field in the header record instead of HeaderOptions.NameTransform,
+// and the key of its field in keyed data, see KeyedTag.
+type ColumnNamer interface {
+	// ColumnName returns the name of the column, the empty string means the default one.
+	ColumnName() string
+}
+
+// HeaderOptions control how the columns of the records are named in the header record, see Config.Header,
+// and how Decoder.ReadHeader binds them to the fields of a struct.
+type HeaderOptions struct {
+	// NameTransform returns the name of the column of a field from the name of the field, e.g. strings.ToLower.
+	// If it is nil, the column has the name of the field.
+	NameTransform func(fieldName string) string
+	// CaseInsensitive makes the names of the columns match the names of the fields regardless of case
+	// when decoding.
+	CaseInsensitive bool
+}
+
+// ReadHeader reads the header record of tabular input, the names of the columns separated by the ValueSeparator,
+// and makes the decoder bind the columns of the following records to the fields of a struct by their names
+// instead of their positions. It returns the names of the columns.
+// Only the fields at the top level of a struct, the fields of embedded structs included, are bound.
+// The fields missing in the header are left zero and the columns matching no field are ignored.
+func (dec *Decoder) ReadHeader(opts HeaderOptions) ([]string, error) {
+	if dec.err != nil {
+		return nil, dec.err
+	}
+
+	for {
+		data, err := dec.readValue()
+		if len(data) != 0 {
+			columns, err := dec.e.splitHeader(data)
+			if err != nil {
+				return nil, err
+			}
+			dec.columns, dec.header, dec.bindings = columns, opts, nil
+			return columns, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// bind returns the record with the columns reordered to the fields of the struct v points to.
+// The record is returned as is if there is no header or v doesn't point to a struct.
+func (dec *Decoder) bind(record []byte, v any) ([]byte, error) {
+	if dec.columns == nil || v == nil {
+		return record, nil
+	}
+	t := elemType(v)
+	if t.Kind() != reflect.Struct {
+		return record, nil
+	}
+
+	binding, ok := dec.bindings[t]
+	if !ok {
+		if dec.bindings == nil {
+			dec.bindings = make(map[reflect.Type][]int)
+		}
+		var err error
+		if binding, err = dec.e.bindColumns(t, dec.columns, dec.header); err != nil {
+			return nil, err
+		}
+		dec.bindings[t] = binding
+	}
+	return dec.e.reorderColumns(record, binding)
+}
+
+// splitHeader returns the names of the columns of the header record.
+func (e *engine[T]) splitHeader(record []byte) ([]string, error) {
+	s := e.newDecodeState()
+	defer decodeStatePool.Put(s)
+
+	values, err := s.cutAll(record, s.separator(1))
+	if err != nil {
+		return nil, s.err
+	}
+
+	columns := make([]string, len(values))
+	for i, value := range values {
+		columns[i] = string(bytes.TrimSpace(s.release(value)))
+	}
+	return columns, nil
+}
+
+// bindColumns returns the indexes of the columns of the top level fields of the struct, -1 for missing ones.
+// If Config.DisallowUnknownFields is set, the columns matching no field are reported.
+func (e *engine[T]) bindColumns(t reflect.Type, columns []string, opts HeaderOptions) ([]int, error) {
+	names := e.columnNames(t, opts)
+	binding := make([]int, len(names))
+	bound := make([]bool, len(columns))
+
+	for i, name := range names {
+		binding[i] = -1
+		for j, column := range columns {
+			if column == name || opts.CaseInsensitive && strings.EqualFold(column, name) {
+				binding[i], bound[j] = j, true
+				break
+			}
+		}
+	}
+
+	if e.load().disallowUnknown {
+		for j, ok := range bound {
+			if !ok {
+				return nil, fmt.Errorf("%s: %w: column %s of struct %s", e.Name(), ErrUnknownField, columns[j], t.Name())
+			}
+		}
+	}
+	return binding, nil
+}
+
+// columnNames returns the names of the columns of the top level fields of the struct, see ColumnNamer.
+func (e *engine[T]) columnNames(t reflect.Type, opts HeaderOptions) []string {
+	return e.cachedFields(t).names(nil, opts)
+}
+
+// names appends the names of the fields, the fields of embedded structs included, to dst, see ColumnNamer.
+func (f structFields[T]) names(dst []string, opts HeaderOptions) []string {
+	for _, fld := range f {
+		switch {
+		case fld.extras:
+		case fld.embedded != nil:
+			dst = fld.embedded.names(dst, opts)
+		default:
+			if n, ok := any(fld.meta).(ColumnNamer); ok && fld.meta != nil && n.ColumnName() != "" {
+				dst = append(dst, n.ColumnName())
+			} else if opts.NameTransform != nil {
+				dst = append(dst, opts.NameTransform(fld.key))
+			} else {
+				dst = append(dst, fld.key)
+			}
+		}
+	}
+	return dst
+}
+
+// MarshalAll encodes the elements of the slice or the array values with the engine e as records,
+// each followed by the RecordSeparator. If Config.Header is set and the elements are structs, the records
+// are preceded by the header record, the names of the columns of the fields separated by the ValueSeparator.
+// It returns ErrUnsupported if e doesn't have the MarshalAll method.
+func MarshalAll(e Engine, values any) ([]byte, error) {
+	if m, ok := e.(interface{ MarshalAll(any) ([]byte, error) }); ok {
+		return m.MarshalAll(values)
+	}
+	return nil, unsupported(e, "MarshalAll")
+}
+
+// MarshalAll encodes the elements of the slice or the array values as records, see the function MarshalAll.
+func (e *engine[T]) MarshalAll(values any) ([]byte, error) {
+	if values == nil {
+		return nil, fmt.Errorf("%s: %w", e.Name(), ErrNilInterface)
+	}
+	rv := reflect.ValueOf(values)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, fmt.Errorf("%s: %w: %s", e.Name(), ErrNotSupportType, rv.Type())
+	}
+
+	s := e.load()
+
+	var out []byte
+	if t := rv.Type().Elem(); s.header != nil {
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if t.Kind() == reflect.Struct {
+			out = s.appendHeader(out, e.columnNames(t, *s.header))
+		}
+	}
+
+	for i := 0; i < rv.Len(); i++ {
+		var err error
+		if out, err = e.MarshalAppend(out, rv.Index(i).Interface()); err != nil {
+			return nil, err
+		}
+		out = append(out, s.recordSeparator...)
+	}
+	return out, nil
+}
+
+// appendHeader appends the header record with the names of the columns to dst.
+func (s *settings) appendHeader(dst []byte, names []string) []byte {
+	for i, name := range names {
+		if i != 0 {
+			dst = append(dst, s.separator(1)...)
+		}
+		if s.escape != 0 {
+			dst = s.escapeValue(dst, []byte(name))
+		} else {
+			dst = append(dst, name...)
+		}
+	}
+	return append(dst, s.recordSeparator...)
+}
+
+// reorderColumns returns the record with the columns in the order of the binding, see bindColumns.
+func (e *engine[T]) reorderColumns(record []byte, binding []int) ([]byte, error) {
+	s := e.newDecodeState()
+	defer decodeStatePool.Put(s)
+
+	separator := s.separator(1)
+	values, err := s.cutAll(record, separator)
+	if err != nil {
+		return nil, s.err
+	}
+
+	var out []byte
+	for i, index := range binding {
+		if i != 0 {
+			out = append(out, separator...)
+		}
+		if index >= 0 && index < len(values) {
+			out = append(out, values[index]...)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,124 @@
+package engine
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// columnMeta names the column of its field with the value of the tag, see ColumnNamer.
+type columnMeta struct {
+	column string
+}
+
+func (m *columnMeta) parse(tagValue string) (bool, error) {
+	m.column = tagValue
+	return false, nil
+}
+
+func (m *columnMeta) ColumnName() string {
+	return m.column
+}
+
+type contact struct {
+	Name  string
+	Email string `test:"e-mail"`
+	Age   int
+}
+
+func TestReadHeader(t *testing.T) {
+	e := newEngineOf[columnMeta](func(cfg *Config) { cfg.RecordSeparator = []byte("\n") })
+
+	var tests = []struct {
+		opts    HeaderOptions
+		data    string
+		columns []string
+		expect  []contact
+	}{
+		{
+			data:    "Age,e-mail,Name\n30,a@b,Ann\n\n40,c@d,Bob\n",
+			columns: []string{"Age", "e-mail", "Name"},
+			expect:  []contact{{Name: "Ann", Email: "a@b", Age: 30}, {Name: "Bob", Email: "c@d", Age: 40}},
+		},
+		{
+			// The missing fields are left zero and the unknown columns are ignored.
+			data:    "\nx, Name \n1,Ann\n",
+			columns: []string{"x", "Name"},
+			expect:  []contact{{Name: "Ann"}},
+		},
+		{
+			opts:    HeaderOptions{NameTransform: strings.ToLower},
+			data:    "age,name,Email\n30,Ann,a@b\n",
+			columns: []string{"age", "name", "Email"},
+			expect:  []contact{{Name: "Ann", Age: 30}},
+		},
+		{
+			opts:    HeaderOptions{CaseInsensitive: true},
+			data:    "AGE,E-Mail,name\n30,a@b,Ann\n",
+			columns: []string{"AGE", "E-Mail", "name"},
+			expect:  []contact{{Name: "Ann", Email: "a@b", Age: 30}},
+		},
+	}
+	for _, tt := range tests {
+		dec := NewDecoder(e, strings.NewReader(tt.data))
+		columns, err := dec.ReadHeader(tt.opts)
+		equal(t, nil, err)
+		equal(t, tt.columns, columns)
+
+		got, err := decodeAll[contact](dec)
+		equal(t, io.EOF, err)
+		equal(t, tt.expect, got)
+	}
+
+	dec := NewDecoder(e, strings.NewReader("\n"))
+	_, err := dec.ReadHeader(HeaderOptions{})
+	equal(t, io.EOF, err)
+}
+
+func TestMarshalAll(t *testing.T) {
+	values := []contact{{Name: "Ann", Email: "a,b", Age: 30}, {Name: "Bob", Age: 40}}
+
+	var tests = []struct {
+		header *HeaderOptions
+		values any
+		expect string
+	}{
+		{
+			values: values,
+			expect: "Ann,a\\,b,30\nBob,,40\n",
+		},
+		{
+			header: &HeaderOptions{},
+			values: values,
+			expect: "Name,e-mail,Age\nAnn,a\\,b,30\nBob,,40\n",
+		},
+		{
+			header: &HeaderOptions{NameTransform: strings.ToUpper},
+			values: []*contact{&values[1]},
+			expect: "NAME,e-mail,AGE\nBob,,40\n",
+		},
+		{
+			// Only the records of structs have a header.
+			header: &HeaderOptions{},
+			values: [2]string{"a", "b"},
+			expect: "a\nb\n",
+		},
+	}
+	for _, tt := range tests {
+		e := newEngineOf[columnMeta](func(cfg *Config) {
+			cfg.RecordSeparator, cfg.EscapeChar, cfg.Header = []byte("\n"), '\\', tt.header
+		})
+		b, err := MarshalAll(e, tt.values)
+		equal(t, nil, err)
+		equal(t, tt.expect, string(b))
+	}
+
+	e := newEngineOf[columnMeta](nil)
+	_, err := MarshalAll(e, nil)
+	equal(t, true, errors.Is(err, ErrNilInterface))
+	_, err = MarshalAll(e, values[0])
+	equal(t, true, errors.Is(err, ErrNotSupportType))
+	_, err = MarshalAll(foreignEngine{e}, values)
+	equal(t, true, errors.Is(err, ErrUnsupported))
+}
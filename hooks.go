@@ -0,0 +1,115 @@
+package engine
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// TagStructHooks is the interface implemented by a Tag that writes its own header and trailer around
+// every struct, e.g. the length of the struct, a counter or a record type, instead of or besides
+// the static StructOpener and StructCloser, which are written between them. The structs are the values
+// of the top-level structs and of the nested struct fields, the embedded structs are a part of the struct embedding them.
+type TagStructHooks interface {
+	// EncodeStructStart writes the header of the struct of the type, which precedes the encoded struct.
+	EncodeStructStart(structType reflect.Type, encoded []byte, out Writer) error
+	// EncodeStructEnd writes the trailer of the struct of the type, which follows the encoded struct.
+	EncodeStructEnd(structType reflect.Type, encoded []byte, out Writer) error
+	// DecodeStructStart checks the header of the struct of the type at the beginning of the data and returns
+	// its length and the length of the encoded struct following it, or -1 if the struct isn't delimited
+	// by the header, then it ends where its fields or its StructCloser end. A struct followed by a trailer
+	// in data split by the library itself must be delimited, otherwise the trailer is a part of its last field.
+	DecodeStructStart(structType reflect.Type, data []byte) (header, size int, err error)
+	// DecodeStructEnd checks the trailer of the decoded struct of the type at the beginning of the rest of the data
+	// and returns its length, the trailer is removed from the data.
+	DecodeStructEnd(structType reflect.Type, decoded, data []byte) (n int, err error)
+}
+
+// structHooks encodes the struct of the type with the function f and wraps it in the header and the trailer
+// written by the Tag, see TagStructHooks. The offsets of the fields reported to the spans function
+// are shifted past the header.
+func (s *encodeState[T]) structHooks(t reflect.Type, f func() error) error {
+	h, ok := any(s.Tag).(TagStructHooks)
+	if !ok {
+		return f()
+	}
+
+	// The encoded struct is moved past the header, see yield.
+	s.hold++
+	defer func() { s.hold-- }()
+
+	var inner []span
+	spans, start := s.spans, s.Len()
+	if spans != nil {
+		s.spans = func(path string, start, end int) {
+			inner = append(inner, span{path: path, start: start, end: end})
+		}
+	}
+	err := f()
+	if s.spans = spans; err != nil {
+		return err
+	}
+
+	encoded := append([]byte(nil), s.Bytes()[start:]...)
+	s.Truncate(start)
+	if err = h.EncodeStructStart(t, encoded, s.Buffer); err != nil {
+		return err
+	}
+	shift := s.Len() - start
+	s.Write(encoded)
+	if err = h.EncodeStructEnd(t, encoded, s.Buffer); err != nil {
+		return err
+	}
+
+	for _, sp := range inner {
+		spans(sp.path, sp.start+shift, sp.end+shift)
+	}
+	return nil
+}
+
+// structHooks removes the header of the struct of the type checked by the Tag, decodes the struct
+// with the function f and removes its trailer, see TagStructHooks.
+func (s *decodeState[T]) structHooks(t reflect.Type, f func() error) error {
+	h, ok := any(s.Tag).(TagStructHooks)
+	if !ok {
+		return f()
+	}
+
+	header, size, err := h.DecodeStructStart(t, s.data)
+	if err != nil {
+		return err
+	}
+	if header < 0 || header > len(s.data) || size > len(s.data)-header {
+		s.err = fmt.Errorf("%s: %w", s.Name(), ErrInvalidFormat)
+		return errExist
+	}
+	data := s.data[header:]
+
+	var rest []byte
+	if s.data = data; size >= 0 {
+		s.data, rest = data[:size], data[size:]
+	}
+	if err = f(); err != nil {
+		return err
+	}
+	if size >= 0 {
+		// The struct must take all of its data.
+		if len(s.data) != 0 {
+			s.err = fmt.Errorf("%s: %w", s.Name(), ErrInvalidFormat)
+			return errExist
+		}
+		s.data = rest
+	} else {
+		size = len(data) - len(s.data)
+	}
+
+	n, err := h.DecodeStructEnd(t, data[:size], s.data)
+	if err != nil {
+		return err
+	}
+	if n < 0 || n > len(s.data) {
+		s.err = fmt.Errorf("%s: %w", s.Name(), ErrInvalidFormat)
+		return errExist
+	}
+	s.data = s.data[n:]
+	return nil
+}
@@ -0,0 +1,73 @@
+package engine
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+// lengthTag writes the length of every struct followed by '|' before it and '#' after it, see TagStructHooks.
+type lengthTag[T any] struct {
+	testTag[T]
+}
+
+func (lengthTag[T]) EncodeStructStart(_ reflect.Type, encoded []byte, out Writer) error {
+	_, err := out.WriteString(strconv.Itoa(len(encoded)) + "|")
+	return err
+}
+
+func (lengthTag[T]) EncodeStructEnd(_ reflect.Type, _ []byte, out Writer) error {
+	return out.WriteByte('#')
+}
+
+func (lengthTag[T]) DecodeStructStart(_ reflect.Type, data []byte) (int, int, error) {
+	length, _, ok := bytes.Cut(data, []byte("|"))
+	if !ok {
+		return 0, 0, fmt.Errorf("no length in %q", data)
+	}
+	size, err := strconv.Atoi(string(length))
+	return len(length) + 1, size, err
+}
+
+func (lengthTag[T]) DecodeStructEnd(_ reflect.Type, decoded, data []byte) (int, error) {
+	if len(data) == 0 || data[0] != '#' {
+		return 0, fmt.Errorf("no trailer after %q", decoded)
+	}
+	return 1, nil
+}
+
+func TestTagStructHooks(t *testing.T) {
+	type base struct {
+		E int
+	}
+	type record struct {
+		base
+		P party
+		Z int
+	}
+	e := New[testMeta](lengthTag[testMeta]{}, testConfig())
+	value := record{base: base{E: 1}, P: party{ID: "i", Agency: 2}, Z: 3}
+
+	b, err := e.Marshal(value)
+	equal(t, nil, err)
+	// The embedded struct is a part of the struct embedding it.
+	equal(t, "11|1,4|i:2:#,3#", string(b))
+
+	var got record
+	equal(t, nil, e.Unmarshal(b, &got))
+	equal(t, value, got)
+
+	var tests = []string{
+		"11|1,4|i:2:,3##",
+		"10|1,4|i:2:#,3#",
+		"1,4|i:2:#,3#",
+	}
+	for _, data := range tests {
+		equal(t, true, e.Unmarshal([]byte(data), &got) != nil)
+	}
+	err = e.Unmarshal([]byte("99|1,4|i:2:#,3#"), &got)
+	equal(t, true, errors.Is(err, ErrInvalidFormat))
+}
@@ -0,0 +1,89 @@
+package engine
+
+import (
+	"bufio"
+	"io"
+)
+
+// An Index is the offsets of the values of a stream, it allows decoding the values in any order
+// without reading the stream again, see NewIndex.
+type Index struct {
+	e     streamer
+	spans []span
+}
+
+// NewIndex reads the stream once with the engine e and returns the index of its values for random access,
+// ErrUnsupported if e doesn't have the Index method.
+func NewIndex(e Engine, r io.Reader) (*Index, error) {
+	if i, ok := e.(indexer); ok {
+		return i.Index(r)
+	}
+	return nil, unsupported(e, "Index")
+}
+
+// indexer is implemented by the engines indexing streams, see NewIndex.
+type indexer interface {
+	Index(r io.Reader) (*Index, error)
+}
+
+// Index reads the stream once and returns the index of its values, framed like by a Decoder.
+// Empty values aren't indexed.
+func (e *engine[T]) Index(r io.Reader) (*Index, error) {
+	cr := &countingReader{r: bufio.NewReader(r)}
+	ix := &Index{e: e}
+	s := e.load()
+
+	for {
+		value, err := s.readValue(cr)
+
+		// The value ends before the RecordSeparator, if it isn't the last one.
+		end := cr.n
+		if err == nil && len(s.recordSeparator) != 0 {
+			end -= len(s.recordSeparator)
+		}
+		if len(value) != 0 {
+			ix.spans = append(ix.spans, span{start: end - len(value), end: end})
+		}
+
+		if err == io.EOF {
+			return ix, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Len returns the number of the values in the index.
+func (ix *Index) Len() int {
+	return len(ix.spans)
+}
+
+// Offset returns the offsets of the start and the end of the i-th value in the stream.
+func (ix *Index) Offset(i int) (start, end int64) {
+	return int64(ix.spans[i].start), int64(ix.spans[i].end)
+}
+
+// DecodeAt reads the i-th value from the indexed stream and stores it in the value pointed to by v.
+func (ix *Index) DecodeAt(ra io.ReaderAt, i int, v any) error {
+	sp := ix.spans[i]
+	data := make([]byte, sp.end-sp.start)
+	if n, err := ra.ReadAt(data, int64(sp.start)); n < len(data) {
+		return err
+	}
+	return ix.e.unmarshalWith(data, v, decodeOptions{noCopy: true})
+}
+
+// countingReader counts the bytes read from the stream.
+type countingReader struct {
+	r *bufio.Reader
+	n int
+}
+
+func (c *countingReader) ReadByte() (byte, error) {
+	b, err := c.r.ReadByte()
+	if err == nil {
+		c.n++
+	}
+	return b, err
+}
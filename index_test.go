@@ -0,0 +1,52 @@
+package engine
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestIndex(t *testing.T) {
+	type record struct{ A, B string }
+	e := newTestEngine(func(cfg *Config) { cfg.RecordSeparator = []byte("\r\n") })
+	data := "a,b\r\n\r\nc,d\r\ne,f"
+
+	ix, err := NewIndex(e, strings.NewReader(data))
+	equal(t, nil, err)
+	equal(t, 3, ix.Len())
+
+	var tests = []struct {
+		start, end int64
+		expect     record
+	}{
+		{
+			start:  0,
+			end:    3,
+			expect: record{"a", "b"},
+		},
+		{
+			start:  7,
+			end:    10,
+			expect: record{"c", "d"},
+		},
+		{
+			start:  12,
+			end:    15,
+			expect: record{"e", "f"},
+		},
+	}
+	// The values are decoded in any order.
+	r := strings.NewReader(data)
+	for i := len(tests) - 1; i >= 0; i-- {
+		start, end := ix.Offset(i)
+		equal(t, tests[i].start, start)
+		equal(t, tests[i].end, end)
+
+		var got record
+		equal(t, nil, ix.DecodeAt(r, i, &got))
+		equal(t, tests[i].expect, got)
+	}
+
+	_, err = NewIndex(foreignEngine{e}, strings.NewReader(data))
+	equal(t, true, errors.Is(err, ErrUnsupported))
+}
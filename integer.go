@@ -0,0 +1,131 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrIntegerWidth is returned for a value that doesn't fit the width of the integer declared by the tag
+// of its field, or doesn't fit the field, see IntegerWidther.
+var ErrIntegerWidth = errors.New("the value doesn't fit the integer width")
+
+// IntegerWidther is the interface implemented by a parsed tag, a *T of the engine Tag, of a field holding
+// an integer of a binary format whose width doesn't match a Go kind, e.g. the 24-bit, 48-bit or 128-bit
+// integers of network and storage formats. The value of the field is encoded into exactly width bytes
+// passed to Tag.Encode, and Tag.Decode must write exactly width bytes, which are checked to fit the field.
+// The field is of an integer kind, signed integers are two's complement, or a []byte or a [width]byte
+// holding the bytes of the integer in big-endian order, e.g. for 128-bit integers.
+type IntegerWidther interface {
+	// IntegerWidth returns the width of the integer in bytes and the order of its bytes.
+	IntegerWidth() (width int, littleEndian bool)
+}
+
+// integerCoders returns the coders of the integer of the width in bytes held by a field of the type t.
+func integerCoders[T any](t reflect.Type, width int, littleEndian bool) (encoderFunc[T], decoderFunc[T], error) {
+	switch {
+	case width <= 0:
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uintptr,
+		t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8,
+		t.Kind() == reflect.Array && t.Elem().Kind() == reflect.Uint8 && t.Len() == width:
+		return integerEncoder[T](width, littleEndian), integerDecoder[T](width, littleEndian), nil
+	}
+	return nil, nil, fmt.Errorf("%w: %d bytes into %s", ErrIntegerWidth, width, t)
+}
+
+func integerEncoder[T any](width int, littleEndian bool) encoderFunc[T] {
+	return func(s *encodeState[T], v reflect.Value) error {
+		p := make([]byte, width)
+
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			x := v.Int()
+			if width < 8 && (x < -1<<(8*width-1) || x >= 1<<(8*width-1)) {
+				return fmt.Errorf("%w: %d into %d bytes", ErrIntegerWidth, x, width)
+			}
+			for i := width - 1; i >= 0; i-- {
+				p[i], x = byte(x), x>>8
+			}
+		case reflect.Slice, reflect.Array:
+			if v.Len() != width {
+				return fmt.Errorf("%w: %d bytes into %d bytes", ErrIntegerWidth, v.Len(), width)
+			}
+			reflect.Copy(reflect.ValueOf(p), v)
+		default:
+			x := v.Uint()
+			if width < 8 && x >= 1<<(8*width) {
+				return fmt.Errorf("%w: %d into %d bytes", ErrIntegerWidth, x, width)
+			}
+			for i := width - 1; i >= 0; i-- {
+				p[i], x = byte(x), x>>8
+			}
+		}
+
+		if littleEndian {
+			reverse(p)
+		}
+		return s.encodeValue(p)
+	}
+}
+
+func integerDecoder[T any](width int, littleEndian bool) decoderFunc[T] {
+	return func(s *decodeState[T], v reflect.Value) error {
+		if s.Len() != width {
+			return fmt.Errorf("%w: %d bytes instead of %d", ErrIntegerWidth, s.Len(), width)
+		}
+		p := append([]byte(nil), s.Bytes()...)
+		if littleEndian {
+			reverse(p)
+		}
+
+		signed := v.Kind() >= reflect.Int && v.Kind() <= reflect.Int64
+		if width > 8 && v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			// The bytes beyond 64 bits may only extend the integer.
+			var fill byte
+			if signed && p[width-8]&0x80 != 0 {
+				fill = 0xff
+			}
+			for _, b := range p[:width-8] {
+				if b != fill {
+					return fmt.Errorf("%w: %d bytes into %s", ErrIntegerWidth, width, v.Type())
+				}
+			}
+			p = p[width-8:]
+		}
+
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			var x int64
+			if p[0]&0x80 != 0 {
+				x = -1
+			}
+			for _, b := range p {
+				x = x<<8 | int64(b)
+			}
+			if v.OverflowInt(x) {
+				return fmt.Errorf("%w: %d into %s", ErrIntegerWidth, x, v.Type())
+			}
+			v.SetInt(x)
+		case reflect.Slice:
+			v.SetBytes(p)
+		case reflect.Array:
+			reflect.Copy(v, reflect.ValueOf(p))
+		default:
+			var x uint64
+			for _, b := range p {
+				x = x<<8 | uint64(b)
+			}
+			if v.OverflowUint(x) {
+				return fmt.Errorf("%w: %d into %s", ErrIntegerWidth, x, v.Type())
+			}
+			v.SetUint(x)
+		}
+		return nil
+	}
+}
+
+func reverse(p []byte) {
+	for i, j := 0, len(p)-1; i < j; i, j = i+1, j-1 {
+		p[i], p[j] = p[j], p[i]
+	}
+}
@@ -0,0 +1,112 @@
+package engine
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// widthMeta is a parsed tag declaring the width of an integer, "l" before the width makes it little-endian,
+// see IntegerWidther.
+type widthMeta struct {
+	width        int
+	littleEndian bool
+}
+
+func (m *widthMeta) parse(tagValue string) (bool, error) {
+	v, ok := strings.CutPrefix(tagValue, "l")
+	m.littleEndian = ok
+	var err error
+	m.width, err = strconv.Atoi(v)
+	return false, err
+}
+
+func (m *widthMeta) IntegerWidth() (int, bool) {
+	return m.width, m.littleEndian
+}
+
+func TestIntegerWidther(t *testing.T) {
+	type int24 struct {
+		A int64 `test:"3"`
+	}
+	type int48 struct {
+		A int32 `test:"l6"`
+	}
+	type uint128 struct {
+		A uint64 `test:"16"`
+	}
+	type bytes128 struct {
+		A [16]byte `test:"16"`
+	}
+	// The binary values are decoded as a whole, they may hold the separators.
+	e := newEngineOf[widthMeta](func(cfg *Config) { cfg.ValueSeparator, cfg.ComponentSeparator = nil, nil })
+
+	var tests = []struct {
+		value any
+		data  string
+		into  func() any
+	}{
+		{
+			value: int24{A: -2},
+			data:  "\xff\xff\xfe",
+			into:  func() any { return new(int24) },
+		},
+		{
+			value: int24{A: 1<<23 - 1},
+			data:  "\x7f\xff\xff",
+			into:  func() any { return new(int24) },
+		},
+		{
+			value: int48{A: -300},
+			data:  "\xd4\xfe\xff\xff\xff\xff",
+			into:  func() any { return new(int48) },
+		},
+		{
+			value: uint128{A: 1<<64 - 1},
+			data:  "\x00\x00\x00\x00\x00\x00\x00\x00\xff\xff\xff\xff\xff\xff\xff\xff",
+			into:  func() any { return new(uint128) },
+		},
+		{
+			value: bytes128{A: [16]byte{1, 2}},
+			data:  "\x01\x02\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00",
+			into:  func() any { return new(bytes128) },
+		},
+	}
+	for _, tt := range tests {
+		b, err := e.Marshal(tt.value)
+		equal(t, nil, err)
+		equal(t, tt.data, string(b))
+
+		got := tt.into()
+		equal(t, nil, e.Unmarshal(b, got))
+		equal(t, tt.value, reflect.ValueOf(got).Elem().Interface())
+	}
+
+	// The values that don't fit the width or the field.
+	_, err := e.Marshal(int24{A: 1 << 23})
+	equal(t, true, errors.Is(err, ErrIntegerWidth))
+
+	var i24 int24
+	err = e.Unmarshal([]byte("\x00\x01"), &i24)
+	equal(t, true, errors.Is(err, ErrIntegerWidth))
+
+	var i128 struct {
+		A int64 `test:"16"`
+	}
+	err = e.Unmarshal([]byte("\x00\x00\x00\x00\x00\x00\x00\x01\x00\x00\x00\x00\x00\x00\x00\x00"), &i128)
+	equal(t, true, errors.Is(err, ErrIntegerWidth))
+	equal(t, nil, e.Unmarshal([]byte("\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xfe"), &i128))
+	equal(t, int64(-2), i128.A)
+
+	var i48 int48
+	err = e.Unmarshal([]byte("\x00\x00\x00\x00\x01\x00"), &i48)
+	equal(t, true, errors.Is(err, ErrIntegerWidth))
+
+	var str struct {
+		A string `test:"2"`
+	}
+	_, err = e.Marshal(str)
+	equal(t, true, errors.Is(err, ErrIntegerWidth))
+}
@@ -0,0 +1,122 @@
+package engine
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// FieldEncodeInterceptor is called to encode the value of every field of a struct instead of its coder,
+// which it calls with next, e.g. to trace the fields or to convert the units of the value before it is encoded.
+type FieldEncodeInterceptor func(field FieldInfo, v reflect.Value, next func(v reflect.Value) error) error
+
+// FieldDecodeInterceptor is called to decode the value of every field of a struct present in the data
+// instead of its coder, which it calls with next, e.g. to convert the units of the value after it is decoded.
+type FieldDecodeInterceptor func(field FieldInfo, v reflect.Value, next func(v reflect.Value) error) error
+
+// fieldHooks are the field interceptors of an engine, shared with its profiles and dialects.
+type fieldHooks struct {
+	mu    sync.Mutex   // serializes RegisterFieldInterceptor
+	chain atomic.Value // *interceptorChain, replaced as a whole
+}
+
+type interceptorChain struct {
+	encoders []FieldEncodeInterceptor
+	decoders []FieldDecodeInterceptor
+}
+
+// RegisterFieldInterceptor makes the engine e, its profiles and dialects pass the values of the fields
+// through the interceptors when encoding and decoding them, either of them may be nil.
+// The interceptors registered first are the outermost.
+// It returns ErrUnsupported if e doesn't have the RegisterFieldInterceptor method.
+func RegisterFieldInterceptor(e Engine, enc FieldEncodeInterceptor, dec FieldDecodeInterceptor) error {
+	r, ok := implementation[interceptorRegistry](e)
+	if !ok {
+		return unsupported(e, "RegisterFieldInterceptor")
+	}
+	r.RegisterFieldInterceptor(enc, dec)
+	return nil
+}
+
+// interceptorRegistry is implemented by the engines taking field interceptors, see RegisterFieldInterceptor.
+type interceptorRegistry interface {
+	RegisterFieldInterceptor(enc FieldEncodeInterceptor, dec FieldDecodeInterceptor)
+}
+
+// RegisterFieldInterceptor makes the engine pass the values of the fields through the interceptors,
+// see the function RegisterFieldInterceptor.
+func (e *engine[T]) RegisterFieldInterceptor(enc FieldEncodeInterceptor, dec FieldDecodeInterceptor) {
+	e.interceptors.mu.Lock()
+	defer e.interceptors.mu.Unlock()
+
+	var chain interceptorChain
+	if old := e.interceptors.load(); old != nil {
+		chain = *old
+	}
+	// The slices are shared with the values being encoded and decoded, they are never appended in place.
+	if enc != nil {
+		chain.encoders = append(chain.encoders[:len(chain.encoders):len(chain.encoders)], enc)
+	}
+	if dec != nil {
+		chain.decoders = append(chain.decoders[:len(chain.decoders):len(chain.decoders)], dec)
+	}
+	e.interceptors.chain.Store(&chain)
+}
+
+// load returns the current interceptors, nil if there are none.
+func (i *fieldHooks) load() *interceptorChain {
+	if i == nil {
+		return nil
+	}
+	chain, _ := i.chain.Load().(*interceptorChain)
+	return chain
+}
+
+// encodeField encodes the value of the current field with its encoder through the interceptors.
+func (s *encodeState[T]) encodeField(rv reflect.Value) error {
+	chain := s.interceptors.load()
+	if chain == nil || len(chain.encoders) == 0 {
+		return s.field.encoder(s, rv)
+	}
+
+	info, encoder := s.field.info(), s.field.encoder
+	next := func(v reflect.Value) error {
+		return encoder(s, v)
+	}
+	for i := len(chain.encoders) - 1; i >= 0; i-- {
+		f, inner := chain.encoders[i], next
+		next = func(v reflect.Value) error {
+			return f(info, v, inner)
+		}
+	}
+	return next(rv)
+}
+
+// decodeField decodes the value of the current field, the composite value in the data or the value written
+// by Tag.Decode otherwise, with its decoder through the interceptors.
+func (s *decodeState[T]) decodeField(rv reflect.Value, value []byte) error {
+	composite, decoder := s.field.composite && s.split, s.field.decoder
+
+	chain := s.interceptors.load()
+	if chain == nil || len(chain.decoders) == 0 {
+		if composite {
+			return s.decodeFrom(value, rv)
+		}
+		return decoder(s, rv)
+	}
+
+	info := s.field.info()
+	next := func(v reflect.Value) error {
+		if composite {
+			return s.decodeFrom(value, v)
+		}
+		return decoder(s, v)
+	}
+	for i := len(chain.decoders) - 1; i >= 0; i-- {
+		f, inner := chain.decoders[i], next
+		next = func(v reflect.Value) error {
+			return f(info, v, inner)
+		}
+	}
+	return next(rv)
+}
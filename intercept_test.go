@@ -0,0 +1,54 @@
+package engine
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestFieldInterceptors(t *testing.T) {
+	type record struct {
+		Name  string
+		Cents int
+		Party party
+	}
+	e := newTestEngine(nil)
+
+	var trace []string
+	tracer := func(prefix string) func(FieldInfo, reflect.Value, func(reflect.Value) error) error {
+		return func(field FieldInfo, v reflect.Value, next func(reflect.Value) error) error {
+			trace = append(trace, prefix+field.Name)
+			return next(v)
+		}
+	}
+	equal(t, nil, RegisterFieldInterceptor(e, tracer(">"), tracer("<")))
+	// The Cents are written as whole units and read back as cents.
+	equal(t, nil, RegisterFieldInterceptor(e, func(field FieldInfo, v reflect.Value, next func(reflect.Value) error) error {
+		if field.Name != "Cents" {
+			return next(v)
+		}
+		return next(reflect.ValueOf(v.Int() / 100))
+	}, func(field FieldInfo, v reflect.Value, next func(reflect.Value) error) error {
+		if err := next(v); err != nil || field.Name != "Cents" {
+			return err
+		}
+		v.SetInt(v.Int() * 100)
+		return nil
+	}))
+	equal(t, nil, RegisterFieldInterceptor(e, nil, nil))
+
+	value := record{Name: "a", Cents: 1200, Party: party{ID: "1", Agency: 2, Code: "c"}}
+	b, err := e.Marshal(value)
+	equal(t, nil, err)
+	equal(t, "a,12,1:2:c", string(b))
+	equal(t, []string{">Name", ">Cents", ">Party", ">ID", ">Agency", ">Code"}, trace)
+
+	trace = nil
+	var got record
+	equal(t, nil, e.Unmarshal(b, &got))
+	equal(t, value, got)
+	equal(t, []string{"<Name", "<Cents", "<Party", "<ID", "<Agency", "<Code"}, trace)
+
+	err = RegisterFieldInterceptor(foreignEngine{e}, tracer(">"), nil)
+	equal(t, true, errors.Is(err, ErrUnsupported))
+}
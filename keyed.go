@@ -0,0 +1,127 @@
+package engine
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// KeyedTag is the interface implemented by a Tag of a format whose values carry the keys of their fields,
+// e.g. "name=value". When decoding, the engine matches the values of a struct to its fields by their keys
+// instead of their positions, so the values may come in any order and some of them may be missing.
+// The key of a field is its name unless the parsed tag implements ColumnNamer or Renamer, and Aliaser
+// gives it other keys. The keys are case-sensitive unless Config.CaseInsensitiveKeys is set.
+// The values matching no field go to the Extras field of the struct keyed by their keys, or are reported
+// if Config.DisallowUnknownFields is set. Otherwise, they are ignored.
+// It takes effect when the library splits the data itself, see Config.ComponentSeparator.
+// The Tag still writes the keys itself when encoding.
+type KeyedTag interface {
+	// CutKey cuts the key off the encoded value and returns the rest of the value that is decoded into the field.
+	// If the value has no key, ok is false.
+	CutKey(value []byte) (key string, rest []byte, ok bool)
+}
+
+// Aliaser is the interface implemented by a parsed tag, a *T of the engine Tag,
+// that gives its field other keys accepted in keyed data besides its own one, see KeyedTag,
+// e.g. the former names of the field, so that the data written before it was renamed still decodes.
+// The keys of the fields take precedence over the aliases.
+type Aliaser interface {
+	// Aliases returns the other keys of the field.
+	Aliases() []string
+}
+
+// aliases appends the aliases of the fields, the fields of embedded structs included, to dst
+// in the order of their names, see names.
+func (f structFields[T]) aliases(dst [][]string) [][]string {
+	for _, fld := range f {
+		switch {
+		case fld.extras:
+		case fld.embedded != nil:
+			dst = fld.embedded.aliases(dst)
+		default:
+			dst = append(dst, fld.aliases)
+		}
+	}
+	return dst
+}
+
+// keyed reorders the values of the struct in the data to the order of its fields,
+// so that they are decoded as positional ones. The missing values are left empty.
+func (f *structFields[T]) keyed(s *decodeState[T], kt KeyedTag, v reflect.Value, unwrap bool) error {
+	names := f.names(nil, HeaderOptions{})
+	index := make(map[string]int, len(names))
+	var folded map[string]int // the keys in lower case, see Config.CaseInsensitiveKeys
+	if s.foldKeys {
+		folded = make(map[string]int, len(names))
+	}
+	add := func(key string, i int) {
+		if _, ok := index[key]; !ok {
+			index[key] = i
+		}
+		if folded == nil {
+			return
+		}
+		if _, ok := folded[strings.ToLower(key)]; !ok {
+			folded[strings.ToLower(key)] = i
+		}
+	}
+	// The names take precedence over the aliases.
+	for i, name := range names {
+		add(name, i)
+	}
+	for i, aliases := range f.aliases(nil) {
+		for _, alias := range aliases {
+			add(alias, i)
+		}
+	}
+
+	fld := f.extras()
+	separator := s.separator(s.depth)
+
+	values := make([][]byte, len(names))
+	var extras Extras
+	for len(s.data) != 0 && !(unwrap && bytes.HasPrefix(s.data, s.structCloser)) {
+		value := s.cut(separator)
+		key, rest, ok := kt.CutKey(value)
+		i, found := index[key]
+		if !found && folded != nil {
+			i, found = folded[strings.ToLower(key)]
+		}
+		if ok && found {
+			values[i] = rest
+			continue
+		}
+
+		switch {
+		case fld != nil:
+			if extras == nil {
+				extras = make(Extras)
+			}
+			extras[key] = append([]byte(nil), value...)
+		case s.disallowUnknown:
+			if !ok {
+				key = strings.TrimSpace(string(value))
+			}
+			s.err = fmt.Errorf("%s: %w: key %q of struct %s", s.Name(), ErrUnknownField, key, v.Type().Name())
+			return errExist
+		}
+	}
+
+	if fld != nil {
+		v.Field(fld.index).Set(reflect.ValueOf(extras))
+	}
+
+	// The values go before whatever follows the struct, e.g. its closer.
+	// Their offsets in the input are kept for the spans, see decodeState.offset.
+	var r rebuilt
+	for i, value := range values {
+		if i != 0 {
+			r.data = append(r.data, separator...)
+		}
+		r.add(s.offset(value), value)
+	}
+	r.add(s.offset(s.data), s.data)
+	s.data, s.rebuilt = r.data, append(s.rebuilt, r)
+	return nil
+}
@@ -0,0 +1,199 @@
+package engine
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestKeyedTag(t *testing.T) {
+	type record struct {
+		A string
+		B int
+		C string
+	}
+
+	var tests = []struct {
+		data     string
+		disallow bool
+		expect   record
+		err      error
+	}{
+		{
+			data:   "C=c,B=2,A=a",
+			expect: record{A: "a", B: 2, C: "c"},
+		},
+		{
+			// The missing values are left empty and the unknown ones are ignored.
+			data:   "x=1,C=c,nokey",
+			expect: record{C: "c"},
+		},
+		{
+			data:     "C=c,x=1",
+			disallow: true,
+			err:      ErrUnknownField,
+		},
+	}
+	for _, tt := range tests {
+		e := newKeyEngine[testMeta](func(cfg *Config) { cfg.DisallowUnknownFields = tt.disallow })
+		var got record
+		err := e.Unmarshal([]byte(tt.data), &got)
+		equal(t, true, errors.Is(err, tt.err))
+		equal(t, tt.expect, got)
+	}
+}
+
+func TestKeyedExtras(t *testing.T) {
+	type record struct {
+		A      string
+		B      int
+		Extras Extras
+	}
+	e := newKeyEngine[testMeta](nil)
+
+	var got record
+	equal(t, nil, e.Unmarshal([]byte("x=1,A=a"), &got))
+	equal(t, record{A: "a", Extras: Extras{"x": []byte("x=1")}}, got)
+
+	b, err := e.Marshal(got)
+	equal(t, nil, err)
+	equal(t, "A=a,B=0,x=1", string(b))
+}
+
+func TestKeyedSpans(t *testing.T) {
+	type inner struct{ X, Y string }
+	type record struct {
+		A string
+		B int
+		I inner
+	}
+	e := newKeyEngine[testMeta](nil)
+
+	type span struct {
+		path       string
+		start, end int
+	}
+	var got []span
+	var r record
+	equal(t, nil, UnmarshalSpans(e, []byte("B=2,A=hello,I=Y=y:X=x"), &r, func(path string, start, end int) {
+		got = append(got, span{path, start, end})
+	}))
+	// The values are reported at their offsets in the data, not in the order of the fields.
+	equal(t, []span{{"A", 6, 11}, {"B", 2, 3}, {"I", 14, 21}, {"I.X", 20, 21}, {"I.Y", 16, 17}}, got)
+}
+
+func TestKeyedEdit(t *testing.T) {
+	type record struct {
+		A string
+		B int
+	}
+	e := newKeyEngine[testMeta](nil)
+
+	var tests = []struct {
+		data   string
+		expect string
+	}{
+		{
+			data:   "A= hello,B=02",
+			expect: "A=x,B=02",
+		},
+		{
+			// The values are reordered, the value is encoded as a whole.
+			data:   "B=02,A= hello",
+			expect: "A=x,B=2",
+		},
+	}
+	for _, tt := range tests {
+		var r record
+		ed, err := NewEdit(e, []byte(tt.data), &r)
+		equal(t, nil, err)
+		r.A = "x"
+		b, err := ed.Encode()
+		equal(t, nil, err)
+		equal(t, tt.expect, string(b))
+	}
+}
+
+// aliasMeta gives its field the other keys listed in the tag, see Aliaser.
+type aliasMeta struct {
+	aliases []string
+}
+
+func (m *aliasMeta) parse(tagValue string) (bool, error) {
+	if tagValue != "" {
+		m.aliases = strings.Split(tagValue, "|")
+	}
+	return false, nil
+}
+
+func (m *aliasMeta) Aliases() []string {
+	return m.aliases
+}
+
+func TestAliaser(t *testing.T) {
+	type record struct {
+		FullName string `test:"name|fn|Age"`
+		Age      int
+	}
+	e := newKeyEngine[aliasMeta](nil)
+
+	var tests = []struct {
+		data   string
+		expect record
+	}{
+		{
+			data:   "FullName=a,Age=1",
+			expect: record{FullName: "a", Age: 1},
+		},
+		{
+			data:   "fn=a,name=b",
+			expect: record{FullName: "b"},
+		},
+		{
+			// The keys of the fields take precedence over the aliases.
+			data:   "Age=2",
+			expect: record{Age: 2},
+		},
+	}
+	for _, tt := range tests {
+		var got record
+		equal(t, nil, e.Unmarshal([]byte(tt.data), &got))
+		equal(t, tt.expect, got)
+	}
+
+	// The fields are encoded under their keys.
+	b, err := e.Marshal(record{FullName: "a", Age: 1})
+	equal(t, nil, err)
+	equal(t, "FullName=a,Age=1", string(b))
+}
+
+func TestCaseInsensitiveKeys(t *testing.T) {
+	type record struct {
+		Name string
+		NAME string
+		Age  int
+	}
+
+	var tests = []struct {
+		fold   bool
+		data   string
+		expect record
+	}{
+		{
+			data:   "name=x,NAME=y,AGE=3",
+			expect: record{NAME: "y"},
+		},
+		{
+			// The exact matches take precedence.
+			fold:   true,
+			data:   "name=x,NAME=y,AGE=3",
+			expect: record{Name: "x", NAME: "y", Age: 3},
+		},
+	}
+	for _, tt := range tests {
+		e := newKeyEngine[testMeta](func(cfg *Config) { cfg.CaseInsensitiveKeys = tt.fold })
+		var got record
+		equal(t, nil, e.Unmarshal([]byte(tt.data), &got))
+		equal(t, tt.expect, got)
+	}
+}
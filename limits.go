@@ -0,0 +1,29 @@
+package engine
+
+import "fmt"
+
+// checkValueSize returns ErrLimit if the value of the current field is larger than Config.MaxValueSize.
+func (s *decodeState[T]) checkValueSize() error {
+	if s.maxValueSize > 0 && s.Len() > s.maxValueSize {
+		return valueLimitError(s.maxValueSize)
+	}
+	return nil
+}
+
+// checkDecodedSize adds the size of the value of the current field to the total size of the values decoded
+// and returns ErrLimit if it is larger than Config.MaxDecodedSize.
+func (s *decodeState[T]) checkDecodedSize() error {
+	if s.decoded += s.Len(); s.maxDecodedSize > 0 && s.decoded > s.maxDecodedSize {
+		return fmt.Errorf("%w: more than %d bytes decoded", ErrLimit, s.maxDecodedSize)
+	}
+	return nil
+}
+
+// checkElements returns ErrLimit if the number of the elements of a slice or the entries of a map
+// is larger than Config.MaxElements.
+func (s *decodeState[T]) checkElements(n int) error {
+	if s.maxElements > 0 && n > s.maxElements {
+		return fmt.Errorf("%w: more than %d elements", ErrLimit, s.maxElements)
+	}
+	return nil
+}
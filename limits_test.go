@@ -0,0 +1,91 @@
+package engine
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestDecodeLimits(t *testing.T) {
+	type record struct {
+		A     string
+		Ints  []int
+		Items []item
+		M     map[string]int
+	}
+	limited := func(cfg *Config) {
+		listConfig(cfg)
+		cfg.ElementOpener, cfg.ElementCloser = []byte("<"), []byte(">")
+		cfg.MapOpener, cfg.MapCloser = []byte("{"), []byte("}")
+		cfg.KeyValueSeparator, cfg.EntrySeparator = []byte("="), []byte("&")
+		cfg.MaxValueSize, cfg.MaxElements, cfg.MaxDecodedSize = 10, 2, 24
+	}
+	e := newTestEngine(limited)
+
+	var got record
+	equal(t, nil, e.Unmarshal([]byte("abc,(1~2),(<1:2>~<3:4>),{a=1&b=2}"), &got))
+	equal(t, record{A: "abc", Ints: []int{1, 2}, Items: []item{{1, 2}, {3, 4}}, M: map[string]int{"a": 1, "b": 2}}, got)
+
+	var tests = []string{
+		"abcdefghijk,(),(),{}",
+		"a,(1~2~3),(),{}",
+		"a,(),(<1:2>~<3:4>~<5:6>),{}",
+		"a,(),(),{a=1&b=2&c=3}",
+		"abcdefgh,(1~2),(<11:22>),{a=1&b=2}",
+	}
+	for _, data := range tests {
+		err := e.Unmarshal([]byte(data), &got)
+		equal(t, true, errors.Is(err, ErrLimit))
+	}
+
+	// The count of the elements is checked before they are decoded.
+	e = newTestEngine(func(cfg *Config) {
+		limited(cfg)
+		cfg.CountElements = true
+	})
+	err := e.Unmarshal([]byte("a,(),(1000000000~<1:2>),{}"), &got)
+	equal(t, true, errors.Is(err, ErrLimit))
+}
+
+func TestRecordLimit(t *testing.T) {
+	e := newTestEngine(func(cfg *Config) {
+		cfg.RecordSeparator = []byte("\r\n")
+		cfg.MaxRecordSize = 5
+	})
+
+	// The record longer than the limit is skipped, so that the next one can be decoded.
+	dec := NewDecoder(e, strings.NewReader("aa,11\r\naaaaaa,1\r\nb,2\r\n"+strings.Repeat("c", 6)))
+	var got streamed
+	equal(t, nil, dec.Decode(&got))
+	equal(t, streamed{A: "aa", B: 11}, got)
+	err := dec.Decode(&got)
+	equal(t, true, errors.Is(err, ErrLimit))
+	equal(t, nil, dec.Decode(&got))
+	equal(t, streamed{A: "b", B: 2}, got)
+	err = dec.Decode(&got)
+	equal(t, true, errors.Is(err, ErrLimit))
+	equal(t, io.EOF, dec.Decode(&got))
+
+	// The wrapped values are limited as well.
+	e = newTestEngine(func(cfg *Config) {
+		cfg.StructOpener, cfg.StructCloser, cfg.UnwrapWhenDecoding = []byte("("), []byte(")"), true
+		cfg.MaxRecordSize = 5
+	})
+	dec = NewDecoder(e, strings.NewReader("(aaaaaa,1) (b,2)"))
+	err = dec.Decode(&got)
+	equal(t, true, errors.Is(err, ErrLimit))
+	equal(t, nil, dec.Decode(&got))
+	equal(t, streamed{A: "b", B: 2}, got)
+
+	// The record isn't buffered to be rejected.
+	s := &settings{recordSeparator: []byte("\n"), maxRecordSize: 8}
+	data := append(bytes.Repeat([]byte("x"), 1<<20), '\n')
+	allocs := testing.AllocsPerRun(10, func() {
+		if _, err := s.readValue(bytes.NewReader(data)); !errors.Is(err, ErrLimit) {
+			t.Fatal(err)
+		}
+	})
+	equal(t, true, allocs < 10)
+}
@@ -0,0 +1,201 @@
+package engine
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Positioner is the interface implemented by a parsed tag, a *T of the engine Tag, of a field of a fixed-width
+// format that declares where the value of the field is in the record. It is only used by Lint to find the fields
+// whose values overlap, the Tag itself places the values.
+type Positioner interface {
+	// Position returns the offset of the value of the field in the record and its width in bytes.
+	Position() (offset, width int)
+}
+
+// LintWarning is a likely mistake in the declaration of a type found by Lint.
+type LintWarning struct {
+	// Type is the type the mistake is found in, the struct declaring the field if Field is set.
+	Type reflect.Type
+	// Field is the path of the field in the struct, the names of the embedded structs it is declared in
+	// and its own name separated by dots, empty for a mistake of the type itself.
+	Field string
+	// Message describes the mistake.
+	Message string
+}
+
+// String returns the warning as "Type.Field: Message".
+func (w LintWarning) String() string {
+	if w.Field == "" {
+		return fmt.Sprintf("%s: %s", w.Type, w.Message)
+	}
+	return fmt.Sprintf("%s.%s: %s", w.Type, w.Field, w.Message)
+}
+
+// Lint walks the type t and the types of its fields like Compile and returns the warnings about the common mistakes
+// in their declarations for the engine e, so that tooling can fail CI on them:
+//   - a tag of the engine on an unexported field, which is ignored;
+//   - a tag that fails to parse;
+//   - omitempty on a field whose values are never empty, e.g. a struct without the IsZero method;
+//   - the Unmarshaler implemented on a value receiver, so that the decoded value is lost;
+//   - only one of the Marshaller and the Unmarshaler implemented by a type;
+//   - fields of a struct sharing a key or, if their parsed tags implement Positioner, overlapping.
+//
+// It returns ErrUnsupported if e doesn't have the Lint method.
+func Lint(e Engine, t reflect.Type) ([]LintWarning, error) {
+	l, ok := implementation[interface {
+		Lint(t reflect.Type) []LintWarning
+	}](e)
+	if !ok {
+		return nil, unsupported(e, "Lint")
+	}
+	return l.Lint(t), nil
+}
+
+// Lint returns the warnings about the declarations of the type t and the types of its fields, see the function Lint.
+func (e *engine[T]) Lint(t reflect.Type) []LintWarning {
+	l := &linter[T]{engine: e, settings: e.load(), seen: make(map[reflect.Type]bool)}
+	l.lint(t)
+	return l.warnings
+}
+
+// linter collects the warnings of the types it walks.
+type linter[T any] struct {
+	*engine[T]
+	*settings
+	seen     map[reflect.Type]bool
+	warnings []LintWarning
+}
+
+func (l *linter[T]) warn(t reflect.Type, field, format string, args ...any) {
+	l.warnings = append(l.warnings, LintWarning{Type: t, Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (l *linter[T]) lint(t reflect.Type) {
+	if l.seen[t] {
+		return
+	}
+	l.seen[t] = true
+
+	if l.lintMethods(t) || l.isCustom(t) {
+		return
+	}
+
+	t = containerOf(t)
+
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		l.lint(t.Elem())
+	case reflect.Struct:
+		l.lintStruct(t)
+	}
+}
+
+// lintMethods checks the receivers of the Marshaller and the Unmarshaler of the type. It reports whether
+// the type implements either of them, then its values are encoded and decoded as a whole.
+func (l *linter[T]) lintMethods(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer || t.Kind() == reflect.Interface {
+		return false
+	}
+
+	p := reflect.PointerTo(t)
+	marshaller, unmarshaler := p.Implements(l.marshaller), p.Implements(l.unmarshaler)
+	switch {
+	case marshaller && !unmarshaler:
+		l.warn(t, "", "implements %s but not %s, it is decoded by the library", l.marshaller, l.unmarshaler)
+	case unmarshaler && !marshaller:
+		l.warn(t, "", "implements %s but not %s, it is encoded by the library", l.unmarshaler, l.marshaller)
+	}
+	// A map is changed through a copy of it.
+	if unmarshaler && t.Implements(l.unmarshaler) && t.Kind() != reflect.Map {
+		l.warn(t, "", "implements %s on a value receiver, the decoded value is lost", l.unmarshaler)
+	}
+	return marshaller || unmarshaler
+}
+
+func (l *linter[T]) lintStruct(t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.IsExported() || sf.Anonymous {
+			continue
+		}
+		if _, ok := l.lookupTag(t.Name(), sf.Name, sf.Tag); ok {
+			l.warn(t, sf.Name, "the tag %s of an unexported field is ignored", l.Name())
+		}
+	}
+
+	var (
+		keys      = make(map[string]string)
+		positions []position
+	)
+	l.lintFields(t, "", l.cachedFields(t), keys, &positions)
+
+	sort.SliceStable(positions, func(i, j int) bool {
+		return positions[i].offset < positions[j].offset
+	})
+	for i := 1; i < len(positions); i++ {
+		prev, cur := positions[i-1], positions[i]
+		if cur.offset < prev.offset+prev.width {
+			l.warn(t, cur.path, "the value at %d overlaps the value of %s at %d of %d bytes",
+				cur.offset, prev.path, prev.offset, prev.width)
+		}
+	}
+}
+
+// position is the place of the value of a field in a fixed-width record, see Positioner.
+type position struct {
+	path          string
+	offset, width int
+}
+
+// lintFields checks the fields of the struct t, the fields of its embedded structs are prefixed with the path.
+func (l *linter[T]) lintFields(t reflect.Type, path string, fields structFields[T], keys map[string]string, positions *[]position) {
+	for _, fld := range fields {
+		name := fld.name
+		if path != "" {
+			name = path + "." + name
+		}
+
+		switch {
+		case fld.extras:
+			continue
+		case fld.embedded != nil:
+			l.lintFields(t, name, fld.embedded, keys, positions)
+			continue
+		case fld.err != nil:
+			l.warn(t, name, "%v", fld.err)
+			continue
+		}
+
+		if other, ok := keys[fld.key]; ok {
+			l.warn(t, name, "the key %q is the key of %s as well", fld.key, other)
+		} else {
+			keys[fld.key] = name
+		}
+		if p, ok := any(fld.meta).(Positioner); ok {
+			offset, width := p.Position()
+			*positions = append(*positions, position{path: name, offset: offset, width: width})
+		}
+		if fld.omitEmpty && l.emptiness == nil && !canBeEmpty(fld.typ) {
+			l.warn(t, name, "omitempty has no effect, the values of %s are never empty", fld.typ)
+		}
+
+		if fld.delegate == nil {
+			l.lint(fld.typ)
+		}
+	}
+}
+
+// canBeEmpty reports whether a value of the type may be empty for omitempty, see settings.isEmpty.
+func canBeEmpty(t reflect.Type) bool {
+	switch {
+	case containerOf(t) != t, t.Implements(zeroerType), reflect.PointerTo(t).Implements(zeroerType):
+		return true
+	case t.Kind() == reflect.Struct:
+		return false
+	case t.Kind() == reflect.Array:
+		return t.Len() == 0
+	}
+	return true
+}
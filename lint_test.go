@@ -0,0 +1,92 @@
+package engine
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// valueCode implements the Unmarshaler on a value receiver.
+type valueCode string
+
+func (c valueCode) MarshalTest() ([]byte, error) {
+	return []byte(c), nil
+}
+
+func (c valueCode) UnmarshalTest([]byte) error {
+	return nil
+}
+
+// encodedOnly implements the Marshaller only.
+type encodedOnly struct{}
+
+func (encodedOnly) MarshalTest() ([]byte, error) {
+	return nil, nil
+}
+
+// positionMeta is a parsed tag "offset:width" declaring the position of its field, see Positioner.
+type positionMeta struct {
+	offset, width int
+}
+
+func (m *positionMeta) parse(tagValue string) (bool, error) {
+	offset, width, _ := strings.Cut(tagValue, ":")
+	var err error
+	if m.offset, err = strconv.Atoi(offset); err != nil {
+		return false, err
+	}
+	m.width, err = strconv.Atoi(width)
+	return false, err
+}
+
+func (m *positionMeta) Position() (int, int) {
+	return m.offset, m.width
+}
+
+func TestLint(t *testing.T) {
+	type inner struct {
+		Code valueCode
+		Raw  encodedOnly
+	}
+	type record struct {
+		hidden string `test:"a"`
+		In     inner  `test:"omitempty"`
+		party
+	}
+	e := newTestEngine(nil)
+
+	warnings, err := Lint(e, reflect.TypeOf(&record{}))
+	equal(t, nil, err)
+
+	var got []string
+	for _, w := range warnings {
+		got = append(got, w.String())
+	}
+	equal(t, []string{
+		"engine.record.hidden: the tag test of an unexported field is ignored",
+		"engine.record.In: omitempty has no effect, the values of engine.inner are never empty",
+		"engine.valueCode: implements engine.testUnmarshaler on a value receiver, the decoded value is lost",
+		"engine.encodedOnly: implements engine.testMarshaller but not engine.testUnmarshaler, it is decoded by the library",
+	}, got)
+
+	type duplicate struct {
+		A int `test:"x"`
+		B int `test:"x"`
+	}
+	warnings, _ = Lint(newEngineOf[nameMeta](nil), reflect.TypeOf(duplicate{}))
+	equal(t, 1, len(warnings))
+	equal(t, "B", warnings[0].Field)
+
+	type fixed struct {
+		A string `test:"0:4"`
+		B string `test:"4:2"`
+		C string `test:"5:3"`
+	}
+	warnings, _ = Lint(newEngineOf[positionMeta](nil), reflect.TypeOf(fixed{}))
+	equal(t, []LintWarning{{Type: reflect.TypeOf(fixed{}), Field: "C", Message: "the value at 5 overlaps the value of B at 4 of 2 bytes"}}, warnings)
+
+	_, err = Lint(foreignEngine{e}, reflect.TypeOf(record{}))
+	equal(t, true, errors.Is(err, ErrUnsupported))
+}
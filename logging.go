@@ -0,0 +1,44 @@
+package engine
+
+import (
+	gocontext "context"
+	"log/slog"
+)
+
+// LogValue returns the slog.LogValuer that encodes the value v with the engine only when it is logged,
+// e.g. slog.Debug("sent", "message", engine.LogValue(e, msg)). If encoding fails, the error is logged instead.
+func LogValue(e Engine, v any) slog.LogValuer {
+	return logValue{e: e, v: v}
+}
+
+type logValue struct {
+	e Engine
+	v any
+}
+
+func (lv logValue) LogValue() slog.Value {
+	data, err := lv.e.Marshal(lv.v)
+	if err != nil {
+		return slog.GroupValue(slog.Any("error", err))
+	}
+	return slog.StringValue(string(data))
+}
+
+// logError logs the failure to decode the value of the current field, see Config.Logger.
+func (s *decodeState[T]) logError(err error) {
+	level := slog.LevelError
+	if s.logLevel != nil {
+		level = s.logLevel.Level()
+	}
+
+	ctx := gocontext.Background()
+	if !s.logger.Enabled(ctx, level) {
+		return
+	}
+	s.logger.LogAttrs(ctx, level, "cannot decode data",
+		slog.String("engine", s.Name()),
+		slog.String("struct", s.structName),
+		slog.String("field", s.fieldPath()),
+		slog.Any("error", err),
+	)
+}
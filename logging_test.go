@@ -0,0 +1,62 @@
+package engine
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestLogger(t *testing.T) {
+	type record struct {
+		A string
+		B int
+		P party
+	}
+
+	var tests = []struct {
+		all    bool
+		level  slog.Leveler
+		expect []string
+	}{
+		{
+			expect: []string{
+				`level=ERROR msg="cannot decode data" engine=test struct=record field=B error=`,
+			},
+		},
+		{
+			all:   true,
+			level: slog.LevelWarn,
+			expect: []string{
+				`level=WARN msg="cannot decode data" engine=test struct=record field=B error=`,
+				`level=WARN msg="cannot decode data" engine=test struct=party field=P.Agency error=`,
+			},
+		},
+		{
+			// The records below the level of the handler aren't logged.
+			level: slog.LevelDebug,
+		},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		e := newTestEngine(func(cfg *Config) {
+			cfg.AllErrorsWhenDecoding = tt.all
+			cfg.Logger, cfg.LogLevel = slog.New(slog.NewTextHandler(&buf, nil)), tt.level
+		})
+		equal(t, true, e.Unmarshal([]byte("a,x,1:y:c"), &record{}) != nil)
+
+		var lines []string
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			if _, rest, ok := strings.Cut(line, " "); ok {
+				lines = append(lines, rest[:strings.Index(rest, "error=")+len("error=")])
+			}
+		}
+		equal(t, tt.expect, lines)
+	}
+}
+
+func TestLogValue(t *testing.T) {
+	e := newTestEngine(nil)
+	equal(t, "1,2,c", LogValue(e, party{ID: "1", Agency: 2, Code: "c"}).LogValue().String())
+	equal(t, slog.KindGroup, LogValue(e, make(chan int)).LogValue().Kind())
+}
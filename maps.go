@@ -0,0 +1,126 @@
+package engine
+
+import (
+	"reflect"
+	"sort"
+)
+
+// KeyOrder is the order the entries of a map are encoded in.
+type KeyOrder int
+
+const (
+	// KeysSorted encodes the entries sorted by keys, it's the default.
+	KeysSorted KeyOrder = iota
+	// KeysInserted encodes the entries in the order they were inserted in. Only an OrderedMap keeps it,
+	// other maps are sorted.
+	KeysInserted
+	// KeysUnordered encodes the entries in the order of the map iteration, which is unspecified.
+	KeysUnordered
+)
+
+// KeyOrderer is the interface implemented by a parsed tag, a *T of the engine Tag,
+// that chooses the order the entries of its map field, and of the maps nested in it, are encoded in.
+type KeyOrderer interface {
+	// KeyOrder returns the order of the entries of the map.
+	KeyOrder() KeyOrder
+}
+
+// sortKeys sorts the keys of a map of string or integer kinds.
+func sortKeys(keys []reflect.Value) {
+	sort.Slice(keys, func(i, j int) bool {
+		switch keys[i].Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return keys[i].Int() < keys[j].Int()
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			return keys[i].Uint() < keys[j].Uint()
+		default:
+			return keys[i].String() < keys[j].String()
+		}
+	})
+}
+
+// OrderedMap is a map that keeps the order its keys were inserted in. The engine decodes the entries
+// into it in the order of the data and encodes them in the same order, whatever the KeyOrder of the field is.
+// The zero value is an empty map ready to use.
+type OrderedMap[K comparable, V any] struct {
+	keys   []K
+	values map[K]V
+}
+
+// Set sets the value of the key, a new key is appended to the keys.
+func (m *OrderedMap[K, V]) Set(key K, value V) {
+	if m.values == nil {
+		m.values = make(map[K]V)
+	}
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+// Get returns the value of the key and reports whether the key is in the map.
+func (m *OrderedMap[K, V]) Get(key K) (V, bool) {
+	v, ok := m.values[key]
+	return v, ok
+}
+
+// Delete removes the key from the map.
+func (m *OrderedMap[K, V]) Delete(key K) {
+	if _, ok := m.values[key]; !ok {
+		return
+	}
+	delete(m.values, key)
+	for i, k := range m.keys {
+		if k == key {
+			m.keys = append(m.keys[:i], m.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+// Keys returns the keys in the order they were inserted in.
+func (m *OrderedMap[K, V]) Keys() []K {
+	return append([]K(nil), m.keys...)
+}
+
+// Len returns the number of the entries of the map.
+func (m *OrderedMap[K, V]) Len() int {
+	return len(m.keys)
+}
+
+// orderedMap is implemented by an OrderedMap for the engine.
+type orderedMap interface {
+	// mapType returns the type of the map holding the values, it mustn't use the receiver.
+	mapType() reflect.Type
+	// entries returns the keys in order and the map holding the values.
+	entries() ([]reflect.Value, reflect.Value)
+	// setEntry sets the value of the key.
+	setEntry(key, value reflect.Value)
+	Len() int
+}
+
+var orderedMapType = reflect.TypeOf((*orderedMap)(nil)).Elem()
+
+func (m *OrderedMap[K, V]) mapType() reflect.Type {
+	return reflect.TypeOf(map[K]V(nil))
+}
+
+func (m *OrderedMap[K, V]) entries() ([]reflect.Value, reflect.Value) {
+	keys := make([]reflect.Value, len(m.keys))
+	for i := range m.keys {
+		keys[i] = reflect.ValueOf(&m.keys[i]).Elem()
+	}
+	return keys, reflect.ValueOf(m.values)
+}
+
+func (m *OrderedMap[K, V]) setEntry(key, value reflect.Value) {
+	m.Set(key.Interface().(K), value.Interface().(V))
+}
+
+// orderedMapOf returns the type of the map holding the values of an OrderedMap of the type t.
+func orderedMapOf(t reflect.Type) (reflect.Type, bool) {
+	if t.Kind() != reflect.Struct || !reflect.PointerTo(t).Implements(orderedMapType) {
+		return nil, false
+	}
+	return reflect.Zero(reflect.PointerTo(t)).Interface().(orderedMap).mapType(), true
+}
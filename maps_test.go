@@ -0,0 +1,154 @@
+package engine
+
+import (
+	"testing"
+)
+
+// mapConfig is listConfig separating the keys from the values with "=" and the entries with "&".
+func mapConfig(cfg *Config) {
+	listConfig(cfg)
+	cfg.KeyValueSeparator, cfg.EntrySeparator = []byte("="), []byte("&")
+}
+
+func TestMaps(t *testing.T) {
+	type record struct {
+		A map[string]string
+		B map[int][]int
+		E string
+	}
+	e := newTestEngine(mapConfig)
+
+	var tests = []struct {
+		value record
+		data  string
+	}{
+		{
+			value: record{A: map[string]string{"z": "1", "a&": "x=y"}, B: map[int][]int{10: {1, 2}, 2: {3}}, E: "e"},
+			data:  `a\&=x\=y&z=1,2=(3)&10=(1~2),e`,
+		},
+		{
+			value: record{E: "e"},
+			data:  ",,e",
+		},
+	}
+	for _, tt := range tests {
+		b, err := e.Marshal(tt.value)
+		equal(t, nil, err)
+		equal(t, tt.data, string(b))
+
+		var got record
+		equal(t, nil, e.Unmarshal(b, &got))
+		equal(t, tt.value, got)
+	}
+
+	// The entries are ordered by their keys.
+	b, err := e.Marshal(map[string]int{"q": 1, "p": 2})
+	equal(t, nil, err)
+	equal(t, "p=2&q=1", string(b))
+
+	var got map[string]int
+	equal(t, nil, e.Unmarshal([]byte("q=1&p=2"), &got))
+	equal(t, map[string]int{"q": 1, "p": 2}, got)
+}
+
+func TestNestedMaps(t *testing.T) {
+	type record struct {
+		P map[string]party
+		N map[string]map[string]int
+		L []map[string]int
+		E string
+	}
+	e := newTestEngine(func(cfg *Config) {
+		mapConfig(cfg)
+		cfg.MapOpener, cfg.MapCloser = []byte("{"), []byte("}")
+	})
+
+	value := record{
+		P: map[string]party{"b": {ID: "1", Agency: 2}, "a": {Code: "c"}},
+		N: map[string]map[string]int{"x": {"k": 1, "j": 2}, "y": {}},
+		L: []map[string]int{{"a": 1}, {"b": 2, "c": 3}},
+		E: "e",
+	}
+	b, err := e.Marshal(value)
+	equal(t, nil, err)
+	equal(t, "{a=:0:c&b=1:2:},{x={j=2&k=1}&y={}},({a=1}~{b=2&c=3}),e", string(b))
+
+	var got record
+	equal(t, nil, e.Unmarshal(b, &got))
+	equal(t, value, got)
+}
+
+func TestOrderedMap(t *testing.T) {
+	var m OrderedMap[string, int]
+	m.Set("z", 1)
+	m.Set("a", 2)
+	m.Set("m", 3)
+	m.Set("z", 4)
+	m.Delete("a")
+	m.Delete("x")
+	equal(t, []string{"z", "m"}, m.Keys())
+	equal(t, 2, m.Len())
+	v, ok := m.Get("z")
+	equal(t, 4, v)
+	equal(t, true, ok)
+	_, ok = m.Get("a")
+	equal(t, false, ok)
+
+	type record struct {
+		O OrderedMap[string, int]
+		E string
+	}
+	e := newTestEngine(mapConfig)
+
+	b, err := e.Marshal(record{O: m, E: "e"})
+	equal(t, nil, err)
+	equal(t, "z=4&m=3,e", string(b))
+
+	// The entries are decoded in the order of the data.
+	var got record
+	equal(t, nil, e.Unmarshal([]byte("q=1&p=2&r=3,e"), &got))
+	equal(t, []string{"q", "p", "r"}, got.O.Keys())
+	v, _ = got.O.Get("p")
+	equal(t, 2, v)
+}
+
+// keyOrderMeta orders the entries of its map field in the order of the value of the tag, see KeyOrderer.
+type keyOrderMeta struct {
+	order KeyOrder
+}
+
+func (m *keyOrderMeta) parse(tagValue string) (bool, error) {
+	if tagValue == "inserted" {
+		m.order = KeysInserted
+	}
+	return false, nil
+}
+
+func (m *keyOrderMeta) KeyOrder() KeyOrder {
+	return m.order
+}
+
+func TestKeyOrderer(t *testing.T) {
+	type record struct {
+		M map[string]int          `test:"inserted"`
+		O OrderedMap[string, int] `test:"inserted"`
+		S OrderedMap[string, []int]
+	}
+	e := newEngineOf[keyOrderMeta](mapConfig)
+
+	var value record
+	value.M = map[string]int{"z": 1, "a": 2}
+	value.O.Set("z", 1)
+	value.O.Set("a", 2)
+	value.S.Set("z", []int{1})
+	value.S.Set("a", []int{2, 3})
+
+	// Only an OrderedMap keeps the order of its keys, whatever the KeyOrder is.
+	b, err := e.Marshal(value)
+	equal(t, nil, err)
+	equal(t, "a=2&z=1,z=1&a=2,z=(1)&a=(2~3)", string(b))
+
+	var got record
+	equal(t, nil, e.Unmarshal(b, &got))
+	equal(t, value, got)
+}
@@ -0,0 +1,320 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrMiddleware is returned by the methods of an engine with a middleware that can't pass the data
+// through the middleware, see Wrap.
+var ErrMiddleware = errors.New("the method can't pass the data through the middleware")
+
+// Middleware transforms the values and the encoded data around an inner engine, e.g. for logging, metrics,
+// compression or encryption, so that such concerns needn't be baked into a Tag, see Wrap and Chain.
+// Any of its functions may be nil. The after functions are called only if the engine succeeds.
+type Middleware struct {
+	// BeforeMarshal is called with the value to encode and returns the value passed to the inner engine.
+	BeforeMarshal func(v any) (any, error)
+	// AfterMarshal transforms the data encoded by the inner engine, e.g. compresses it.
+	AfterMarshal func(data []byte) ([]byte, error)
+	// BeforeUnmarshal transforms the data before the inner engine decodes it, e.g. decompresses it.
+	BeforeUnmarshal func(data []byte) ([]byte, error)
+	// AfterUnmarshal is called with the pointer to the value decoded by the inner engine.
+	AfterUnmarshal func(v any) error
+}
+
+// Chain composes the middlewares into one, the first one is the outermost: it sees the values and the data
+// of the caller, while the last one sees the values and the data of the inner engine.
+func Chain(middlewares ...Middleware) Middleware {
+	var m Middleware
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		m = middlewares[i].around(m)
+	}
+	return m
+}
+
+// around returns the middleware with the inner one between it and the engine.
+func (m Middleware) around(inner Middleware) Middleware {
+	return Middleware{
+		BeforeMarshal:   thenValue(m.BeforeMarshal, inner.BeforeMarshal),
+		AfterMarshal:    thenData(inner.AfterMarshal, m.AfterMarshal),
+		BeforeUnmarshal: thenData(m.BeforeUnmarshal, inner.BeforeUnmarshal),
+		AfterUnmarshal:  thenPointer(inner.AfterUnmarshal, m.AfterUnmarshal),
+	}
+}
+
+// empty reports whether none of the functions of the middleware is set.
+func (m Middleware) empty() bool {
+	return m.BeforeMarshal == nil && m.AfterMarshal == nil && m.BeforeUnmarshal == nil && m.AfterUnmarshal == nil
+}
+
+func thenValue(first, second func(v any) (any, error)) func(v any) (any, error) {
+	if first == nil || second == nil {
+		if first == nil {
+			return second
+		}
+		return first
+	}
+	return func(v any) (any, error) {
+		v, err := first(v)
+		if err != nil {
+			return nil, err
+		}
+		return second(v)
+	}
+}
+
+func thenData(first, second func(data []byte) ([]byte, error)) func(data []byte) ([]byte, error) {
+	if first == nil || second == nil {
+		if first == nil {
+			return second
+		}
+		return first
+	}
+	return func(data []byte) ([]byte, error) {
+		data, err := first(data)
+		if err != nil {
+			return nil, err
+		}
+		return second(data)
+	}
+}
+
+func thenPointer(first, second func(v any) error) func(v any) error {
+	if first == nil || second == nil {
+		if first == nil {
+			return second
+		}
+		return first
+	}
+	return func(v any) error {
+		if err := first(v); err != nil {
+			return err
+		}
+		return second(v)
+	}
+}
+
+// Wrap returns the engine that passes the values and the data through the middlewares, see Chain,
+// when they are encoded with Marshal, MarshalAppend, MarshalIndent and MarshalAll and decoded with Unmarshal,
+// UnmarshalNoCopy, UnmarshalSpans, Validate and Valid. The streams of its Encoder, EncodePipeline, Decoder
+// and Index pass every record through the middlewares, so the data the middleware makes mustn't hold
+// the RecordSeparator unless the records are framed, see Framing. Its profiles, dialects and sniffed engines
+// are wrapped as well. NewEdit and Compact fail with ErrMiddleware unless the middleware is empty. The engine
+// has the Unwrap method returning the inner engine, the other functions taking an Engine, e.g. NameOf, Compile
+// and Dispatch, use the inner engine.
+func Wrap(inner Engine, middlewares ...Middleware) Engine {
+	return &wrapped{inner: inner, m: Chain(middlewares...)}
+}
+
+// wrapped is an engine with a middleware, see Wrap. It has only the methods passing the data through
+// the middleware, so that none of the methods of the inner engine bypasses it.
+type wrapped struct {
+	inner Engine
+	m     Middleware
+}
+
+// Unwrap returns the inner engine.
+func (w *wrapped) Unwrap() Engine {
+	return w.inner
+}
+
+func (w *wrapped) Marshal(v any) ([]byte, error) {
+	return w.MarshalAppend(nil, v)
+}
+
+func (w *wrapped) MarshalAppend(dst []byte, v any) ([]byte, error) {
+	return w.marshal(dst, v, w.inner.Marshal)
+}
+
+func (w *wrapped) MarshalIndent(v any, prefix, indent string) ([]byte, error) {
+	return w.marshal(nil, v, func(v any) ([]byte, error) {
+		return MarshalIndent(w.inner, v, prefix, indent)
+	})
+}
+
+func (w *wrapped) MarshalAll(values any) ([]byte, error) {
+	return w.marshal(nil, values, func(values any) ([]byte, error) {
+		return MarshalAll(w.inner, values)
+	})
+}
+
+// marshal encodes the value with the marshal function of the inner engine and appends the data to dst.
+func (w *wrapped) marshal(dst []byte, v any, marshal func(v any) ([]byte, error)) ([]byte, error) {
+	var err error
+	if w.m.BeforeMarshal != nil {
+		if v, err = w.m.BeforeMarshal(v); err != nil {
+			return dst, err
+		}
+	}
+	data, err := marshal(v)
+	if err != nil {
+		return dst, err
+	}
+	if w.m.AfterMarshal != nil {
+		if data, err = w.m.AfterMarshal(data); err != nil {
+			return dst, err
+		}
+	}
+	return append(dst, data...), nil
+}
+
+func (w *wrapped) Unmarshal(data []byte, v any) error {
+	return w.unmarshal(data, v, w.inner.Unmarshal)
+}
+
+func (w *wrapped) UnmarshalNoCopy(data []byte, v any) error {
+	return w.unmarshal(data, v, func(data []byte, v any) error {
+		return UnmarshalNoCopy(w.inner, data, v)
+	})
+}
+
+// UnmarshalSpans reports the offsets of the values in the data passed to the inner engine.
+func (w *wrapped) UnmarshalSpans(data []byte, v any, spans func(path string, start, end int)) error {
+	return w.unmarshal(data, v, func(data []byte, v any) error {
+		return UnmarshalSpans(w.inner, data, v, spans)
+	})
+}
+
+// Edit can't keep the bytes of the unchanged fields of the data the middleware transforms.
+func (w *wrapped) Edit(data []byte, v any) (*Edit, error) {
+	if !w.m.empty() {
+		return nil, fmt.Errorf("%s: %w: Edit", NameOf(w), ErrMiddleware)
+	}
+	return NewEdit(w.inner, data, v)
+}
+
+func (w *wrapped) Valid(data []byte) bool {
+	if w.m.BeforeUnmarshal != nil {
+		var err error
+		if data, err = w.m.BeforeUnmarshal(data); err != nil {
+			return false
+		}
+	}
+	return Valid(w.inner, data)
+}
+
+// Compact can't remove the whitespace of the data the middleware transforms.
+func (w *wrapped) Compact(dst, data []byte) ([]byte, error) {
+	if !w.m.empty() {
+		return dst, fmt.Errorf("%s: %w: Compact", NameOf(w), ErrMiddleware)
+	}
+	return Compact(w.inner, dst, data)
+}
+
+func (w *wrapped) Validate(data []byte, prototype any) (err error) {
+	if w.m.BeforeUnmarshal != nil {
+		if data, err = w.m.BeforeUnmarshal(data); err != nil {
+			return err
+		}
+	}
+	return Validate(w.inner, data, prototype)
+}
+
+// unmarshal decodes the data with the unmarshal function of the inner engine.
+func (w *wrapped) unmarshal(data []byte, v any, unmarshal func(data []byte, v any) error) (err error) {
+	if w.m.BeforeUnmarshal != nil {
+		if data, err = w.m.BeforeUnmarshal(data); err != nil {
+			return err
+		}
+	}
+	if err = unmarshal(data, v); err != nil {
+		return err
+	}
+	if w.m.AfterUnmarshal != nil {
+		return w.m.AfterUnmarshal(v)
+	}
+	return nil
+}
+
+// rewrap returns the engine derived from the inner engine, e.g. its profile, with the middleware.
+func (w *wrapped) rewrap(e Engine) Engine {
+	return &wrapped{inner: e, m: w.m}
+}
+
+func (w *wrapped) NewEncoder(out io.Writer) *Encoder {
+	enc := NewEncoder(w.inner, out)
+	if enc.err == nil {
+		enc.e = w.streamer(enc.e)
+	}
+	return enc
+}
+
+func (w *wrapped) NewEncodePipeline(out io.Writer, workers int) *EncodePipeline {
+	p := NewEncodePipeline(w.inner, out, workers)
+	if p.err == nil {
+		p.e = w.streamer(p.e)
+	}
+	return p
+}
+
+func (w *wrapped) NewDecoder(r io.Reader) *Decoder {
+	dec := NewDecoder(w.inner, r)
+	if dec.err == nil {
+		dec.e = w.streamer(dec.e)
+		dec.base = dec.e
+	}
+	return dec
+}
+
+func (w *wrapped) Index(r io.Reader) (*Index, error) {
+	ix, err := NewIndex(w.inner, r)
+	if err != nil {
+		return nil, err
+	}
+	ix.e = w.streamer(ix.e)
+	return ix, nil
+}
+
+// streamer returns the streamer of the inner engine passing the records through the middleware.
+func (w *wrapped) streamer(inner streamer) streamer {
+	if w.m.empty() {
+		return inner
+	}
+	return &wrappedStreamer{streamer: inner, w: w}
+}
+
+// wrappedStreamer is the streamer of an engine with a middleware, see Wrap.
+type wrappedStreamer struct {
+	streamer
+	w *wrapped
+}
+
+func (s *wrappedStreamer) Marshal(v any) ([]byte, error) {
+	return s.w.marshal(nil, v, s.streamer.Marshal)
+}
+
+func (s *wrappedStreamer) encodeTo(out io.Writer, v any) error {
+	if s.w.m.AfterMarshal == nil {
+		var err error
+		if s.w.m.BeforeMarshal != nil {
+			if v, err = s.w.m.BeforeMarshal(v); err != nil {
+				return err
+			}
+		}
+		return s.streamer.encodeTo(out, v)
+	}
+
+	data, err := s.Marshal(v)
+	if err != nil {
+		return err
+	}
+	cfg, _ := ConfigOf(s.w)
+	_, err = out.Write(append(data, cfg.RecordSeparator...))
+	return err
+}
+
+func (s *wrappedStreamer) unmarshalWith(data []byte, v any, opts decodeOptions) error {
+	return s.w.unmarshal(data, v, func(data []byte, v any) error {
+		return s.streamer.unmarshalWith(data, v, opts)
+	})
+}
+
+func (s *wrappedStreamer) sniffer(data []byte, candidates [][]byte) (streamer, error) {
+	inner, err := s.streamer.sniffer(data, candidates)
+	if err != nil {
+		return nil, err
+	}
+	return &wrappedStreamer{streamer: inner, w: s.w}, nil
+}
@@ -0,0 +1,204 @@
+package engine
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestChain(t *testing.T) {
+	var calls []string
+	layer := func(name string) Middleware {
+		return Middleware{
+			BeforeMarshal: func(v any) (any, error) {
+				calls = append(calls, name+".BeforeMarshal")
+				return v, nil
+			},
+			AfterMarshal: func(data []byte) ([]byte, error) {
+				calls = append(calls, name+".AfterMarshal")
+				return append(data, name...), nil
+			},
+			BeforeUnmarshal: func(data []byte) ([]byte, error) {
+				calls = append(calls, name+".BeforeUnmarshal")
+				return data[:len(data)-len(name)], nil
+			},
+			AfterUnmarshal: func(v any) error {
+				calls = append(calls, name+".AfterUnmarshal")
+				return nil
+			},
+		}
+	}
+	m := Chain(layer("a"), layer("b"))
+
+	data, _ := m.AfterMarshal([]byte("x"))
+	equal(t, "xba", string(data))
+	data, _ = m.BeforeUnmarshal(data)
+	equal(t, "x", string(data))
+	_, _ = m.BeforeMarshal(nil)
+	_ = m.AfterUnmarshal(nil)
+	equal(t, []string{
+		"b.AfterMarshal", "a.AfterMarshal",
+		"a.BeforeUnmarshal", "b.BeforeUnmarshal",
+		"a.BeforeMarshal", "b.BeforeMarshal",
+		"b.AfterUnmarshal", "a.AfterUnmarshal",
+	}, calls)
+}
+
+// upper is a middleware encoding the data in upper case and counting the decoded values.
+func upper(decoded *int) Middleware {
+	return Middleware{
+		AfterMarshal: func(data []byte) ([]byte, error) {
+			return bytes.ToUpper(data), nil
+		},
+		BeforeUnmarshal: func(data []byte) ([]byte, error) {
+			return bytes.ToLower(data), nil
+		},
+		AfterUnmarshal: func(any) error {
+			*decoded++
+			return nil
+		},
+	}
+}
+
+func TestWrapStreams(t *testing.T) {
+	var decoded int
+	e := Wrap(newTestEngine(func(cfg *Config) { cfg.RecordSeparator = []byte("\n") }), upper(&decoded))
+	values := []streamed{{"a", 1}, {"b", 2}}
+
+	var tests = []struct {
+		framing Framing
+		expect  string
+	}{
+		{
+			expect: "A,1\nB,2\n",
+		},
+		{
+			framing: LengthPrefix,
+			expect:  "\x00\x00\x00\x03A,1\x00\x00\x00\x03B,2",
+		},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		enc := NewEncoder(e, &buf)
+		enc.UseFraming(tt.framing)
+		for _, v := range values {
+			equal(t, nil, enc.Encode(v))
+		}
+		equal(t, tt.expect, buf.String())
+
+		decoded = 0
+		dec := NewDecoder(e, &buf)
+		dec.UseFraming(tt.framing)
+		got, err := decodeAll[streamed](dec)
+		equal(t, io.EOF, err)
+		equal(t, values, got)
+		equal(t, 2, decoded)
+	}
+
+	// The pipeline encodes like the encoder.
+	var buf bytes.Buffer
+	ch := make(chan any)
+	go func() {
+		defer close(ch)
+		for _, v := range values {
+			ch <- v
+		}
+	}()
+	equal(t, nil, NewEncodePipeline(e, &buf, 2).Run(ch))
+	equal(t, "A,1\nB,2\n", buf.String())
+
+	// The indexed values are decoded through the middleware.
+	data := "A,1\nB,2\n"
+	ix, err := NewIndex(e, strings.NewReader(data))
+	equal(t, nil, err)
+	var v streamed
+	decoded = 0
+	equal(t, nil, ix.DecodeAt(strings.NewReader(data), 1, &v))
+	equal(t, values[1], v)
+	equal(t, 1, decoded)
+}
+
+func TestWrapSpans(t *testing.T) {
+	var decoded int
+	inner := newTestEngine(nil)
+	e := Wrap(inner, upper(&decoded))
+
+	var paths []string
+	var v streamed
+	err := UnmarshalSpans(e, []byte("A,1"), &v, func(path string, start, end int) {
+		paths = append(paths, path)
+	})
+	equal(t, nil, err)
+	equal(t, streamed{"a", 1}, v)
+	equal(t, []string{"A", "B"}, paths)
+	equal(t, 1, decoded)
+
+	// The bytes of the fields of the transformed data can't be kept.
+	_, err = NewEdit(e, []byte("A,1"), &v)
+	equal(t, true, errors.Is(err, ErrMiddleware))
+
+	ed, err := NewEdit(Wrap(inner), []byte("a,1"), &v)
+	equal(t, nil, err)
+	v.B = 2
+	data, err := ed.Encode()
+	equal(t, nil, err)
+	equal(t, "a,2", string(data))
+}
+
+func TestWrapEngines(t *testing.T) {
+	var decoded int
+	inner := newTestEngine(func(cfg *Config) {
+		cfg.Profiles = map[string]Config{"pipe": {ValueSeparator: []byte("|"), ComponentSeparator: []byte(":")}}
+	})
+	e := Wrap(inner, upper(&decoded))
+
+	// The functions without the data use the inner engine.
+	equal(t, NameOf(inner), NameOf(e))
+	cfg, ok := ConfigOf(e)
+	equal(t, true, ok)
+	equal(t, ",", string(cfg.ValueSeparator))
+	_, err := Compile(e, reflect.TypeOf(streamed{}))
+	equal(t, nil, err)
+
+	// The indented output passes through the middleware.
+	b, err := MarshalIndent(e, streamed{"a", 1}, "", "\t")
+	equal(t, nil, err)
+	equal(t, "A,\n\t1", string(b))
+
+	// The derived engines keep the middleware.
+	pipe, ok := Profile(e, "pipe")
+	equal(t, true, ok)
+	dialect, err := WithDialect(e, Dialect{Name: "d"})
+	equal(t, nil, err)
+	sniffed, err := Sniff(e, []byte("A;1"), []byte(";"))
+	equal(t, nil, err)
+
+	var tests = []struct {
+		e      Engine
+		expect string
+	}{
+		{e: pipe, expect: "A|1"},
+		{e: dialect, expect: "A,1"},
+		{e: sniffed, expect: "A;1"},
+	}
+	for _, tt := range tests {
+		b, err := tt.e.Marshal(streamed{"a", 1})
+		equal(t, nil, err)
+		equal(t, tt.expect, string(b))
+
+		decoded = 0
+		var got streamed
+		equal(t, nil, tt.e.Unmarshal(b, &got))
+		equal(t, streamed{"a", 1}, got)
+		equal(t, 1, decoded)
+	}
+
+	// The engine wrapping a foreign engine has none of its optional methods.
+	_, ok = Profile(Wrap(foreignEngine{inner}), "pipe")
+	equal(t, false, ok)
+	err = NewDecoder(Wrap(foreignEngine{inner}), strings.NewReader("a,1")).Decode(&streamed{})
+	equal(t, true, errors.Is(err, ErrUnsupported))
+}
@@ -0,0 +1,35 @@
+// Code generated by format-engine. DO NOT EDIT.
+
+package mimetag
+
+import "reflect"
+
+// Marshaller is the interface implemented by types that can marshal themselves into valid MIMETAG.
+type Marshaller interface {
+	MarshalMIMETAG() ([]byte, error)
+}
+
+// IsMarshaller attempts to cast the value to MIMETAG Marshaller interface,
+// if so, returns a marshal function.
+func (e engineTag) IsMarshaller(rv reflect.Value) (func() ([]byte, error), bool) {
+	if i, ok := rv.Interface().(Marshaller); ok {
+		return i.MarshalMIMETAG, ok
+	}
+
+	return nil, false
+}
+
+// Unmarshaler is the interface implemented by types that can unmarshal MIMETAG description of themselves.
+type Unmarshaler interface {
+	UnmarshalMIMETAG([]byte) error
+}
+
+// IsUnmarshaler attempts to cast the value to MIMETAG Unmarshaler interface,
+// if so, returns an unmarshal function.
+func (e engineTag) IsUnmarshaler(rv reflect.Value) (func([]byte) error, bool) {
+	if i, ok := rv.Interface().(Unmarshaler); ok {
+		return i.UnmarshalMIMETAG, ok
+	}
+
+	return nil, false
+}
@@ -0,0 +1,76 @@
+// Package mimetag implements encoding and decoding of RFC 822 style header blocks,
+// "Header-Name: value" lines with folding, as used by email and HTTP-like protocols.
+//
+// Struct fields are bound to headers with tags like `mime:"Content-Type"` or `mime:"Subject,omitempty"`,
+// an untagged field is bound to the header named as the field. Header names are compared case-insensitively
+// when decoding, and decoding stops at the first empty line, the end of a header block.
+package mimetag
+
+import (
+	"bytes"
+	"errors"
+)
+
+// MaxLineLength is the length in characters, excluding the line break, after which header lines are folded.
+const MaxLineLength = 78
+
+var ErrInvalidValue = errors.New("header value contains a line break")
+
+var crlf = []byte("\r\n")
+
+// Marshal encodes the value v and returns header lines.
+func Marshal(v any) ([]byte, error) {
+	return mime.Marshal(v)
+}
+
+// Unmarshal unfolds the header lines of data and stores the result in the value pointed to by v.
+func Unmarshal(data []byte, v any) error {
+	return mime.Unmarshal(Unfold(data), v)
+}
+
+// Fold breaks a header line before whitespace so that no line is longer than MaxLineLength
+// where possible. A line without suitable whitespace is left as is.
+func Fold(line []byte) []byte {
+	if len(line) <= MaxLineLength {
+		return line
+	}
+
+	out := make([]byte, 0, len(line)+len(line)/MaxLineLength*2)
+	for len(line) > MaxLineLength {
+		// Break before the last whitespace that fits, the whitespace starts the continuation line.
+		i := bytes.LastIndexAny(line[1:MaxLineLength+1], " \t") + 1
+		if i == 0 {
+			if i = bytes.IndexAny(line[1:], " \t") + 1; i == 0 {
+				break
+			}
+		}
+		out = append(append(out, line[:i]...), crlf...)
+		line = line[i:]
+	}
+	return append(out, line...)
+}
+
+// Unfold joins folded lines, removing every line break followed by a space or a horizontal tab.
+func Unfold(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	for len(data) != 0 {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			return append(out, data...)
+		}
+
+		end := i
+		if end > 0 && data[end-1] == '\r' {
+			end--
+		}
+		if i+1 < len(data) && (data[i+1] == ' ' || data[i+1] == '\t') {
+			out = append(out, data[:end]...)
+			data = data[i+1:]
+			continue
+		}
+
+		out = append(out, data[:i+1]...)
+		data = data[i+1:]
+	}
+	return out
+}
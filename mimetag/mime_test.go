@@ -0,0 +1,126 @@
+package mimetag
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func equal(t *testing.T, exp, got interface{}) {
+	if !reflect.DeepEqual(exp, got) {
+		t.Fatalf("Not equal:\nexp: %v\ngot: %v", exp, got)
+	}
+}
+
+func TestFold(t *testing.T) {
+	long := "Subject: " + strings.Repeat("word ", 20) + "end"
+
+	var tests = []struct {
+		line   string
+		expect string
+	}{
+		{
+			line:   "Subject: short",
+			expect: "Subject: short",
+		},
+		{
+			line:   long,
+			expect: long[:78] + "\r\n" + long[78:],
+		},
+		{
+			// A line without whitespace can't be folded.
+			line:   "X: " + strings.Repeat("a", 90),
+			expect: "X:\r\n " + strings.Repeat("a", 90),
+		},
+		{
+			line:   strings.Repeat("a", 90),
+			expect: strings.Repeat("a", 90),
+		},
+	}
+	for _, tt := range tests {
+		got := Fold([]byte(tt.line))
+		equal(t, tt.expect, string(got))
+		equal(t, tt.line, string(Unfold(got)))
+	}
+}
+
+func TestUnfold(t *testing.T) {
+	var tests = []struct {
+		data   string
+		expect string
+	}{
+		{
+			data:   "Subject: a\r\n b\r\nFrom: x\r\n",
+			expect: "Subject: a b\r\nFrom: x\r\n",
+		},
+		{
+			data:   "Subject: a\n\tb",
+			expect: "Subject: a\tb",
+		},
+	}
+	for _, tt := range tests {
+		equal(t, tt.expect, string(Unfold([]byte(tt.data))))
+	}
+}
+
+type header struct {
+	ContentType string `mime:"Content-Type"`
+	Subject     string `mime:"Subject,omitempty"`
+	From        string
+}
+
+func TestMarshal(t *testing.T) {
+	var tests = []struct {
+		value  header
+		expect string
+		err    error
+	}{
+		{
+			value:  header{ContentType: "text/plain", From: "a@b"},
+			expect: "Content-Type: text/plain\r\nFrom: a@b\r\n",
+		},
+		{
+			value:  header{ContentType: "text/plain", Subject: "hi", From: "a@b"},
+			expect: "Content-Type: text/plain\r\nSubject: hi\r\nFrom: a@b\r\n",
+		},
+		{
+			value: header{From: "a\r\nBcc: x"},
+			err:   ErrInvalidValue,
+		},
+	}
+	for _, tt := range tests {
+		b, err := Marshal(tt.value)
+		equal(t, true, errors.Is(err, tt.err))
+		if tt.err == nil {
+			equal(t, tt.expect, string(b))
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	var tests = []header{
+		{ContentType: "text/plain", From: "a@b"},
+		{ContentType: "text/plain", Subject: strings.Repeat("word ", 30) + "end", From: "a@b"},
+	}
+	for _, tt := range tests {
+		b, err := Marshal(tt)
+		equal(t, nil, err)
+		for _, line := range bytes.Split(b, crlf) {
+			equal(t, true, len(line) <= MaxLineLength)
+		}
+
+		var got header
+		equal(t, nil, Unmarshal(b, &got))
+		equal(t, tt, got)
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	// The names are case-insensitive and the block ends at the first empty line.
+	data := "content-type:  text/html \r\nsubject: a\r\n b\r\n\r\nFrom: body\r\n"
+	var got header
+	equal(t, nil, Unmarshal([]byte(data), &got))
+	equal(t, header{ContentType: "text/html", Subject: "a b"}, got)
+}
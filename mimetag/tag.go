@@ -0,0 +1,93 @@
+package mimetag
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/gromey/format-engine"
+)
+
+var (
+	cfg = engine.Config{
+		// WARNING: DO NOT DELETE CONFIGURATIONS BELOW!
+		Marshaller:  reflect.TypeOf((*Marshaller)(nil)).Elem(),
+		Unmarshaler: reflect.TypeOf((*Unmarshaler)(nil)).Elem(),
+	}
+	mime = engine.New[tag](&engineTag{name: "mime"}, cfg)
+)
+
+type engineTag struct {
+	name string
+	engine.Default[tag]
+}
+
+type tag struct {
+	name string
+}
+
+// Rename returns the name of the header, the engine passes it to Encode and Decode instead of the field name.
+func (t *tag) Rename() string {
+	return t.name
+}
+
+// Name returns the name of the tag.
+func (e engineTag) Name() string {
+	return e.name
+}
+
+// Skip returns a flag indicating that the field should be ignored.
+func (e engineTag) Skip(tagValue string) bool {
+	return tagValue == "-"
+}
+
+// Parse gets a tagValue string in the form "Header-Name[,omitempty]", parses the tagValue into tag *tag,
+// returns a flag indicating that the field is skipped if it's empty.
+func (e engineTag) Parse(tagValue string, tag *tag) (omit bool, err error) {
+	var opts string
+	tag.name, opts, _ = strings.Cut(tagValue, ",")
+	return opts == "omitempty", nil
+}
+
+// Encode writes the value as a folded "Header-Name: value" line.
+func (e engineTag) Encode(fieldName string, tag *tag, in []byte, out engine.Writer) (err error) {
+	if bytes.ContainsAny(in, "\r\n") {
+		return fmt.Errorf("%s: %w", fieldName, ErrInvalidValue)
+	}
+
+	line := make([]byte, 0, len(fieldName)+len(in)+2)
+	line = append(append(append(line, fieldName...), ": "...), in...)
+
+	if _, err = out.Write(Fold(line)); err != nil {
+		return
+	}
+	_, err = out.Write(crlf)
+	return
+}
+
+// Decode finds the header in the unfolded data, comparing names case-insensitively,
+// and writes its value without surrounding whitespace.
+func (e engineTag) Decode(fieldName string, tag *tag, in []byte, out engine.Writer) (err error) {
+	for len(in) != 0 {
+		line := in
+		if i := bytes.IndexByte(in, '\n'); i >= 0 {
+			line, in = in[:i], in[i+1:]
+		} else {
+			in = nil
+		}
+
+		line = bytes.TrimRight(line, "\r")
+		if len(line) == 0 {
+			// An empty line ends the header block.
+			return
+		}
+
+		if key, value, ok := bytes.Cut(line, []byte(":")); ok && strings.EqualFold(string(bytes.TrimSpace(key)), fieldName) {
+			_, err = out.Write(bytes.TrimSpace(value))
+			return
+		}
+	}
+
+	return
+}
@@ -0,0 +1,76 @@
+package engine
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Renamer is the interface implemented by a parsed tag, a *T of the engine Tag,
+// that renames its field: the name is passed to Tag.Encode and Tag.Decode instead of the name of the Go field,
+// and it names the column and the key of the field as well, see ColumnNamer and KeyedTag.
+type Renamer interface {
+	// Rename returns the name of the field, the empty string keeps the name of the Go field.
+	Rename() string
+}
+
+// mapName returns the name of the field passed to the Tag unless it is renamed, see Config.FieldNameMapper.
+func (e *engine[T]) mapName(fieldName string) string {
+	if e.nameMapper == nil {
+		return fieldName
+	}
+	return e.nameMapper(fieldName)
+}
+
+// SnakeCase is a Config.FieldNameMapper that maps "UserID" to "user_id".
+func SnakeCase(fieldName string) string {
+	return strings.Join(words(fieldName), "_")
+}
+
+// KebabCase is a Config.FieldNameMapper that maps "UserID" to "user-id".
+func KebabCase(fieldName string) string {
+	return strings.Join(words(fieldName), "-")
+}
+
+// LowerCamelCase is a Config.FieldNameMapper that maps "UserID" to "userID" and "HTTPServer" to "httpServer".
+func LowerCamelCase(fieldName string) string {
+	r := []rune(fieldName)
+	// The leading initialism is lowered but its last letter if it begins the next word.
+	n := 0
+	for n < len(r) && unicode.IsUpper(r[n]) {
+		n++
+	}
+	if n > 1 && n < len(r) && unicode.IsLower(r[n]) {
+		n--
+	}
+	for i := 0; i < n; i++ {
+		r[i] = unicode.ToLower(r[i])
+	}
+	return string(r)
+}
+
+// words splits the name of a field into lowercase words at underscores and changes of case,
+// an initialism is a single word, e.g. "HTTPServerID" is "http", "server", "id".
+func words(fieldName string) []string {
+	var (
+		words []string
+		word  []rune
+	)
+	r := []rune(fieldName)
+	for i, c := range r {
+		if c == '_' {
+			if len(word) != 0 {
+				words, word = append(words, string(word)), nil
+			}
+			continue
+		}
+		if i != 0 && len(word) != 0 && unicode.IsUpper(c) &&
+			(!unicode.IsUpper(r[i-1]) || i+1 < len(r) && unicode.IsLower(r[i+1])) {
+			words, word = append(words, string(word)), nil
+		}
+		word = append(word, unicode.ToLower(c))
+	}
+	if len(word) != 0 {
+		words = append(words, string(word))
+	}
+	return words
+}
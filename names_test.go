@@ -0,0 +1,61 @@
+package engine
+
+import (
+	"testing"
+)
+
+func TestFieldNameMappers(t *testing.T) {
+	var tests = []struct {
+		mapper func(string) string
+		name   string
+		expect string
+	}{
+		{
+			mapper: SnakeCase,
+			name:   "HTTPServerID",
+			expect: "http_server_id",
+		},
+		{
+			mapper: SnakeCase,
+			name:   "Field_Name",
+			expect: "field_name",
+		},
+		{
+			mapper: KebabCase,
+			name:   "UserID",
+			expect: "user-id",
+		},
+		{
+			mapper: LowerCamelCase,
+			name:   "HTTPServer",
+			expect: "httpServer",
+		},
+		{
+			mapper: LowerCamelCase,
+			name:   "ID",
+			expect: "id",
+		},
+	}
+	for _, tt := range tests {
+		equal(t, tt.expect, tt.mapper(tt.name))
+	}
+}
+
+func TestFieldNameMapper(t *testing.T) {
+	type record struct {
+		UserID   int
+		FullName string `test:"name"`
+		HTTPPort int
+	}
+	e := newKeyEngine[nameMeta](func(cfg *Config) { cfg.FieldNameMapper = KebabCase })
+
+	// The renamed fields keep their names.
+	value := record{UserID: 1, FullName: "Ann", HTTPPort: 80}
+	b, err := e.Marshal(value)
+	equal(t, nil, err)
+	equal(t, "user-id=1,name=Ann,http-port=80", string(b))
+
+	var got record
+	equal(t, nil, e.Unmarshal([]byte("http-port=80,name=Ann,user-id=1"), &got))
+	equal(t, value, got)
+}
@@ -0,0 +1,167 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrNotAcceptable is returned when none of the media types of a Negotiator is acceptable, see Negotiator.Negotiate.
+	ErrNotAcceptable = errors.New("none of the media types is acceptable")
+	// ErrUnknownMediaType is returned when no engine is registered for the media type of the content,
+	// see Negotiator.ForContentType.
+	ErrUnknownMediaType = errors.New("no engine is registered for the media type")
+)
+
+// A Negotiator picks one of the engines registered for media types by an Accept or a Content-Type header,
+// e.g. for the HTTP handlers supporting several formats built on the library.
+type Negotiator struct {
+	types []negotiated // in the order of registration, the first one is the default
+}
+
+// negotiated is an engine registered for a media type.
+type negotiated struct {
+	mediaType string
+	e         Engine
+}
+
+// NewNegotiator returns a new negotiator without engines.
+func NewNegotiator() *Negotiator {
+	return new(Negotiator)
+}
+
+// Register registers the engine e for the media type, e.g. "application/edifact", which replaces the engine
+// registered for it before. Media types are case-insensitive, their parameters are ignored.
+func (n *Negotiator) Register(mediaType string, e Engine) {
+	mediaType = baseMediaType(mediaType)
+	for i := range n.types {
+		if n.types[i].mediaType == mediaType {
+			n.types[i].e = e
+			return
+		}
+	}
+	n.types = append(n.types, negotiated{mediaType: mediaType, e: e})
+}
+
+// Negotiate returns the engine and the media type registered for the media type the accept header,
+// e.g. "application/x-a;q=0.5, application/*", prefers. The quality of a media type is the one of the most
+// specific media range matching it, and the media types of the same quality are preferred in the order
+// of registration. An empty header accepts the first media type registered.
+// It returns ErrNotAcceptable if none of the media types is acceptable.
+func (n *Negotiator) Negotiate(accept string) (Engine, string, error) {
+	if strings.TrimSpace(accept) == "" {
+		accept = "*/*"
+	}
+	ranges := parseAccept(accept)
+
+	best, quality := -1, 0.0
+	for i, t := range n.types {
+		if q := t.quality(ranges); q > quality {
+			best, quality = i, q
+		}
+	}
+	if best < 0 {
+		return nil, "", fmt.Errorf("%w: %s", ErrNotAcceptable, accept)
+	}
+	return n.types[best].e, n.types[best].mediaType, nil
+}
+
+// ForContentType returns the engine registered for the media type of the content type header,
+// e.g. "application/x-a; charset=utf-8". It returns ErrUnknownMediaType if there is no such engine.
+func (n *Negotiator) ForContentType(contentType string) (Engine, error) {
+	mediaType := baseMediaType(contentType)
+	for _, t := range n.types {
+		if t.mediaType == mediaType {
+			return t.e, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownMediaType, contentType)
+}
+
+// Marshal encodes the value v with the engine the accept header prefers, see Negotiate,
+// and returns the encoded data and its media type.
+func (n *Negotiator) Marshal(accept string, v any) ([]byte, string, error) {
+	e, mediaType, err := n.Negotiate(accept)
+	if err != nil {
+		return nil, "", err
+	}
+	data, err := e.Marshal(v)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, mediaType, nil
+}
+
+// Unmarshal decodes the data with the engine registered for the media type of the content type header,
+// see ForContentType, and stores the result in the value pointed to by v.
+func (n *Negotiator) Unmarshal(contentType string, data []byte, v any) error {
+	e, err := n.ForContentType(contentType)
+	if err != nil {
+		return err
+	}
+	return e.Unmarshal(data, v)
+}
+
+// mediaRange is a media range of an Accept header with its quality.
+type mediaRange struct {
+	typ, subtype string // may be "*"
+	quality      float64
+}
+
+// parseAccept returns the media ranges of the accept header, the invalid ones are skipped.
+func parseAccept(accept string) []mediaRange {
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		typ, subtype, ok := strings.Cut(mediaType, "/")
+		if !ok {
+			continue
+		}
+
+		r := mediaRange{typ: typ, subtype: subtype, quality: 1}
+		if q, ok := params["q"]; ok {
+			if r.quality, err = strconv.ParseFloat(q, 64); err != nil || r.quality < 0 || r.quality > 1 {
+				continue
+			}
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges
+}
+
+// quality returns the quality of the media type for the most specific of the media ranges matching it,
+// 0 if none of them does.
+func (t negotiated) quality(ranges []mediaRange) float64 {
+	typ, subtype, _ := strings.Cut(t.mediaType, "/")
+
+	specificity, quality := -1, 0.0
+	for _, r := range ranges {
+		var s int
+		switch {
+		case r.typ == typ && r.subtype == subtype:
+			s = 2
+		case r.typ == typ && r.subtype == "*":
+			s = 1
+		case r.typ == "*" && r.subtype == "*":
+			s = 0
+		default:
+			continue
+		}
+		if s > specificity {
+			specificity, quality = s, r.quality
+		}
+	}
+	return quality
+}
+
+// baseMediaType returns the media type without the parameters in lower case.
+func baseMediaType(mediaType string) string {
+	mediaType, _, _ = strings.Cut(mediaType, ";")
+	return strings.ToLower(strings.TrimSpace(mediaType))
+}
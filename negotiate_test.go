@@ -0,0 +1,47 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNegotiator(t *testing.T) {
+	comma := newTestEngine(nil)
+	pipe := newTestEngine(func(cfg *Config) { cfg.ValueSeparator = []byte("|") })
+
+	n := NewNegotiator()
+	n.Register("application/x-comma", comma)
+	n.Register("Application/X-Pipe; version=1", pipe)
+
+	var tests = []struct {
+		accept    string
+		mediaType string
+		expect    error
+	}{
+		{accept: "", mediaType: "application/x-comma"},
+		{accept: "application/x-pipe", mediaType: "application/x-pipe"},
+		{accept: "application/x-comma;q=0.5, application/x-pipe;q=0.8", mediaType: "application/x-pipe"},
+		{accept: "application/*;q=0.1, application/x-pipe;q=0", mediaType: "application/x-comma"},
+		{accept: "text/html, */*;q=0.1", mediaType: "application/x-comma"},
+		{accept: "text/html", expect: ErrNotAcceptable},
+	}
+
+	for _, tt := range tests {
+		_, mediaType, err := n.Negotiate(tt.accept)
+		equal(t, true, errors.Is(err, tt.expect))
+		equal(t, tt.mediaType, mediaType)
+	}
+
+	value := streamed{A: "a", B: 1}
+	b, mediaType, err := n.Marshal("application/x-pipe", value)
+	equal(t, nil, err)
+	equal(t, "application/x-pipe", mediaType)
+	equal(t, "a|1", string(b))
+
+	var got streamed
+	equal(t, nil, n.Unmarshal("application/x-pipe; charset=utf-8", b, &got))
+	equal(t, value, got)
+
+	err = n.Unmarshal("text/plain", b, &got)
+	equal(t, true, errors.Is(err, ErrUnknownMediaType))
+}
@@ -0,0 +1,53 @@
+package engine
+
+import (
+	"bytes"
+	"unicode"
+)
+
+// Normalizer is the interface implemented by a parsed tag, a *T of the engine Tag,
+// that normalizes the values of its field instead of Config.Normalize.
+type Normalizer interface {
+	// Normalize returns the normalized value written by Tag.Decode.
+	Normalize(value []byte) []byte
+}
+
+// Normalizers returns a function that applies the normalizers in order.
+func Normalizers(normalizers ...func([]byte) []byte) func([]byte) []byte {
+	return func(value []byte) []byte {
+		for _, normalize := range normalizers {
+			value = normalize(value)
+		}
+		return value
+	}
+}
+
+// ToUpper is a normalizer that maps all letters of the value to their upper case.
+func ToUpper(value []byte) []byte {
+	return bytes.ToUpper(value)
+}
+
+// ToLower is a normalizer that maps all letters of the value to their lower case.
+func ToLower(value []byte) []byte {
+	return bytes.ToLower(value)
+}
+
+// TrimPadding returns a normalizer that strips the leading and trailing padding characters of the value.
+func TrimPadding(padding string) func([]byte) []byte {
+	return func(value []byte) []byte {
+		return bytes.Trim(value, padding)
+	}
+}
+
+// CollapseSpaces is a normalizer that strips the leading and trailing whitespace of the value
+// and replaces every run of whitespace inside it with a single space.
+func CollapseSpaces(value []byte) []byte {
+	out := make([]byte, 0, len(value))
+	for _, word := range bytes.FieldsFunc(value, unicode.IsSpace) {
+		if len(out) != 0 {
+			out = append(out, ' ')
+		}
+		out = append(out, word...)
+	}
+	return out
+}
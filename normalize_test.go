@@ -0,0 +1,96 @@
+package engine
+
+import (
+	"testing"
+)
+
+func TestNormalizers(t *testing.T) {
+	var tests = []struct {
+		normalize func([]byte) []byte
+		value     string
+		expect    string
+	}{
+		{
+			normalize: ToUpper,
+			value:     "abc",
+			expect:    "ABC",
+		},
+		{
+			normalize: TrimPadding("0 "),
+			value:     "  0012300 ",
+			expect:    "123",
+		},
+		{
+			normalize: CollapseSpaces,
+			value:     " a \t b\n\nc ",
+			expect:    "a b c",
+		},
+		{
+			normalize: Normalizers(CollapseSpaces, ToLower),
+			value:     " A  B ",
+			expect:    "a b",
+		},
+	}
+	for _, tt := range tests {
+		equal(t, tt.expect, string(tt.normalize([]byte(tt.value))))
+	}
+}
+
+// normalizeMeta is a parsed tag upper-casing the values of the fields tagged "upper".
+type normalizeMeta struct {
+	upper bool
+}
+
+func (m *normalizeMeta) parse(tagValue string) (bool, error) {
+	m.upper = tagValue == "upper"
+	return false, nil
+}
+
+func (m *normalizeMeta) Normalize(value []byte) []byte {
+	if m.upper {
+		return ToUpper(value)
+	}
+	return value
+}
+
+func TestConfigNormalize(t *testing.T) {
+	type padded struct {
+		Code   string
+		Amount int
+	}
+	e := newTestEngine(func(cfg *Config) { cfg.Normalize = TrimPadding("0 ") })
+
+	var got padded
+	equal(t, nil, e.Unmarshal([]byte(" AB ,00123"), &got))
+	equal(t, padded{Code: "AB", Amount: 123}, got)
+
+	type tagged struct {
+		A string `test:"upper"`
+		B string
+	}
+	e = newEngineOf[normalizeMeta](func(cfg *Config) { cfg.Normalize = CollapseSpaces })
+
+	// The Normalizer of a field replaces the Config.Normalize.
+	var tg tagged
+	equal(t, nil, e.Unmarshal([]byte("a  b, c  d "), &tg))
+	equal(t, tagged{A: "A  B", B: "c d"}, tg)
+}
+
+func TestConfigCanonicalize(t *testing.T) {
+	type record struct {
+		Name string
+		Code string
+		Age  int
+	}
+	e := newTestEngine(func(cfg *Config) {
+		cfg.Canonicalize, cfg.Normalize = Normalizers(CollapseSpaces, ToUpper), ToLower
+	})
+
+	b, err := e.Marshal(record{Name: " john  doe ", Code: "ab", Age: 7})
+	equal(t, nil, err)
+	equal(t, "JOHN DOE,AB,7", string(b))
+
+	var got record
+	equal(t, nil, e.Unmarshal(b, &got))
+	equal(t, record{Name: "john doe", Code: "ab", Age: 7}, got)
+}
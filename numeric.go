@@ -0,0 +1,275 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidNumber is returned for a numeric value that doesn't match the representation of its field,
+// or a value that the field can't hold, see NumberFormatter.
+var ErrInvalidNumber = errors.New("invalid number")
+
+// NumberFormat is the representation of a number, see NumberFormatter.
+type NumberFormat int
+
+const (
+	// PlainNumber is the decimal digits of the number preceded by "-" if it is negative.
+	PlainNumber NumberFormat = iota
+	// Overpunch is the ASCII decimal digits of the number with the sign punched over the last one:
+	// "{" and "A" to "I" are the positive 0 to 9, "}" and "J" to "R" are the negative ones.
+	// An unpunched last digit is positive.
+	Overpunch
+	// ZonedDecimal is the EBCDIC zoned decimal digits of the number, one byte per digit with the zone 0xF
+	// in the high nibble, and the sign in the zone of the last one: 0xC is positive, 0xD is negative
+	// and 0xF is unsigned. The zones 0xA and 0xE are positive and 0xB is negative when decoding.
+	ZonedDecimal
+	// PackedDecimal is the packed decimal digits of the number, two digits per byte, followed by the sign
+	// in the low nibble of the last byte, as in COBOL COMP-3: 0xC is positive, 0xD is negative and 0xF is unsigned.
+	// A leading zero is added to fill the first byte. The signs 0xA and 0xE are positive and 0xB is negative
+	// when decoding.
+	PackedDecimal
+	// PackedBCD is the unsigned binary-coded decimal digits of the number, two digits per byte, as in ISO 8583
+	// and smart-card data. A leading zero is added to fill the first byte, negative numbers are invalid.
+	PackedBCD
+)
+
+// NumberFormatter is the interface implemented by a parsed tag, a *T of the engine Tag, of a numeric field
+// that is represented as in mainframe extracts and other legacy formats. The field is of an integer
+// or a floating-point kind, the value of a floating-point field is scaled by the implied decimal places
+// and rounded, e.g. 12.345 with 2 places is "1235". The implied decimal places of integer fields are
+// ignored, the fields hold the scaled values. The Tag pads and aligns the values if the format needs it.
+type NumberFormatter interface {
+	// NumberFormat returns the representation of the number and the number of its implied decimal places.
+	NumberFormat() (format NumberFormat, scale int)
+}
+
+// numberFormat is the representation of a numeric field, see NumberFormatter.
+type numberFormat struct {
+	format NumberFormat
+	scale  int
+}
+
+// numberCoders returns the coders of a numeric field of the type t with the representation.
+func numberCoders[T any](t reflect.Type, nf *numberFormat) (encoderFunc[T], decoderFunc[T], error) {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return numberEncoder[T](nf), numberDecoder[T](nf), nil
+	}
+	return nil, nil, fmt.Errorf("%w: number format of %s", ErrNotSupportType, t)
+}
+
+func numberEncoder[T any](nf *numberFormat) encoderFunc[T] {
+	return func(s *encodeState[T], v reflect.Value) error {
+		var (
+			neg    bool
+			digits []byte
+		)
+
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			x := v.Int()
+			neg = x < 0
+			if neg {
+				x = -x
+			}
+			digits = strconv.AppendUint(s.scratch[:0], uint64(x), 10)
+		case reflect.Float32, reflect.Float64:
+			x := math.Round(v.Float() * math.Pow10(nf.scale))
+			if math.IsInf(x, 0) || math.IsNaN(x) {
+				return fmt.Errorf("%w: %v", ErrInvalidNumber, v.Float())
+			}
+			neg = x < 0
+			digits = strconv.AppendFloat(s.scratch[:0], math.Abs(x), 'f', 0, 64)
+		default:
+			digits = strconv.AppendUint(s.scratch[:0], v.Uint(), 10)
+		}
+
+		last := len(digits) - 1
+		switch nf.format {
+		case Overpunch:
+			digits[last] = overpunch(digits[last]-'0', neg)
+		case ZonedDecimal:
+			for i := range digits {
+				digits[i] = 0xF0 | (digits[i] - '0')
+			}
+			if neg {
+				digits[last] = 0xD0 | digits[last]&0x0F
+			} else {
+				digits[last] = 0xC0 | digits[last]&0x0F
+			}
+		case PackedDecimal:
+			sign := byte(0xC)
+			if neg {
+				sign = 0xD
+			}
+			digits = pack(append(digits, '0'+sign))
+		case PackedBCD:
+			if neg {
+				return fmt.Errorf("%w: negative %s", ErrInvalidNumber, digits)
+			}
+			digits = pack(digits)
+		default:
+			if neg {
+				digits = append([]byte{'-'}, digits...)
+			}
+		}
+		return s.encodeValue(digits)
+	}
+}
+
+func numberDecoder[T any](nf *numberFormat) decoderFunc[T] {
+	return func(s *decodeState[T], v reflect.Value) error {
+		neg, digits, err := nf.digits(s.Bytes())
+		if err != nil {
+			return err
+		}
+
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if neg {
+				digits = "-" + digits
+			}
+			x, err := strconv.ParseInt(digits, 10, 64)
+			if err != nil || v.OverflowInt(x) {
+				return fmt.Errorf("%w: %s into %s", ErrInvalidNumber, digits, v.Type())
+			}
+			v.SetInt(x)
+		case reflect.Float32, reflect.Float64:
+			if nf.scale > 0 {
+				// The implied decimal point.
+				digits = strings.Repeat("0", max(nf.scale-len(digits)+1, 0)) + digits
+				digits = digits[:len(digits)-nf.scale] + "." + digits[len(digits)-nf.scale:]
+			}
+			x, err := strconv.ParseFloat(digits, v.Type().Bits())
+			if err != nil {
+				return fmt.Errorf("%w: %s into %s", ErrInvalidNumber, digits, v.Type())
+			}
+			if nf.scale < 0 {
+				x *= math.Pow10(-nf.scale)
+			}
+			if neg {
+				x = -x
+			}
+			v.SetFloat(x)
+		default:
+			x, err := strconv.ParseUint(digits, 10, 64)
+			if err != nil || neg && x != 0 || v.OverflowUint(x) {
+				return fmt.Errorf("%w: %s into %s", ErrInvalidNumber, digits, v.Type())
+			}
+			v.SetUint(x)
+		}
+		return nil
+	}
+}
+
+// digits returns the sign and the ASCII decimal digits of the represented number.
+func (nf *numberFormat) digits(p []byte) (neg bool, digits string, err error) {
+	if len(p) == 0 {
+		return false, "", fmt.Errorf("%w: empty value", ErrInvalidNumber)
+	}
+
+	b := []byte(string(p))
+	last := len(b) - 1
+	switch nf.format {
+	case Overpunch:
+		var ok bool
+		if b[last], neg, ok = unpunch(b[last]); !ok {
+			return false, "", fmt.Errorf("%w: overpunch %q", ErrInvalidNumber, p)
+		}
+	case ZonedDecimal:
+		for i, c := range b {
+			zone, digit := c>>4, c&0x0F
+			if digit > 9 || i < last && zone != 0xF {
+				return false, "", fmt.Errorf("%w: zoned decimal %X", ErrInvalidNumber, p)
+			}
+			if i == last {
+				switch zone {
+				case 0xD, 0xB:
+					neg = true
+				case 0xC, 0xF, 0xA, 0xE:
+				default:
+					return false, "", fmt.Errorf("%w: zoned decimal %X", ErrInvalidNumber, p)
+				}
+			}
+			b[i] = '0' + digit
+		}
+	case PackedDecimal, PackedBCD:
+		b = make([]byte, 0, 2*len(p))
+		for _, c := range p {
+			b = append(b, '0'+c>>4, '0'+c&0x0F)
+		}
+		if nf.format == PackedBCD {
+			break
+		}
+
+		last = len(b) - 1
+		switch b[last] - '0' {
+		case 0xD, 0xB:
+			neg = true
+		case 0xC, 0xF, 0xA, 0xE:
+		default:
+			return false, "", fmt.Errorf("%w: packed decimal %X", ErrInvalidNumber, p)
+		}
+		b = b[:last]
+	default:
+		if b[0] == '-' || b[0] == '+' {
+			neg, b = b[0] == '-', b[1:]
+		}
+	}
+
+	for _, c := range b {
+		if c < '0' || c > '9' {
+			return false, "", fmt.Errorf("%w: %q", ErrInvalidNumber, p)
+		}
+	}
+	return neg, string(b), nil
+}
+
+// pack packs the nibbles, the ASCII digits and the sign, two per byte with a leading zero if their number is odd.
+func pack(nibbles []byte) []byte {
+	if len(nibbles)%2 != 0 {
+		nibbles = append([]byte{'0'}, nibbles...)
+	}
+	p := make([]byte, len(nibbles)/2)
+	for i := range p {
+		p[i] = (nibbles[2*i]-'0')<<4 | (nibbles[2*i+1] - '0')
+	}
+	return p
+}
+
+// overpunch returns the last digit d of a number with the sign punched over it, see Overpunch.
+func overpunch(d byte, neg bool) byte {
+	switch {
+	case d == 0 && neg:
+		return '}'
+	case d == 0:
+		return '{'
+	case neg:
+		return 'J' + d - 1
+	default:
+		return 'A' + d - 1
+	}
+}
+
+// unpunch returns the ASCII digit and the sign of the last digit c of a number, see Overpunch.
+func unpunch(c byte) (digit byte, neg bool, ok bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return c, false, true
+	case c == '{':
+		return '0', false, true
+	case c == '}':
+		return '0', true, true
+	case c >= 'A' && c <= 'I':
+		return '1' + c - 'A', false, true
+	case c >= 'J' && c <= 'R':
+		return '1' + c - 'J', true, true
+	}
+	return 0, false, false
+}
@@ -0,0 +1,145 @@
+package engine
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// numberMeta is a parsed tag declaring the representation of a number and its implied decimal places,
+// e.g. "punch.2", see NumberFormatter.
+type numberMeta struct {
+	format NumberFormat
+	scale  int
+}
+
+var numberFormats = map[string]NumberFormat{
+	"plain":  PlainNumber,
+	"punch":  Overpunch,
+	"zoned":  ZonedDecimal,
+	"packed": PackedDecimal,
+	"bcd":    PackedBCD,
+}
+
+func (m *numberMeta) parse(tagValue string) (bool, error) {
+	format, scale, ok := strings.Cut(tagValue, ".")
+	m.format = numberFormats[format]
+	var err error
+	if ok {
+		m.scale, err = strconv.Atoi(scale)
+	}
+	return false, err
+}
+
+func (m *numberMeta) NumberFormat() (NumberFormat, int) {
+	return m.format, m.scale
+}
+
+func TestOverpunch(t *testing.T) {
+	type record struct {
+		A int     `test:"punch"`
+		B int     `test:"punch"`
+		C float64 `test:"punch.2"`
+		D uint    `test:"punch"`
+		E int64   `test:"zoned"`
+		F float32 `test:"zoned.3"`
+		G float64 `test:"plain.2"`
+	}
+	e := newEngineOf[numberMeta](func(cfg *Config) { cfg.ValueSeparator = []byte("|") })
+	value := record{A: -123, B: 450, C: -12.345, D: 9, E: -7, F: 0.004, G: 0.05}
+
+	b, err := e.Marshal(value)
+	equal(t, nil, err)
+	equal(t, "12L|45{|123N|I|\xd7|\xc4|5", string(b))
+
+	var got record
+	equal(t, nil, e.Unmarshal(b, &got))
+	equal(t, record{A: -123, B: 450, C: -12.35, D: 9, E: -7, F: 0.004, G: 0.05}, got)
+
+	// An unpunched last digit is positive, the zone 0xF is unsigned.
+	equal(t, nil, e.Unmarshal([]byte("1}|A|10|0|\xf1\xc2|\xf1|-1"), &got))
+	equal(t, record{A: -10, B: 1, C: 0.1, D: 0, E: 12, F: 0.001, G: -0.01}, got)
+
+	var tests = []string{
+		"1x",
+		"|1{2",
+		"||||\x12",
+		"|||J",
+	}
+	for _, data := range tests {
+		err = e.Unmarshal([]byte(data), &got)
+		equal(t, true, errors.Is(err, ErrInvalidNumber))
+	}
+
+	var bad struct {
+		A string `test:"punch"`
+	}
+	_, err = e.Marshal(bad)
+	equal(t, true, errors.Is(err, ErrNotSupportType))
+}
+
+func TestPackedNumbers(t *testing.T) {
+	type decimal struct {
+		A float64 `test:"packed.2"`
+	}
+	type bcd struct {
+		A int `test:"bcd"`
+	}
+	// The library splits the data itself, so that the packed bytes that are whitespace aren't trimmed.
+	e := newEngineOf[numberMeta](func(cfg *Config) { cfg.ValueSeparator = []byte("|") })
+
+	var tests = []struct {
+		value any
+		data  string
+		into  func() any
+	}{
+		{
+			value: decimal{A: -123.45},
+			data:  "\x12\x34\x5d",
+			into:  func() any { return new(decimal) },
+		},
+		{
+			value: decimal{A: 1.5},
+			data:  "\x15\x0c",
+			into:  func() any { return new(decimal) },
+		},
+		{
+			value: bcd{A: 1234},
+			data:  "\x12\x34",
+			into:  func() any { return new(bcd) },
+		},
+		{
+			value: bcd{A: 123},
+			data:  "\x01\x23",
+			into:  func() any { return new(bcd) },
+		},
+	}
+	for _, tt := range tests {
+		b, err := e.Marshal(tt.value)
+		equal(t, nil, err)
+		equal(t, tt.data, string(b))
+
+		got := tt.into()
+		equal(t, nil, e.Unmarshal(b, got))
+		equal(t, tt.value, reflect.ValueOf(got).Elem().Interface())
+	}
+
+	// The sign 0xF is unsigned, 0xB is negative.
+	var d decimal
+	equal(t, nil, e.Unmarshal([]byte("\x12\x3f"), &d))
+	equal(t, 1.23, d.A)
+	equal(t, nil, e.Unmarshal([]byte("\x12\x3b"), &d))
+	equal(t, -1.23, d.A)
+
+	err := e.Unmarshal([]byte("\x12\x34"), &d)
+	equal(t, true, errors.Is(err, ErrInvalidNumber))
+
+	var b bcd
+	err = e.Unmarshal([]byte("\x1a"), &b)
+	equal(t, true, errors.Is(err, ErrInvalidNumber))
+
+	_, err = e.Marshal(bcd{A: -1})
+	equal(t, true, errors.Is(err, ErrInvalidNumber))
+}
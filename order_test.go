@@ -0,0 +1,46 @@
+package engine
+
+import (
+	"testing"
+)
+
+type orderedFields struct {
+	B string
+	A int
+	C bool
+}
+
+func TestFieldLess(t *testing.T) {
+	reversed := func(a, b FieldInfo) bool {
+		return a.Index > b.Index
+	}
+
+	var tests = []struct {
+		fieldLess func(a, b FieldInfo) bool
+		expect    string
+	}{
+		{
+			fieldLess: nil,
+			expect:    "b,1,true",
+		},
+		{
+			fieldLess: FieldsByName,
+			expect:    "1,b,true",
+		},
+		{
+			fieldLess: reversed,
+			expect:    "true,1,b",
+		},
+	}
+	// The engines share the type but not the order of its fields.
+	for _, tt := range tests {
+		e := newTestEngine(func(cfg *Config) { cfg.FieldLess = tt.fieldLess })
+		b, err := e.Marshal(orderedFields{B: "b", A: 1, C: true})
+		equal(t, nil, err)
+		equal(t, tt.expect, string(b))
+
+		var got orderedFields
+		equal(t, nil, e.Unmarshal(b, &got))
+		equal(t, orderedFields{B: "b", A: 1, C: true}, got)
+	}
+}
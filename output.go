@@ -0,0 +1,119 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+// ErrOutputCharset is returned for a character of a value that the output character set of its field doesn't allow
+// and can't transliterate, see OutputCharsetValidator.
+var ErrOutputCharset = errors.New("the character isn't allowed in the output")
+
+// OutputCharsetValidator restricts the characters of the values to the character set the format allows,
+// e.g. the SWIFT X character set, when encoding. The values are validated one by one, after they are encoded,
+// their control characters are handled, see Config.ControlCharsWhenEncoding, and before they are converted
+// to the charset of the data, see Charset. The elements of the lists and the keys of the maps are validated
+// one by one as well, the separators, the openers and the closers are left as they are. The binary values
+// are left as they are too, see Config.ControlCharsWhenEncoding.
+type OutputCharsetValidator interface {
+	// Validate appends the UTF-8 value p to dst with the characters the character set doesn't allow transliterated,
+	// it returns an error wrapping ErrOutputCharset for a character it can't transliterate.
+	Validate(dst, p []byte) ([]byte, error)
+}
+
+// OutputCharsetter is the interface implemented by a parsed tag, a *T of the engine Tag, of a field whose values
+// are restricted to a character set other than the one of the engine, see Config.OutputCharsetValidator.
+type OutputCharsetter interface {
+	// OutputCharset returns the validator of the values of the field, nil for the validator of the engine.
+	OutputCharset() OutputCharsetValidator
+}
+
+var (
+	// ASCIIOutput allows the ASCII characters only.
+	ASCIIOutput = NewOutputCharset("ASCII", func(r rune) bool { return r < utf8.RuneSelf }, nil)
+	// SWIFTXOutput allows the characters of the SWIFT X character set only: the Latin letters, the digits,
+	// the space, CR, LF and / - ? : ( ) . , ' +.
+	SWIFTXOutput = NewOutputCharset("SWIFT X", isSWIFTX, nil)
+)
+
+// outputCharset is a character set defined by a function reporting whether a character is allowed.
+type outputCharset struct {
+	name          string
+	allowed       func(r rune) bool
+	transliterate func(r rune) (string, bool)
+}
+
+// NewOutputCharset returns the validator of the character set of the name allowing the characters the function
+// allowed reports. The characters it doesn't allow are replaced with the strings transliterate returns for them,
+// which must be allowed, e.g. TransliterateLatin. If transliterate is nil or returns false,
+// the validator returns ErrOutputCharset.
+func NewOutputCharset(name string, allowed func(r rune) bool, transliterate func(r rune) (string, bool)) OutputCharsetValidator {
+	return &outputCharset{name: name, allowed: allowed, transliterate: transliterate}
+}
+
+// Validate returns the value with the characters the character set doesn't allow transliterated,
+// see OutputCharsetValidator.
+func (cs *outputCharset) Validate(dst, p []byte) ([]byte, error) {
+	for len(p) != 0 {
+		r, n := utf8.DecodeRune(p)
+		switch {
+		case r == utf8.RuneError && n == 1:
+		case cs.allowed(r):
+			dst = append(dst, p[:n]...)
+			p = p[n:]
+			continue
+		case cs.transliterate != nil:
+			if s, ok := cs.transliterate(r); ok {
+				dst = append(dst, s...)
+				p = p[n:]
+				continue
+			}
+		}
+		return dst, fmt.Errorf("%w: %q in %s", ErrOutputCharset, p[:n], cs.name)
+	}
+	return dst, nil
+}
+
+// isSWIFTX reports whether the character belongs to the SWIFT X character set.
+func isSWIFTX(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return true
+	}
+	switch r {
+	case '/', '-', '?', ':', '(', ')', '.', ',', '\'', '+', ' ', '\r', '\n':
+		return true
+	}
+	return false
+}
+
+// TransliterateLatin returns the Latin letters with diacritics and ligatures of Latin-1 spelled
+// with the ASCII letters, e.g. "e" for "é" and "ss" for "ß", see NewOutputCharset.
+func TransliterateLatin(r rune) (string, bool) {
+	s, ok := latin[r]
+	return s, ok
+}
+
+var latin = map[rune]string{
+	'À': "A", 'Á': "A", 'Â': "A", 'Ã': "A", 'Ä': "A", 'Å': "A", 'Æ': "AE", 'Ç': "C",
+	'È': "E", 'É': "E", 'Ê': "E", 'Ë': "E", 'Ì': "I", 'Í': "I", 'Î': "I", 'Ï': "I",
+	'Ð': "D", 'Ñ': "N", 'Ò': "O", 'Ó': "O", 'Ô': "O", 'Õ': "O", 'Ö': "O", 'Ø': "O",
+	'Ù': "U", 'Ú': "U", 'Û': "U", 'Ü': "U", 'Ý': "Y", 'Þ': "TH", 'ß': "ss",
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'æ': "ae", 'ç': "c",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ì': "i", 'í': "i", 'î': "i", 'ï': "i",
+	'ð': "d", 'ñ': "n", 'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ý': "y", 'þ': "th", 'ÿ': "y",
+}
+
+// outputCharsetOf returns the output character set of the values of the field, the one of the engine v
+// if the field declares none, or nil if the values aren't validated, see OutputCharsetValidator.
+func (f *field[T]) outputCharsetOf(v OutputCharsetValidator) OutputCharsetValidator {
+	switch {
+	case f.binary():
+		return nil
+	case f.output != nil:
+		return f.output
+	}
+	return v
+}
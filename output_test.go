@@ -0,0 +1,53 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+)
+
+// outputMeta is a parsed tag "ascii" restricting the values of its field to ASCII, see OutputCharsetter.
+type outputMeta struct {
+	ascii bool
+}
+
+func (m *outputMeta) parse(tagValue string) (bool, error) {
+	m.ascii = tagValue == "ascii"
+	return false, nil
+}
+
+func (m *outputMeta) OutputCharset() OutputCharsetValidator {
+	if m.ascii {
+		return ASCIIOutput
+	}
+	return nil
+}
+
+func TestOutputCharsetValidator(t *testing.T) {
+	type record struct {
+		Name string
+		Note string `test:"ascii"`
+		Tags []string
+	}
+	swift := NewOutputCharset("SWIFT X", isSWIFTX, TransliterateLatin)
+	e := newEngineOf[outputMeta](func(cfg *Config) {
+		listConfig(cfg)
+		cfg.OutputCharsetValidator = swift
+	})
+
+	b, err := e.Marshal(record{Name: "Café Müller", Note: "a&b", Tags: []string{"Straße", "x"}})
+	equal(t, nil, err)
+	equal(t, "Cafe Muller,a&b,(Strasse~x)", string(b))
+
+	var tests = []record{
+		{Name: "a&b"},
+		{Note: "né"},
+		{Tags: []string{"€"}},
+	}
+	for _, value := range tests {
+		_, err = e.Marshal(value)
+		equal(t, true, errors.Is(err, ErrOutputCharset))
+	}
+
+	_, err = SWIFTXOutput.Validate(nil, []byte("é"))
+	equal(t, true, errors.Is(err, ErrOutputCharset))
+}
@@ -0,0 +1,123 @@
+package engine
+
+import (
+	"bytes"
+	"io"
+	"runtime"
+	"sync/atomic"
+)
+
+// An EncodePipeline encodes the values it receives on a channel with a pool of workers and writes them
+// to an output stream in the order they are received, like an Encoder. The number of values being encoded
+// is bounded, so the sender is blocked while the workers and the writer are busy.
+type EncodePipeline struct {
+	w       io.Writer
+	e       streamer
+	workers int
+	framing Framing
+	err     error // the engine can't encode a stream, see NewEncodePipeline
+}
+
+// NewEncodePipeline returns a new pipeline of the engine e that writes to w with the number of workers,
+// GOMAXPROCS workers if it isn't positive. The pipeline of an engine without the NewEncodePipeline method
+// receives the values without encoding them and returns ErrUnsupported.
+func NewEncodePipeline(e Engine, w io.Writer, workers int) *EncodePipeline {
+	if n, ok := e.(pipeliner); ok {
+		return n.NewEncodePipeline(w, workers)
+	}
+	return &EncodePipeline{w: w, err: unsupported(e, "NewEncodePipeline")}
+}
+
+// pipeliner is implemented by the engines encoding streams with a pool of workers, see NewEncodePipeline.
+type pipeliner interface {
+	NewEncodePipeline(w io.Writer, workers int) *EncodePipeline
+}
+
+// NewEncodePipeline returns a new pipeline that writes to w, see the function NewEncodePipeline.
+func (e *engine[T]) NewEncodePipeline(w io.Writer, workers int) *EncodePipeline {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	return &EncodePipeline{w: w, e: e, workers: workers}
+}
+
+// UseFraming makes the pipeline wrap every value in a frame of the framing f, see Encoder.UseFraming.
+func (p *EncodePipeline) UseFraming(f Framing) {
+	p.framing = f
+}
+
+// encoded is the result of encoding a value of the pipeline.
+type encoded struct {
+	data []byte
+	err  error
+}
+
+// job is a value of the pipeline to encode and where to send the result.
+type job struct {
+	v      any
+	result chan<- encoded
+}
+
+// Run encodes the values received on the channel and writes them to the stream until the channel is closed.
+// If the writer has a Flush method, like bufio.Writer, it is flushed after the last value is written.
+// Once a value fails to be encoded or written, the following values are received but neither encoded nor written,
+// and Run returns the error after the channel is closed.
+func (p *EncodePipeline) Run(values <-chan any) error {
+	if p.err != nil {
+		for range values {
+		}
+		return p.err
+	}
+
+	jobs := make(chan job, p.workers)
+	results := make(chan chan encoded, p.workers)
+	var failed int32 // set once a value fails, the rest of the values are drained
+
+	for i := 0; i < p.workers; i++ {
+		go func() {
+			var buf bytes.Buffer
+			enc := &Encoder{w: &buf, e: p.e, framing: p.framing}
+			for j := range jobs {
+				buf.Reset()
+				err := enc.Encode(j.v)
+				j.result <- encoded{data: append([]byte(nil), buf.Bytes()...), err: err}
+			}
+		}()
+	}
+
+	// The results are queued in the order of the values, so that they are written in the same order.
+	go func() {
+		defer close(jobs)
+		defer close(results)
+		for v := range values {
+			if atomic.LoadInt32(&failed) != 0 {
+				continue
+			}
+			result := make(chan encoded, 1)
+			results <- result
+			jobs <- job{v: v, result: result}
+		}
+	}()
+
+	var err error
+	for result := range results {
+		r := <-result
+		if err != nil {
+			continue
+		}
+		if err = r.err; err == nil {
+			_, err = p.w.Write(r.data)
+		}
+		if err != nil {
+			atomic.StoreInt32(&failed, 1)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if f, ok := p.w.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
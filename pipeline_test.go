@@ -0,0 +1,78 @@
+package engine
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestEncodePipeline(t *testing.T) {
+	e := newTestEngine(func(cfg *Config) { cfg.RecordSeparator = []byte("\n") })
+
+	var tests = []struct {
+		workers int
+		framing Framing
+	}{
+		{workers: 0},
+		{workers: 1},
+		{workers: 4, framing: LengthPrefix},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		w := bufio.NewWriter(&buf)
+		p := NewEncodePipeline(e, w, tt.workers)
+		p.UseFraming(tt.framing)
+
+		values := make(chan any)
+		var expect []streamed
+		go func() {
+			defer close(values)
+			for i := 0; i < 100; i++ {
+				v := streamed{A: fmt.Sprint("v", i), B: i}
+				expect = append(expect, v)
+				values <- v
+			}
+		}()
+		equal(t, nil, p.Run(values))
+
+		// The values are written in the order they are received and the writer is flushed.
+		dec := NewDecoder(e, &buf)
+		dec.UseFraming(tt.framing)
+		got, err := decodeAll[streamed](dec)
+		equal(t, io.EOF, err)
+		equal(t, expect, got)
+	}
+}
+
+func TestEncodePipelineError(t *testing.T) {
+	e := newTestEngine(func(cfg *Config) { cfg.RecordSeparator = []byte("\n") })
+
+	var buf strings.Builder
+	p := NewEncodePipeline(e, &buf, 2)
+	values := make(chan any)
+	go func() {
+		defer close(values)
+		values <- streamed{A: "a", B: 1}
+		values <- make(chan int)
+		for i := 0; i < 10; i++ {
+			values <- streamed{A: "b", B: i}
+		}
+	}()
+
+	// The values following the failed one are drained but not written.
+	equal(t, true, p.Run(values) != nil)
+	equal(t, "a,1\n", buf.String())
+	// The pipeline of a foreign engine drains the values.
+	values = make(chan any)
+	go func() {
+		defer close(values)
+		values <- streamed{A: "c", B: 2}
+	}()
+	err := NewEncodePipeline(foreignEngine{e}, &buf, 2).Run(values)
+	equal(t, true, errors.Is(err, ErrUnsupported))
+	equal(t, "a,1\n", buf.String())
+}
@@ -0,0 +1,46 @@
+package engine
+
+// CSVConfig returns the configuration of comma-separated records, one per line.
+// The library splits the data itself: the values of a record are separated by commas, the components
+// of its composite fields by semicolons and the elements of its lists by pipes.
+// A separator inside a value is escaped with a backslash, there is no quoting.
+// The Marshaller and the Unmarshaler aren't set, the caller must set them before passing the configuration to New.
+func CSVConfig() Config {
+	return Config{
+		ValueSeparator:     []byte(","),
+		ComponentSeparator: []byte(";"),
+		ElementSeparator:   []byte("|"),
+		EscapeChar:         '\\',
+		RecordSeparator:    []byte("\n"),
+	}
+}
+
+// PipeDelimitedConfig returns the configuration of pipe-delimited records, one per line, in the style of HL7:
+// the values of a record are separated by "|", the components of its composite fields by "^" and the elements
+// of its lists by "~". A separator inside a value is escaped with a backslash,
+// and the empty values at the end of a record are dropped when encoding.
+// The Marshaller and the Unmarshaler aren't set, the caller must set them before passing the configuration to New.
+func PipeDelimitedConfig() Config {
+	return Config{
+		ValueSeparator:     []byte("|"),
+		ComponentSeparator: []byte("^"),
+		ElementSeparator:   []byte("~"),
+		EscapeChar:         '\\',
+		TrimTrailingEmpty:  true,
+		RecordSeparator:    []byte("\n"),
+	}
+}
+
+// LineRecordsConfig returns the configuration of tab-separated records, one per line, e.g. for logs.
+// The components of composite fields are separated by semicolons and the elements of lists by pipes.
+// A separator inside a value is escaped with a backslash.
+// The Marshaller and the Unmarshaler aren't set, the caller must set them before passing the configuration to New.
+func LineRecordsConfig() Config {
+	return Config{
+		ValueSeparator:     []byte("\t"),
+		ComponentSeparator: []byte(";"),
+		ElementSeparator:   []byte("|"),
+		EscapeChar:         '\\',
+		RecordSeparator:    []byte("\n"),
+	}
+}
@@ -0,0 +1,46 @@
+package engine
+
+import (
+	"testing"
+)
+
+func TestPresets(t *testing.T) {
+	type record struct {
+		Name  string
+		Party party
+		Tags  []string
+		Note  string
+	}
+	// Only the separators of the preset are escaped, the spaces of the text are kept as is.
+	value := record{Name: "Ann Lee, Jr.", Party: party{ID: "1", Agency: 2}, Tags: []string{"x y", "z"}, Note: "a|b"}
+
+	var tests = []struct {
+		config Config
+		expect string
+	}{
+		{
+			config: CSVConfig(),
+			expect: "Ann Lee\\, Jr.,1;2;,x y|z,a\\|b\n",
+		},
+		{
+			config: PipeDelimitedConfig(),
+			expect: "Ann Lee, Jr.|1^2|x y~z|a\\|b\n",
+		},
+		{
+			config: LineRecordsConfig(),
+			expect: "Ann Lee, Jr.\t1;2;\tx y|z\ta\\|b\n",
+		},
+	}
+	for _, tt := range tests {
+		cfg := tt.config
+		cfg.Marshaller, cfg.Unmarshaler = testConfig().Marshaller, testConfig().Unmarshaler
+		e := New[testMeta](testTag[testMeta]{}, cfg)
+		b, err := MarshalAll(e, []record{value})
+		equal(t, nil, err)
+		equal(t, tt.expect, string(b))
+
+		var got record
+		equal(t, nil, e.Unmarshal(b[:len(b)-1], &got))
+		equal(t, value, got)
+	}
+}
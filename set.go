@@ -0,0 +1,138 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrDuplicate is returned when a Set field rejecting duplicates is decoded from a list with repeated elements,
+// see DuplicateRejecter.
+var ErrDuplicate = errors.New("the list has duplicate elements")
+
+// Set is a list of unique elements that keeps the order they were added in. The engine encodes it like a slice
+// and decodes the elements into it dropping the duplicates, see DuplicateRejecter.
+// The zero value is an empty set ready to use.
+type Set[E comparable] struct {
+	elements []E
+	index    map[E]struct{}
+}
+
+// NewSet returns a new set of the elements, the duplicates are dropped.
+func NewSet[E comparable](elements ...E) *Set[E] {
+	s := new(Set[E])
+	for _, e := range elements {
+		s.Add(e)
+	}
+	return s
+}
+
+// Add adds the element to the set and reports whether it wasn't there.
+func (s *Set[E]) Add(e E) bool {
+	if _, ok := s.index[e]; ok {
+		return false
+	}
+	if s.index == nil {
+		s.index = make(map[E]struct{})
+	}
+	s.index[e] = struct{}{}
+	s.elements = append(s.elements, e)
+	return true
+}
+
+// Has reports whether the element is in the set.
+func (s *Set[E]) Has(e E) bool {
+	_, ok := s.index[e]
+	return ok
+}
+
+// Delete removes the element from the set.
+func (s *Set[E]) Delete(e E) {
+	if _, ok := s.index[e]; !ok {
+		return
+	}
+	delete(s.index, e)
+	for i, el := range s.elements {
+		if el == e {
+			s.elements = append(s.elements[:i], s.elements[i+1:]...)
+			break
+		}
+	}
+}
+
+// Elements returns the elements in the order they were added in.
+func (s *Set[E]) Elements() []E {
+	return append([]E(nil), s.elements...)
+}
+
+// Len returns the number of the elements of the set.
+func (s *Set[E]) Len() int {
+	return len(s.elements)
+}
+
+// DuplicateRejecter is the interface implemented by a parsed tag, a *T of the engine Tag,
+// that makes decoding of its Set field fail with ErrDuplicate on repeated elements instead of dropping them.
+type DuplicateRejecter interface {
+	// RejectDuplicates reports whether repeated elements are an error.
+	RejectDuplicates() bool
+}
+
+// set is implemented by a Set for the engine.
+type set interface {
+	// sliceType returns the type of the slice holding the elements, it mustn't use the receiver.
+	sliceType() reflect.Type
+	// slice returns the slice holding the elements.
+	slice() reflect.Value
+	// add adds the element and reports whether it wasn't there.
+	add(e reflect.Value) bool
+	Len() int
+}
+
+var setType = reflect.TypeOf((*set)(nil)).Elem()
+
+func (s *Set[E]) sliceType() reflect.Type {
+	return reflect.TypeOf([]E(nil))
+}
+
+func (s *Set[E]) slice() reflect.Value {
+	return reflect.ValueOf(s.elements)
+}
+
+func (s *Set[E]) add(e reflect.Value) bool {
+	return s.Add(e.Interface().(E))
+}
+
+// setOf returns the type of the slice holding the elements of a Set of the type t.
+func setOf(t reflect.Type) (reflect.Type, bool) {
+	if t.Kind() != reflect.Struct || !reflect.PointerTo(t).Implements(setType) {
+		return nil, false
+	}
+	return reflect.Zero(reflect.PointerTo(t)).Interface().(set).sliceType(), true
+}
+
+// uniqueSetCoders returns the coders of a Set, it's encoded and decoded like the slice of the type t holding its elements.
+func (e *engine[T]) uniqueSetCoders(t reflect.Type, ef encoderFunc[T], df decoderFunc[T]) (encoderFunc[T], decoderFunc[T]) {
+	sef, sdf := e.sliceCoders(t, nil, nil)
+
+	encoder := func(s *encodeState[T], v reflect.Value) error {
+		return sef(s, pointerTo(v).Interface().(set).slice())
+	}
+
+	decoder := func(s *decodeState[T], v reflect.Value) error {
+		elements := reflect.New(t).Elem()
+		if err := sdf(s, elements); err != nil {
+			return err
+		}
+
+		st := pointerTo(v).Interface().(set)
+		for i := 0; i < elements.Len(); i++ {
+			if !st.add(elements.Index(i)) && s.field.unique {
+				s.err = fmt.Errorf("%s: %w: %v", s.Name(), ErrDuplicate, elements.Index(i))
+				return errExist
+			}
+		}
+		return nil
+	}
+
+	return setCoder[T](ef, encoder), setCoder[T](df, decoder)
+}
@@ -0,0 +1,70 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSet(t *testing.T) {
+	s := NewSet("b", "a", "b")
+	equal(t, false, s.Add("a"))
+	equal(t, true, s.Add("c"))
+	s.Delete("a")
+	s.Delete("x")
+	equal(t, []string{"b", "c"}, s.Elements())
+	equal(t, 2, s.Len())
+	equal(t, true, s.Has("c"))
+	equal(t, false, s.Has("a"))
+}
+
+// uniqueMeta rejects the duplicates of its Set field if the value of the tag is "unique", see DuplicateRejecter.
+type uniqueMeta struct {
+	unique bool
+}
+
+func (m *uniqueMeta) parse(tagValue string) (bool, error) {
+	m.unique = tagValue == "unique"
+	return false, nil
+}
+
+func (m *uniqueMeta) RejectDuplicates() bool {
+	return m.unique
+}
+
+func TestSetCoding(t *testing.T) {
+	type record struct {
+		A Set[string]
+		U Set[int] `test:"unique"`
+	}
+	e := newEngineOf[uniqueMeta](listConfig)
+
+	b, err := e.Marshal(record{A: *NewSet("z", "a"), U: *NewSet(3, 1)})
+	equal(t, nil, err)
+	equal(t, "(z~a),(3~1)", string(b))
+
+	var tests = []struct {
+		data   string
+		a      []string
+		u      []int
+		expect error
+	}{
+		{
+			data: "(z~a~z),(3~1)",
+			a:    []string{"z", "a"},
+			u:    []int{3, 1},
+		},
+		{
+			data:   "(z),(3~1~3)",
+			expect: ErrDuplicate,
+		},
+	}
+	for _, tt := range tests {
+		var got record
+		err := e.Unmarshal([]byte(tt.data), &got)
+		equal(t, true, errors.Is(err, tt.expect))
+		if tt.expect == nil {
+			equal(t, tt.a, got.A.Elements())
+			equal(t, tt.u, got.U.Elements())
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+)
+
+type item struct {
+	N, M int
+}
+
+type lists struct {
+	A []string
+	B []*int
+	C [][]int
+	D []item
+	E string
+}
+
+// listConfig wraps the lists with "(" and ")" and separates their elements with "~".
+func listConfig(cfg *Config) {
+	cfg.ElementSeparator = []byte("~")
+	cfg.SliceOpener, cfg.SliceCloser = []byte("("), []byte(")")
+	cfg.EscapeChar = '\\'
+}
+
+func TestSliceEncode(t *testing.T) {
+	e := newTestEngine(listConfig)
+	one, two := 1, 2
+
+	var tests = []struct {
+		value  lists
+		expect string
+	}{
+		{
+			value:  lists{A: []string{"a", "b~c"}, B: []*int{&one, &two}, C: [][]int{{1, 2}, {3}}, D: []item{{1, 2}, {3, 4}}, E: "e"},
+			expect: `(a~b\~c),(1~2),((1~2)~(3)),(1:2~3:4),e`,
+		},
+		{
+			value:  lists{A: []string{}, C: [][]int{{}, nil}},
+			expect: "(),(),(()~()),(),",
+		},
+	}
+	for _, tt := range tests {
+		b, err := e.Marshal(tt.value)
+		equal(t, nil, err)
+		equal(t, tt.expect, string(b))
+	}
+}
+
+func TestSliceDecode(t *testing.T) {
+	e := newTestEngine(listConfig)
+	one, two := 1, 2
+
+	var tests = []struct {
+		data   string
+		expect lists
+	}{
+		{
+			data:   `(a~b\~c),(1~2),((1~2)~(3)),(1:2~3:4),e`,
+			expect: lists{A: []string{"a", "b~c"}, B: []*int{&one, &two}, C: [][]int{{1, 2}, {3}}, D: []item{{1, 2}, {3, 4}}, E: "e"},
+		},
+		{
+			data:   "(),(),(()~(5)),(),x",
+			expect: lists{A: []string{}, B: []*int{}, C: [][]int{{}, {5}}, D: []item{}, E: "x"},
+		},
+		{
+			data:   ",,,,x",
+			expect: lists{E: "x"},
+		},
+	}
+	for _, tt := range tests {
+		var got lists
+		equal(t, nil, e.Unmarshal([]byte(tt.data), &got))
+		equal(t, tt.expect, got)
+	}
+
+	var got lists
+	err := e.Unmarshal([]byte("a~b"), &got)
+	equal(t, true, errors.Is(err, ErrInvalidFormat))
+}
+
+type arrays struct {
+	A [2]string
+	B [3]byte
+	D [2]item
+	C [2][2]int
+}
+
+func TestArrays(t *testing.T) {
+	e := newTestEngine(listConfig)
+
+	value := arrays{A: [2]string{"a", "b"}, B: [3]byte{'x', 'y', 'z'}, D: [2]item{{1, 2}, {3, 4}}, C: [2][2]int{{1, 2}, {3, 4}}}
+	b, err := e.Marshal(value)
+	equal(t, nil, err)
+	equal(t, "(a~b),xyz,(1:2~3:4),((1~2)~(3~4))", string(b))
+
+	var got arrays
+	equal(t, nil, e.Unmarshal(b, &got))
+	equal(t, value, got)
+
+	var tests = []struct {
+		data   string
+		expect error
+	}{
+		{
+			data:   "(a~b~c),xyz,(),",
+			expect: ErrArrayLength,
+		},
+		{
+			data:   "(a~b),xy,(),",
+			expect: ErrArrayLength,
+		},
+		{
+			data:   "(a~b),xyz,(1:2),",
+			expect: ErrArrayLength,
+		},
+		{
+			data:   "(a~b),xyz,(1:2~3:4),",
+			expect: nil,
+		},
+	}
+	for _, tt := range tests {
+		var got arrays
+		err := e.Unmarshal([]byte(tt.data), &got)
+		equal(t, true, errors.Is(err, tt.expect))
+	}
+}
+
+func TestElementOpeners(t *testing.T) {
+	type record struct {
+		Items []item
+		E     string
+	}
+
+	var tests = []struct {
+		count  bool
+		value  record
+		expect string
+	}{
+		{
+			value:  record{Items: []item{{1, 2}, {3, 4}}, E: "e"},
+			expect: "(<1:2>~<3:4>),e",
+		},
+		{
+			value:  record{Items: []item{{5, 6}}},
+			expect: "(<5:6>),",
+		},
+		{
+			count:  true,
+			value:  record{Items: []item{{1, 2}, {3, 4}}, E: "e"},
+			expect: "(2~<1:2>~<3:4>),e",
+		},
+		{
+			count:  true,
+			value:  record{Items: []item{}, E: "x"},
+			expect: "(0~),x",
+		},
+	}
+	for _, tt := range tests {
+		e := newTestEngine(func(cfg *Config) {
+			listConfig(cfg)
+			cfg.ElementOpener, cfg.ElementCloser = []byte("<"), []byte(">")
+			cfg.CountElements = tt.count
+		})
+		b, err := e.Marshal(tt.value)
+		equal(t, nil, err)
+		equal(t, tt.expect, string(b))
+
+		var got record
+		equal(t, nil, e.Unmarshal(b, &got))
+		equal(t, tt.value, got)
+	}
+
+	// The number of the elements must match the count.
+	e := newTestEngine(func(cfg *Config) {
+		listConfig(cfg)
+		cfg.ElementOpener, cfg.ElementCloser = []byte("<"), []byte(">")
+		cfg.CountElements = true
+	})
+	var got record
+	equal(t, true, errors.Is(e.Unmarshal([]byte("(2~<1:2>~<3:4>~<5:6>),e"), &got), ErrInvalidFormat))
+}
+
+func TestEmptySlices(t *testing.T) {
+	type record struct {
+		A []int  `test:"omitempty"`
+		D []item `test:"omitempty"`
+		E string
+	}
+
+	var tests = []struct {
+		keep   bool
+		value  record
+		expect string
+	}{
+		{
+			value:  record{A: []int{}, D: []item{}, E: "e"},
+			expect: "e",
+		},
+		{
+			keep:   true,
+			value:  record{A: []int{}, D: []item{}, E: "e"},
+			expect: "(),(),e",
+		},
+		{
+			keep:   true,
+			value:  record{E: "e"},
+			expect: "e",
+		},
+	}
+	for _, tt := range tests {
+		e := newTestEngine(func(cfg *Config) {
+			listConfig(cfg)
+			cfg.KeepEmptySlices = tt.keep
+		})
+		b, err := e.Marshal(tt.value)
+		equal(t, nil, err)
+		equal(t, tt.expect, string(b))
+	}
+
+	type absent struct {
+		A []int
+		D []item
+		E string
+	}
+	var decodeTests = []struct {
+		empty   bool
+		wrapped bool
+		data    string
+		expect  absent
+	}{
+		{
+			data:   ",,e",
+			expect: absent{E: "e"},
+		},
+		{
+			empty:  true,
+			data:   ",,e",
+			expect: absent{A: []int{}, D: []item{}, E: "e"},
+		},
+		{
+			wrapped: true,
+			data:    "(),(),e",
+			expect:  absent{A: []int{}, D: []item{}, E: "e"},
+		},
+	}
+	for _, tt := range decodeTests {
+		e := newTestEngine(func(cfg *Config) {
+			cfg.ElementSeparator = []byte("~")
+			if tt.wrapped {
+				cfg.SliceOpener, cfg.SliceCloser = []byte("("), []byte(")")
+			}
+			cfg.EmptySliceWhenDecoding = tt.empty
+		})
+		var got absent
+		equal(t, nil, e.Unmarshal([]byte(tt.data), &got))
+		equal(t, tt.expect, got)
+	}
+}
@@ -0,0 +1,138 @@
+package engine
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// ErrNoSeparator is returned when none of the candidate separators is found in the data, see Sniff.
+var ErrNoSeparator = errors.New("none of the separators is found in the data")
+
+// DefaultSniffCandidates are the separators Sniff chooses from by default:
+// comma, semicolon, tab and pipe.
+var DefaultSniffCandidates = [][]byte{[]byte(","), []byte(";"), []byte("\t"), []byte("|")}
+
+// sniffSize is the number of bytes Decoder.Sniff inspects at most.
+const sniffSize = 4096
+
+// Sniff detects the ValueSeparator of the data from the candidates, DefaultSniffCandidates if there are none,
+// and returns the engine e decoding with it, which shares the Tag and the caches with e.
+// The separator is the candidate found the most times in the first record of the data, the first one of them
+// on a tie. The first record ends at the RecordSeparator, or at the end of the line if there is none.
+// If Config.Separators is set, its first separator is replaced instead.
+// It returns ErrUnsupported if e doesn't have the Sniff method.
+func Sniff(e Engine, data []byte, candidates ...[]byte) (Engine, error) {
+	if w, ok := e.(*wrapped); ok {
+		// The separator is detected in the data the inner engine decodes.
+		if w.m.BeforeUnmarshal != nil {
+			var err error
+			if data, err = w.m.BeforeUnmarshal(data); err != nil {
+				return nil, err
+			}
+		}
+		inner, err := Sniff(w.inner, data, candidates...)
+		if err != nil {
+			return nil, err
+		}
+		return w.rewrap(inner), nil
+	}
+	s, ok := implementation[sniffer](e)
+	if !ok {
+		return nil, unsupported(e, "Sniff")
+	}
+	return s.Sniff(data, candidates...)
+}
+
+// sniffer is implemented by the engines detecting separators, see Sniff.
+type sniffer interface {
+	Sniff(data []byte, candidates ...[]byte) (Engine, error)
+}
+
+// Sniff returns the engine decoding with the separator detected in the data, see the function Sniff.
+func (e *engine[T]) Sniff(data []byte, candidates ...[]byte) (Engine, error) {
+	se, err := e.sniff(data, candidates)
+	if err != nil {
+		return nil, err
+	}
+	return se, nil
+}
+
+func (e *engine[T]) sniff(data []byte, candidates [][]byte) (*engine[T], error) {
+	s := e.load()
+
+	separator := s.detectSeparator(s.firstRecord(data), candidates)
+	if separator == nil {
+		return nil, fmt.Errorf("%s: %w", e.Name(), ErrNoSeparator)
+	}
+
+	cfg := s.config.clone()
+	if len(cfg.Separators) != 0 {
+		cfg.Separators[0] = separator
+	} else {
+		cfg.ValueSeparator = separator
+	}
+
+	se := e.withDialect(e.dialect)
+	se.profiles = e.profiles
+	se.current.Store(newSettings(cfg))
+	return se, nil
+}
+
+// sniffer is sniff for the Decoder.
+func (e *engine[T]) sniffer(data []byte, candidates [][]byte) (streamer, error) {
+	se, err := e.sniff(data, candidates)
+	if err != nil {
+		return nil, err
+	}
+	return se, nil
+}
+
+// firstRecord returns the first record of the data.
+func (s *settings) firstRecord(data []byte) []byte {
+	end := s.recordSeparator
+	if len(end) == 0 {
+		end = []byte("\n")
+	}
+	if i := bytes.Index(data, end); i >= 0 {
+		data = data[:i]
+	}
+	return bytes.TrimSuffix(data, []byte("\r"))
+}
+
+// detectSeparator returns the candidate found the most times in the record, nil if none of them is found.
+// Escaped bytes are skipped, see Config.EscapeChar.
+func (s *settings) detectSeparator(record []byte, candidates [][]byte) []byte {
+	if len(candidates) == 0 {
+		candidates = DefaultSniffCandidates
+	}
+
+	counts := make([]int, len(candidates))
+	for i := 0; i < len(record); {
+		if s.escape != 0 && record[i] == s.escape {
+			i += 2
+			continue
+		}
+
+		n := 1
+		for j, c := range candidates {
+			if len(c) != 0 && bytes.HasPrefix(record[i:], c) {
+				counts[j]++
+				n = len(c)
+				break
+			}
+		}
+		i += n
+	}
+
+	best := -1
+	for i, n := range counts {
+		if n > 0 && (best < 0 || n > counts[best]) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return nil
+	}
+	return candidates[best]
+}
@@ -0,0 +1,88 @@
+package engine
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestSniff(t *testing.T) {
+	type record struct{ A, B, C string }
+	e := newTestEngine(func(cfg *Config) { cfg.EscapeChar = '\\' })
+
+	var tests = []struct {
+		data       string
+		candidates [][]byte
+		expect     record
+		err        error
+	}{
+		{
+			data:   "a;b;c",
+			expect: record{A: "a", B: "b", C: "c"},
+		},
+		{
+			// The separator found the most times wins.
+			data:   "a,x|b|c",
+			expect: record{A: "a,x", B: "b", C: "c"},
+		},
+		{
+			// The first candidate wins a tie.
+			data:   "a;b\tc",
+			expect: record{A: "a", B: "b\tc"},
+		},
+		{
+			// Escaped bytes aren't counted.
+			data:   `a\;\;b,c`,
+			expect: record{A: "a;;b", B: "c"},
+		},
+		{
+			// Only the first record is inspected.
+			data:   "a|b\n;;;;",
+			expect: record{A: "a", B: "b\n;;;;"},
+		},
+		{
+			data:       "a::b::c",
+			candidates: [][]byte{[]byte(":"), []byte("::")},
+			expect:     record{A: "a", C: "b"},
+		},
+		{
+			data:       "a::b::c",
+			candidates: [][]byte{[]byte("::"), []byte(":")},
+			expect:     record{A: "a", B: "b", C: "c"},
+		},
+		{
+			data: "abc",
+			err:  ErrNoSeparator,
+		},
+	}
+	for _, tt := range tests {
+		se, err := Sniff(e, []byte(tt.data), tt.candidates...)
+		equal(t, true, errors.Is(err, tt.err))
+		if tt.err != nil {
+			continue
+		}
+		var got record
+		equal(t, nil, se.Unmarshal([]byte(tt.data), &got))
+		equal(t, tt.expect, got)
+	}
+
+	_, err := Sniff(foreignEngine{e}, []byte("a;1"))
+	equal(t, true, errors.Is(err, ErrUnsupported))
+}
+
+func TestDecoderSniff(t *testing.T) {
+	e := newTestEngine(func(cfg *Config) { cfg.RecordSeparator = []byte("\n") })
+
+	dec := NewDecoder(e, strings.NewReader("a|1\nb|2\n"))
+	equal(t, nil, dec.Sniff())
+	got, err := decodeAll[streamed](dec)
+	equal(t, io.EOF, err)
+	equal(t, []streamed{{A: "a", B: 1}, {A: "b", B: 2}}, got)
+
+	dec = NewDecoder(e, strings.NewReader("a 1\n"))
+	equal(t, true, errors.Is(dec.Sniff(), ErrNoSeparator))
+
+	dec = NewDecoder(foreignEngine{e}, strings.NewReader("a|1\n"))
+	equal(t, true, errors.Is(dec.Sniff(), ErrUnsupported))
+}
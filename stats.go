@@ -0,0 +1,104 @@
+package engine
+
+import (
+	"reflect"
+)
+
+// Stats collects statistics of the fields of the values decoded by a Decoder, see Decoder.CollectStats.
+type Stats struct {
+	// Fields are the statistics of the fields by their paths, the names of the fields they are nested in
+	// and their own names separated by dots, e.g. "Buyer.ID", see UnmarshalSpans.
+	// Composite fields have no statistics, their components do.
+	Fields map[string]*FieldStats
+}
+
+// FieldStats are the statistics of a field.
+type FieldStats struct {
+	// Count is the number of values of the field, empty ones included.
+	Count int
+	// Nulls is the number of empty values of the field.
+	Nulls int
+	// MinLen and MaxLen are the least and the greatest lengths of non-empty values of the field
+	// as written by Tag.Decode.
+	MinLen, MaxLen int
+	// Min and Max are the least and the greatest decoded values of a field of a numeric or string type,
+	// they are nil for other fields.
+	Min, Max any
+}
+
+// NewStats returns empty statistics.
+func NewStats() *Stats {
+	return &Stats{Fields: make(map[string]*FieldStats)}
+}
+
+// field returns the statistics of the field, creating them if needed.
+func (st *Stats) field(name string) *FieldStats {
+	fs, ok := st.Fields[name]
+	if !ok {
+		fs = new(FieldStats)
+		st.Fields[name] = fs
+	}
+	return fs
+}
+
+// null counts an empty value of the field.
+func (st *Stats) null(name string) {
+	fs := st.field(name)
+	fs.Count++
+	fs.Nulls++
+}
+
+// add counts a value of the field of the length n decoded into v.
+func (st *Stats) add(name string, n int, v reflect.Value) {
+	fs := st.field(name)
+	if fs.Count++; fs.Count-fs.Nulls == 1 {
+		fs.MinLen, fs.MaxLen = n, n
+	} else if n < fs.MinLen {
+		fs.MinLen = n
+	} else if n > fs.MaxLen {
+		fs.MaxLen = n
+	}
+
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+
+	if !ordered(v) {
+		return
+	}
+	if fs.Min == nil || less(v, reflect.ValueOf(fs.Min)) {
+		fs.Min = v.Interface()
+	}
+	if fs.Max == nil || less(reflect.ValueOf(fs.Max), v) {
+		fs.Max = v.Interface()
+	}
+}
+
+// ordered reports whether the statistics have Min and Max for the value.
+func ordered(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.String:
+		return true
+	}
+	return false
+}
+
+// less reports whether the value a is less than the value b of the same kind.
+func less(a, b reflect.Value) bool {
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return a.Int() < b.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return a.Uint() < b.Uint()
+	case reflect.Float32, reflect.Float64:
+		return a.Float() < b.Float()
+	case reflect.String:
+		return a.String() < b.String()
+	}
+	return false
+}
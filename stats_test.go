@@ -0,0 +1,38 @@
+package engine
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	type trade struct {
+		N      int
+		Buyer  party
+		Seller party
+		Tags   []int
+	}
+	e := newTestEngine(func(cfg *Config) {
+		cfg.RecordSeparator, cfg.ElementSeparator = []byte("\n"), []byte("~")
+	})
+	data := "1,abc:2:x,d:4:,1~2\n5,:3:yy,ef:1:z,3\n-3,zz::x,g:5:,\n"
+
+	st := NewStats()
+	dec := NewDecoder(e, strings.NewReader(data))
+	dec.CollectStats(st)
+	_, err := decodeAll[trade](dec)
+	equal(t, io.EOF, err)
+
+	// The fields of the same type nested in different fields have their own statistics.
+	equal(t, map[string]*FieldStats{
+		"N":             {Count: 3, MinLen: 1, MaxLen: 2, Min: -3, Max: 5},
+		"Buyer.ID":      {Count: 3, Nulls: 1, MinLen: 2, MaxLen: 3, Min: "abc", Max: "zz"},
+		"Buyer.Agency":  {Count: 3, Nulls: 1, MinLen: 1, MaxLen: 1, Min: 2, Max: 3},
+		"Buyer.Code":    {Count: 3, MinLen: 1, MaxLen: 2, Min: "x", Max: "yy"},
+		"Seller.ID":     {Count: 3, MinLen: 1, MaxLen: 2, Min: "d", Max: "g"},
+		"Seller.Agency": {Count: 3, MinLen: 1, MaxLen: 1, Min: 1, Max: 5},
+		"Seller.Code":   {Count: 3, Nulls: 2, MinLen: 1, MaxLen: 1, Min: "z", Max: "z"},
+		"Tags":          {Count: 3, Nulls: 1, MinLen: 1, MaxLen: 3},
+	}, st.Fields)
+}
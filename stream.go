@@ -0,0 +1,318 @@
+package engine
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand"
+	"reflect"
+)
+
+// An Encoder writes encoded values to an output stream.
+type Encoder struct {
+	w       io.Writer
+	e       streamer
+	framing Framing
+	err     error // the engine can't encode a stream, see NewEncoder
+}
+
+// streamer is implemented by the engine to encode and decode values of a stream.
+type streamer interface {
+	Name() string
+	Marshal(v any) ([]byte, error)
+	encodeTo(w io.Writer, v any) error
+	readValue(r io.ByteReader) ([]byte, error)
+	readFrame(r *bufio.Reader, f Framing) ([]byte, error)
+	unmarshalWith(data []byte, v any, opts decodeOptions) error
+	sniffer(data []byte, candidates [][]byte) (streamer, error)
+	splitHeader(record []byte) ([]string, error)
+	bindColumns(t reflect.Type, columns []string, opts HeaderOptions) ([]int, error)
+	reorderColumns(record []byte, binding []int) ([]byte, error)
+}
+
+// NewEncoder returns a new encoder of the engine e that writes to w.
+// The encoder of an engine without the NewEncoder method returns ErrUnsupported.
+func NewEncoder(e Engine, w io.Writer) *Encoder {
+	if n, ok := e.(interface{ NewEncoder(w io.Writer) *Encoder }); ok {
+		return n.NewEncoder(w)
+	}
+	return &Encoder{w: w, err: unsupported(e, "NewEncoder")}
+}
+
+// NewEncoder returns a new encoder that writes to w.
+func (e *engine[T]) NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w, e: e}
+}
+
+// Reset makes the encoder write to w, keeping its settings, so that it can be reused.
+func (enc *Encoder) Reset(w io.Writer) {
+	enc.w = w
+}
+
+// UseFraming makes the encoder wrap every value in a frame of the framing f instead of following it
+// with the RecordSeparator. A nil f restores the RecordSeparator.
+func (enc *Encoder) UseFraming(f Framing) {
+	enc.framing = f
+}
+
+// Encode writes the encoded value v followed by the RecordSeparator to the stream, or wrapped in a frame,
+// see UseFraming. If the writer has a Flush method, like bufio.Writer, it is flushed after the value is written.
+func (enc *Encoder) Encode(v any) error {
+	if enc.err != nil {
+		return enc.err
+	}
+	if enc.framing == nil {
+		if err := enc.e.encodeTo(enc.w, v); err != nil {
+			return err
+		}
+	} else {
+		data, err := enc.e.Marshal(v)
+		if err != nil {
+			return err
+		}
+		if err = enc.framing.WriteFrame(enc.w, data); err != nil {
+			return err
+		}
+	}
+
+	if f, ok := enc.w.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// encodeTo encodes the value v and writes the encoded data to w.
+func (e *engine[T]) encodeTo(w io.Writer, v any) error {
+	s := e.newEncodeState()
+	defer encodeStatePool.Put(s)
+
+	if s.marshal(v); s.err != nil {
+		return s.err
+	}
+
+	s.Write(s.recordSeparator)
+	_, err := s.WriteTo(w)
+	return err
+}
+
+// A Decoder reads and decodes values from an input stream.
+type Decoder struct {
+	r    *bufio.Reader
+	e    streamer
+	base streamer // the engine of the decoder, e may be sniffed from the stream
+	err  error    // the engine can't decode a stream, see NewDecoder
+
+	head    int // the number of values to decode, negative means all of them
+	decoded int
+	sample  float64
+	rand    *rand.Rand
+	stats   *Stats
+	framing Framing
+
+	columns  []string // the names of the columns read by ReadHeader
+	header   HeaderOptions
+	bindings map[reflect.Type][]int // the columns of the fields of the types decoded, see bindColumns
+}
+
+// NewDecoder returns a new decoder of the engine e that reads from r.
+// The decoder of an engine without the NewDecoder method returns ErrUnsupported.
+func NewDecoder(e Engine, r io.Reader) *Decoder {
+	if n, ok := e.(interface{ NewDecoder(r io.Reader) *Decoder }); ok {
+		return n.NewDecoder(r)
+	}
+	return &Decoder{err: unsupported(e, "NewDecoder")}
+}
+
+// NewDecoder returns a new decoder that reads from r.
+// The decoder introduces its own buffering and may read data from r beyond the values requested.
+func (e *engine[T]) NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: bufio.NewReader(r), e: e, base: e, head: -1, sample: 1}
+}
+
+// Reset makes the decoder read from r, reusing its buffer, so that it can be reused, e.g. from a sync.Pool.
+// The settings of the decoder are kept, while the state of the previous stream is discarded: the values counted
+// by Head, the separator detected by Sniff and the header read by ReadHeader.
+func (dec *Decoder) Reset(r io.Reader) {
+	if dec.err != nil {
+		return
+	}
+	dec.r.Reset(r)
+	dec.e, dec.decoded = dec.base, 0
+	dec.columns, dec.header, dec.bindings = nil, HeaderOptions{}, nil
+}
+
+// Head makes the decoder decode only the first n values of the stream, then Decode returns io.EOF.
+func (dec *Decoder) Head(n int) {
+	dec.head = n
+}
+
+// Sample makes the decoder decode every value with the probability p and skip the others without decoding them.
+// The values are chosen with r, or with the default source of math/rand if r is nil.
+// Combined with Head, the decoder decodes n values of the sample.
+func (dec *Decoder) Sample(p float64, r *rand.Rand) {
+	dec.sample, dec.rand = p, r
+}
+
+// CollectStats makes the decoder collect the statistics of the fields of the values it decodes into stats.
+func (dec *Decoder) CollectStats(stats *Stats) {
+	dec.stats = stats
+}
+
+// UseFraming makes the decoder read every value from a frame of the framing f instead of up to
+// the RecordSeparator. A nil f restores the RecordSeparator.
+func (dec *Decoder) UseFraming(f Framing) {
+	dec.framing = f
+}
+
+// Sniff detects the ValueSeparator of the stream from the candidates, like the function Sniff, inspecting
+// its beginning without consuming it, and makes the decoder decode the values of the stream with it.
+func (dec *Decoder) Sniff(candidates ...[]byte) error {
+	if dec.err != nil {
+		return dec.err
+	}
+
+	data, err := dec.r.Peek(sniffSize)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return err
+	}
+
+	e, err := dec.e.sniffer(data, candidates)
+	if err != nil {
+		return err
+	}
+	dec.e = e
+	return nil
+}
+
+// Decode reads the next value from the stream and stores it in the value pointed to by v.
+// Empty values and values out of the sample are skipped. At the end of the stream, Decode returns io.EOF.
+func (dec *Decoder) Decode(v any) error {
+	if dec.err != nil {
+		return dec.err
+	}
+	if dec.head >= 0 && dec.decoded >= dec.head {
+		return io.EOF
+	}
+
+	for {
+		data, err := dec.readValue()
+		if len(data) != 0 && dec.sampled() {
+			dec.decoded++
+			if data, err = dec.bind(data, v); err != nil {
+				return err
+			}
+			return dec.e.unmarshalWith(data, v, decodeOptions{stats: dec.stats, noCopy: true})
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// readValue reads the next value of the stream, see UseFraming.
+func (dec *Decoder) readValue() ([]byte, error) {
+	if dec.framing != nil {
+		return dec.e.readFrame(dec.r, dec.framing)
+	}
+	return dec.e.readValue(dec.r)
+}
+
+// sampled reports whether the next value of the stream is in the sample.
+func (dec *Decoder) sampled() bool {
+	switch {
+	case dec.sample >= 1:
+		return true
+	case dec.rand != nil:
+		return dec.rand.Float64() < dec.sample
+	default:
+		return rand.Float64() < dec.sample
+	}
+}
+
+// readValue reads the next value of a stream with the current settings of the engine.
+func (e *engine[T]) readValue(r io.ByteReader) ([]byte, error) {
+	return e.load().readValue(r)
+}
+
+// readFrame reads the next value of a stream from a frame of the framing f,
+// the value longer than Config.MaxRecordSize is skipped.
+func (e *engine[T]) readFrame(r *bufio.Reader, f Framing) ([]byte, error) {
+	return f.ReadFrame(r, e.load().maxRecordSize)
+}
+
+// readValue reads the next value of a stream: up to the RecordSeparator, which is consumed but not returned,
+// or up to the StructCloser balancing the StructOpener the value begins with, or up to the end of the stream.
+// Bytes following the EscapeChar never end a value. The io.EOF is returned with the last value.
+// A value longer than Config.MaxRecordSize isn't buffered: it is skipped up to its end and ErrLimit is returned.
+func (s *settings) readValue(r io.ByteReader) ([]byte, error) {
+	var (
+		value   []byte
+		depth   int
+		escaped bool
+		skipped bool
+	)
+
+	wrapped := len(s.recordSeparator) == 0 && s.wrap && len(s.structOpener) != 0 && len(s.structCloser) != 0
+
+	// The RecordSeparator being read may follow the bytes of a value of the maximum size.
+	limit := s.maxRecordSize + max(len(s.recordSeparator)-1, 0)
+	// Only the bytes that may begin the end of a skipped value are kept to find it.
+	keep := max(len(s.recordSeparator), len(s.structOpener), len(s.structCloser)) - 1
+
+	for {
+		c, err := r.ReadByte()
+		if err != nil {
+			// The last value isn't followed by the RecordSeparator.
+			if err == io.EOF && (skipped || s.maxRecordSize > 0 && len(value) > s.maxRecordSize) {
+				return nil, s.recordLimitError()
+			}
+			if skipped {
+				return nil, err
+			}
+			return value, err
+		}
+
+		// Whitespace between wrapped values isn't a part of them.
+		if wrapped && len(value) == 0 && (c == ' ' || c == '\t' || c == '\r' || c == '\n') {
+			continue
+		}
+
+		value = append(value, c)
+
+		switch {
+		case escaped:
+			escaped = false
+		case s.escape != 0 && c == s.escape:
+			escaped = true
+		case len(s.recordSeparator) != 0:
+			if bytes.HasSuffix(value, s.recordSeparator) {
+				if skipped {
+					return nil, s.recordLimitError()
+				}
+				return value[:len(value)-len(s.recordSeparator)], nil
+			}
+		case wrapped && bytes.HasSuffix(value, s.structCloser) && depth != 0:
+			if depth--; depth == 0 {
+				if skipped {
+					return nil, s.recordLimitError()
+				}
+				return value, nil
+			}
+		case wrapped && bytes.HasSuffix(value, s.structOpener):
+			depth++
+		}
+
+		if s.maxRecordSize > 0 && (skipped || len(value) > limit) {
+			skipped = true
+			if len(value) > keep {
+				value = append(value[:0], value[len(value)-keep:]...)
+			}
+		}
+	}
+}
+
+// recordLimitError returns the error of a value of a stream longer than Config.MaxRecordSize.
+func (s *settings) recordLimitError() error {
+	return fmt.Errorf("%w: a record of more than %d bytes", ErrLimit, s.maxRecordSize)
+}
@@ -0,0 +1,190 @@
+package engine
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+type streamed struct {
+	A string
+	B int
+}
+
+func TestEncoder(t *testing.T) {
+	e := newTestEngine(func(cfg *Config) { cfg.RecordSeparator = []byte("\n") })
+
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+	enc := NewEncoder(e, w)
+	for _, v := range []streamed{{"a", 1}, {"b", 2}} {
+		equal(t, nil, enc.Encode(v))
+		// The bufio.Writer is flushed after every value.
+		equal(t, 0, w.Buffered())
+	}
+	equal(t, "a,1\nb,2\n", buf.String())
+
+	// A value failing to encode writes nothing.
+	equal(t, true, enc.Encode(make(chan int)) != nil)
+	equal(t, "a,1\nb,2\n", buf.String())
+
+	// Only the Encoder writes the RecordSeparator.
+	b, err := e.Marshal(streamed{"a", 1})
+	equal(t, nil, err)
+	equal(t, "a,1", string(b))
+
+	// An engine without the NewEncoder method can't encode a stream.
+	err = NewEncoder(foreignEngine{e}, w).Encode(streamed{"c", 3})
+	equal(t, true, errors.Is(err, ErrUnsupported))
+	equal(t, "a,1\nb,2\n", buf.String())
+}
+
+// decodeAll decodes the values of the stream until an error, it returns the values and the error.
+func decodeAll[V any](dec *Decoder) ([]V, error) {
+	var values []V
+	for {
+		var v V
+		if err := dec.Decode(&v); err != nil {
+			return values, err
+		}
+		values = append(values, v)
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	type inner struct{ X, Y string }
+	type wrapped struct {
+		A string
+		I inner
+	}
+
+	var tests = []struct {
+		configure func(cfg *Config)
+		data      string
+		expect    any
+	}{
+		{
+			configure: func(cfg *Config) { cfg.RecordSeparator = []byte("\n") },
+			data:      "a,1\n\nb,2\nc,3",
+			expect:    []streamed{{"a", 1}, {"b", 2}, {"c", 3}},
+		},
+		{
+			configure: func(cfg *Config) { cfg.RecordSeparator, cfg.EscapeChar = []byte("\n"), '\\' },
+			data:      "a\\\nb,1\n",
+			expect:    []streamed{{"a\nb", 1}},
+		},
+		{
+			configure: func(cfg *Config) {
+				cfg.StructOpener, cfg.StructCloser, cfg.UnwrapWhenDecoding = []byte("{"), []byte("}"), true
+			},
+			data:   " {a,{x:y}}\n{b,{p:q}} ",
+			expect: []wrapped{{"a", inner{"x", "y"}}, {"b", inner{"p", "q"}}},
+		},
+	}
+	for _, tt := range tests {
+		e := newTestEngine(tt.configure)
+		dec := NewDecoder(e, strings.NewReader(tt.data))
+
+		var got any
+		var err error
+		switch tt.expect.(type) {
+		case []streamed:
+			got, err = decodeAll[streamed](dec)
+		case []wrapped:
+			got, err = decodeAll[wrapped](dec)
+		}
+		equal(t, io.EOF, err)
+		equal(t, tt.expect, got)
+	}
+
+	// A value failing to decode doesn't stop the stream.
+	e := newTestEngine(func(cfg *Config) { cfg.RecordSeparator = []byte("\n") })
+	dec := NewDecoder(e, strings.NewReader("a,x\nb,2\n"))
+	var v streamed
+	equal(t, true, dec.Decode(&v) != nil)
+	equal(t, nil, dec.Decode(&v))
+	equal(t, streamed{"b", 2}, v)
+
+	// An engine without the NewDecoder method can't decode a stream.
+	err := NewDecoder(foreignEngine{e}, strings.NewReader("c,3\n")).Decode(&v)
+	equal(t, true, errors.Is(err, ErrUnsupported))
+}
+
+func TestDecoderSample(t *testing.T) {
+	type record struct{ N int }
+	e := newTestEngine(func(cfg *Config) { cfg.RecordSeparator = []byte("\n") })
+	data := "1\n2\n3\n4\n5\n6\n7\n8\n9\n"
+
+	var tests = []struct {
+		head   int
+		sample float64
+		expect []record
+	}{
+		{
+			head:   3,
+			sample: 1,
+			expect: []record{{1}, {2}, {3}},
+		},
+		{
+			head:   -1,
+			sample: 0.5,
+			expect: []record{{4}, {5}, {7}, {8}, {9}},
+		},
+		{
+			head:   2,
+			sample: 0.5,
+			expect: []record{{4}, {5}},
+		},
+		{
+			head:   -1,
+			sample: 0,
+			expect: nil,
+		},
+	}
+	for _, tt := range tests {
+		dec := NewDecoder(e, strings.NewReader(data))
+		dec.Head(tt.head)
+		dec.Sample(tt.sample, rand.New(rand.NewSource(1)))
+
+		got, err := decodeAll[record](dec)
+		equal(t, io.EOF, err)
+		equal(t, tt.expect, got)
+	}
+}
+
+func TestReset(t *testing.T) {
+	e := newTestEngine(func(cfg *Config) { cfg.RecordSeparator = []byte("\n") })
+
+	var a, b bytes.Buffer
+	enc := NewEncoder(e, &a)
+	enc.UseFraming(STXETX)
+	equal(t, nil, enc.Encode(streamed{"a", 1}))
+	enc.Reset(&b)
+	equal(t, nil, enc.Encode(streamed{"b", 2}))
+	equal(t, "\x02a,1\x03", a.String())
+	equal(t, "\x02b,2\x03", b.String())
+
+	// The state of the previous stream is discarded, the settings are kept.
+	dec := NewDecoder(e, strings.NewReader("B|A\n1|a\n2|b\n3|c\n"))
+	dec.Head(2)
+	equal(t, nil, dec.Sniff())
+	_, err := dec.ReadHeader(HeaderOptions{})
+	equal(t, nil, err)
+	got, err := decodeAll[streamed](dec)
+	equal(t, io.EOF, err)
+	equal(t, []streamed{{"a", 1}, {"b", 2}}, got)
+
+	dec.Reset(strings.NewReader("x,1\ny,2\nz,3\n"))
+	got, err = decodeAll[streamed](dec)
+	equal(t, io.EOF, err)
+	equal(t, []streamed{{"x", 1}, {"y", 2}}, got)
+
+	dec = NewDecoder(foreignEngine{e}, strings.NewReader(""))
+	dec.Reset(strings.NewReader("x,1\n"))
+	var v streamed
+	equal(t, true, errors.Is(dec.Decode(&v), ErrUnsupported))
+}
@@ -0,0 +1,35 @@
+// Code generated by format-engine. DO NOT EDIT.
+
+package syslogtag
+
+import "reflect"
+
+// Marshaller is the interface implemented by types that can marshal themselves into valid SYSLOGTAG.
+type Marshaller interface {
+	MarshalSYSLOGTAG() ([]byte, error)
+}
+
+// IsMarshaller attempts to cast the value to SYSLOGTAG Marshaller interface,
+// if so, returns a marshal function.
+func (e engineTag) IsMarshaller(rv reflect.Value) (func() ([]byte, error), bool) {
+	if i, ok := rv.Interface().(Marshaller); ok {
+		return i.MarshalSYSLOGTAG, ok
+	}
+
+	return nil, false
+}
+
+// Unmarshaler is the interface implemented by types that can unmarshal SYSLOGTAG description of themselves.
+type Unmarshaler interface {
+	UnmarshalSYSLOGTAG([]byte) error
+}
+
+// IsUnmarshaler attempts to cast the value to SYSLOGTAG Unmarshaler interface,
+// if so, returns an unmarshal function.
+func (e engineTag) IsUnmarshaler(rv reflect.Value) (func([]byte) error, bool) {
+	if i, ok := rv.Interface().(Unmarshaler); ok {
+		return i.UnmarshalSYSLOGTAG, ok
+	}
+
+	return nil, false
+}
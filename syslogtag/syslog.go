@@ -0,0 +1,140 @@
+// Package syslogtag implements encoding and decoding of RFC 5424 syslog structured data,
+// SD-ELEMENTs of the form [id name="value" ...].
+//
+// Struct fields are bound to SD-PARAMs with tags like `syslog:"ip"` or `syslog:"user,omitempty"`,
+// an untagged field is bound to the parameter named as the field.
+package syslogtag
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/gromey/format-engine"
+)
+
+var (
+	ErrInvalidName     = errors.New("invalid SD-ID or PARAM-NAME")
+	ErrElementNotFound = errors.New("SD-ELEMENT not found")
+	ErrInvalidElement  = errors.New("SD-ELEMENT has an invalid format")
+)
+
+// Marshal encodes the value v as an SD-ELEMENT with the given SD-ID.
+func Marshal(id string, v any) ([]byte, error) {
+	if !validName(id) {
+		return nil, fmt.Errorf("%s: %w: %q", engine.NameOf(syslog), ErrInvalidName, id)
+	}
+
+	params, err := syslog.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, len(id)+len(params)+2)
+	out = append(append(out, '['), id...)
+	out = append(out, params...)
+	return append(out, ']'), nil
+}
+
+// Unmarshal finds the SD-ELEMENT with the given SD-ID in the structured data
+// and stores its parameters in the value pointed to by v.
+func Unmarshal(data []byte, id string, v any) error {
+	params, err := element(data, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", engine.NameOf(syslog), err)
+	}
+	return syslog.Unmarshal(params, v)
+}
+
+// element returns the parameters of the SD-ELEMENT with the given SD-ID.
+func element(data []byte, id string) ([]byte, error) {
+	for len(data) != 0 {
+		if data[0] != '[' {
+			return nil, ErrInvalidElement
+		}
+
+		end := elementEnd(data)
+		if end < 0 {
+			return nil, ErrInvalidElement
+		}
+
+		body := data[1:end]
+		name, params, _ := bytes.Cut(body, []byte(" "))
+		if string(name) == id {
+			return params, nil
+		}
+
+		data = data[end+1:]
+	}
+
+	return nil, ErrElementNotFound
+}
+
+// elementEnd returns the index of the closing bracket of the SD-ELEMENT at the beginning of data,
+// skipping brackets inside quoted and escaped parameter values.
+func elementEnd(data []byte) int {
+	var quoted bool
+	for i := 1; i < len(data); i++ {
+		switch data[i] {
+		case '\\':
+			if quoted {
+				i++
+			}
+		case '"':
+			quoted = !quoted
+		case ']':
+			if !quoted {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// validName reports whether the name is a valid SD-NAME:
+// 1 to 32 printable US-ASCII characters except '=', ' ', ']' and '"'.
+func validName(name string) bool {
+	if len(name) == 0 || len(name) > 32 {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		if c := name[i]; c <= ' ' || c > '~' || c == '=' || c == ']' || c == '"' {
+			return false
+		}
+	}
+	return true
+}
+
+// escape escapes '"', '\' and ']' of a parameter value.
+func escape(value []byte) []byte {
+	if bytes.IndexAny(value, "\"\\]") < 0 {
+		return value
+	}
+
+	out := make([]byte, 0, len(value)+4)
+	for _, c := range value {
+		if c == '"' || c == '\\' || c == ']' {
+			out = append(out, '\\')
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// unescape reverts escape. A backslash not followed by an escaped character is kept as is.
+func unescape(value []byte) []byte {
+	if bytes.IndexByte(value, '\\') < 0 {
+		return value
+	}
+
+	out := make([]byte, 0, len(value))
+	for i := 0; i < len(value); i++ {
+		if value[i] == '\\' && i+1 < len(value) {
+			if c := value[i+1]; c == '"' || c == '\\' || c == ']' {
+				i++
+			}
+		}
+		out = append(out, value[i])
+	}
+	return out
+}
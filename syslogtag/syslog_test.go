@@ -0,0 +1,135 @@
+package syslogtag
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func equal(t *testing.T, exp, got interface{}) {
+	if !reflect.DeepEqual(exp, got) {
+		t.Fatalf("Not equal:\nexp: %v\ngot: %v", exp, got)
+	}
+}
+
+type event struct {
+	IUT         int    `syslog:"iut"`
+	EventSource string `syslog:"eventSource"`
+	EventID     string `syslog:"eventID,omitempty"`
+	Origin      string
+}
+
+func TestMarshal(t *testing.T) {
+	var tests = []struct {
+		id     string
+		value  any
+		expect string
+		err    error
+	}{
+		{
+			id:     "exampleSDID@32473",
+			value:  event{IUT: 3, EventSource: "Application", EventID: "1011", Origin: "x"},
+			expect: `[exampleSDID@32473 iut="3" eventSource="Application" eventID="1011" Origin="x"]`,
+		},
+		{
+			id:     "ex@1",
+			value:  event{EventSource: `App"li]c\x`},
+			expect: `[ex@1 iut="0" eventSource="App\"li\]c\\x" Origin=""]`,
+		},
+		{
+			id:    "bad id",
+			value: event{},
+			err:   ErrInvalidName,
+		},
+		{
+			id: "ex@1",
+			value: struct {
+				Bad string `syslog:"a=b"`
+			}{},
+			err: ErrInvalidName,
+		},
+	}
+	for _, tt := range tests {
+		b, err := Marshal(tt.id, tt.value)
+		equal(t, true, errors.Is(err, tt.err))
+		if tt.err == nil {
+			equal(t, tt.expect, string(b))
+		}
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	var tests = []struct {
+		data   string
+		id     string
+		expect event
+		err    error
+	}{
+		{
+			data:   `[other@1 iut="1" a="]"][ex@1 iut="3" eventSource="App\"li\]c\\x"]`,
+			id:     "ex@1",
+			expect: event{IUT: 3, EventSource: `App"li]c\x`},
+		},
+		{
+			data: `[other@1 iut="1"]`,
+			id:   "ex@1",
+			err:  ErrElementNotFound,
+		},
+		{
+			data: `[ex@1 iut="3"`,
+			id:   "ex@1",
+			err:  ErrInvalidElement,
+		},
+		{
+			data: `x[ex@1 iut="3"]`,
+			id:   "ex@1",
+			err:  ErrInvalidElement,
+		},
+	}
+	for _, tt := range tests {
+		var got event
+		err := Unmarshal([]byte(tt.data), tt.id, &got)
+		equal(t, true, errors.Is(err, tt.err))
+		equal(t, tt.expect, got)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	var tests = []event{
+		{IUT: 3, EventSource: "Application", EventID: "1011"},
+		{EventSource: `App"li]c\x`, Origin: `\"`},
+	}
+	for _, tt := range tests {
+		b, err := Marshal("ex@1", tt)
+		equal(t, nil, err)
+
+		var got event
+		equal(t, nil, Unmarshal(append([]byte(`[other@1 a="]"]`), b...), "ex@1", &got))
+		equal(t, tt, got)
+	}
+}
+
+func Test_validName(t *testing.T) {
+	var tests = []struct {
+		name   string
+		expect bool
+	}{
+		{name: "exampleSDID@32473", expect: true},
+		{name: "", expect: false},
+		{name: "012345678901234567890123456789012", expect: false},
+		{name: "a b", expect: false},
+		{name: "a=b", expect: false},
+		{name: "a]", expect: false},
+		{name: `a"`, expect: false},
+		{name: "ü", expect: false},
+	}
+	for _, tt := range tests {
+		equal(t, tt.expect, validName(tt.name))
+	}
+}
+
+func Test_unescape(t *testing.T) {
+	equal(t, `a\b`, string(unescape([]byte(`a\b`))))
+	equal(t, `a\`, string(unescape([]byte(`a\`))))
+	equal(t, `"]\`, string(unescape([]byte(`\"\]\\`))))
+}
@@ -0,0 +1,114 @@
+package syslogtag
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/gromey/format-engine"
+)
+
+var (
+	cfg = engine.Config{
+		// WARNING: DO NOT DELETE CONFIGURATIONS BELOW!
+		Marshaller:  reflect.TypeOf((*Marshaller)(nil)).Elem(),
+		Unmarshaler: reflect.TypeOf((*Unmarshaler)(nil)).Elem(),
+	}
+	syslog = engine.New[tag](&engineTag{name: "syslog"}, cfg)
+)
+
+type engineTag struct {
+	name string
+	engine.Default[tag]
+}
+
+type tag struct {
+	name string
+}
+
+// Rename returns the name of the SD-PARAM, the engine passes it to Encode and Decode instead of the field name.
+func (t *tag) Rename() string {
+	return t.name
+}
+
+// Name returns the name of the tag.
+func (e engineTag) Name() string {
+	return e.name
+}
+
+// Skip returns a flag indicating that the field should be ignored.
+func (e engineTag) Skip(tagValue string) bool {
+	return tagValue == "-"
+}
+
+// Parse gets a tagValue string in the form "name[,omitempty]", parses the tagValue into tag *tag,
+// returns a flag indicating that the field is skipped if it's empty.
+func (e engineTag) Parse(tagValue string, tag *tag) (omit bool, err error) {
+	var opts string
+	tag.name, opts, _ = strings.Cut(tagValue, ",")
+	if tag.name != "" && !validName(tag.name) {
+		return false, ErrInvalidName
+	}
+	return opts == "omitempty", nil
+}
+
+// Encode writes the value as a ` name="value"` SD-PARAM.
+func (e engineTag) Encode(fieldName string, tag *tag, in []byte, out engine.Writer) (err error) {
+	if !validName(fieldName) {
+		return fmt.Errorf("%w: %q", ErrInvalidName, fieldName)
+	}
+
+	if err = out.WriteByte(' '); err != nil {
+		return
+	}
+	if _, err = out.WriteString(fieldName); err != nil {
+		return
+	}
+	if _, err = out.WriteString(`="`); err != nil {
+		return
+	}
+	if _, err = out.Write(escape(in)); err != nil {
+		return
+	}
+	return out.WriteByte('"')
+}
+
+// Decode finds the SD-PARAM in the parameters of an SD-ELEMENT and writes its unescaped value.
+func (e engineTag) Decode(fieldName string, tag *tag, in []byte, out engine.Writer) (err error) {
+	name := []byte(fieldName)
+
+	for in = bytes.TrimSpace(in); len(in) != 0; in = bytes.TrimSpace(in) {
+		key, rest, ok := bytes.Cut(in, []byte(`="`))
+		if !ok {
+			return ErrInvalidElement
+		}
+
+		end := valueEnd(rest)
+		if end < 0 {
+			return ErrInvalidElement
+		}
+
+		if bytes.Equal(key, name) {
+			_, err = out.Write(unescape(rest[:end]))
+			return
+		}
+
+		in = rest[end+1:]
+	}
+
+	return
+}
+
+// valueEnd returns the index of the closing quote of a parameter value.
+func valueEnd(value []byte) int {
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,169 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidDate is returned for a date that doesn't exist in the century chosen by Config.YearPivot.
+var ErrInvalidDate = errors.New("invalid date")
+
+// EpochUnit is the unit of an integer timestamp, see TimeFormatter.
+type EpochUnit int
+
+const (
+	// NoEpoch formats the time with the layout.
+	NoEpoch EpochUnit = iota
+	// EpochSeconds is the number of seconds elapsed since January 1, 1970 UTC.
+	EpochSeconds
+	// EpochMillis is the number of milliseconds elapsed since January 1, 1970 UTC.
+	EpochMillis
+	// EpochMicros is the number of microseconds elapsed since January 1, 1970 UTC.
+	EpochMicros
+)
+
+const (
+	// JulianDate is the layout of the YYDDD dates of legacy banking and airline feeds, the two-digit year
+	// followed by the day of the year, e.g. "24060" is February 29, 2024. The century is chosen by Config.YearPivot.
+	JulianDate = "06002"
+	// OrdinalDate is the layout of the YYYYDDD ordinal dates, the year followed by the day of the year.
+	OrdinalDate = "2006002"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// TimeFormatter is the interface implemented by a parsed tag, a *T of the engine Tag, of a time.Time field
+// that is encoded as a formatted time or as an integer timestamp. The field is a single value passed to
+// Tag.Encode and written by Tag.Decode like the values of other types.
+type TimeFormatter interface {
+	// TimeFormat returns the layout of the time, time.RFC3339Nano if it is empty, or the unit of the integer
+	// timestamp, and the location of the decoded time, UTC if it is nil. A layout without a time zone
+	// is parsed in the location, and the time is converted to the location to be formatted if it isn't nil.
+	TimeFormat() (layout string, epoch EpochUnit, loc *time.Location)
+}
+
+// timeFormat is the encoding of a time.Time field, see TimeFormatter.
+type timeFormat struct {
+	layout    string
+	epoch     EpochUnit
+	loc       *time.Location // nil means UTC, the time isn't converted when encoding
+	shortYear bool           // the layout carries a two-digit year, see Config.YearPivot
+	yearDay   bool           // the layout carries the day of the year
+}
+
+// newTimeFormat returns the encoding of a time.Time field declared by the parsed tag.
+func newTimeFormat(f TimeFormatter) *timeFormat {
+	tf := new(timeFormat)
+	tf.layout, tf.epoch, tf.loc = f.TimeFormat()
+	if tf.layout == "" {
+		tf.layout = time.RFC3339Nano
+	}
+	tf.yearDay = strings.Contains(tf.layout, "002") || strings.Contains(tf.layout, "__2")
+	for i := 0; i+2 <= len(tf.layout); i++ {
+		if tf.layout[i:i+2] == "06" && (i < 2 || tf.layout[i-2:i] != "20") {
+			tf.shortYear = true
+		}
+	}
+	return tf
+}
+
+// timeCoders returns the coders of a time.Time field of the type t with the encoding.
+func timeCoders[T any](t reflect.Type, tf *timeFormat) (encoderFunc[T], decoderFunc[T], error) {
+	if t != timeType {
+		return nil, nil, fmt.Errorf("%w: time format of %s", ErrNotSupportType, t)
+	}
+	return timeEncoder[T](tf), timeDecoder[T](tf), nil
+}
+
+func timeEncoder[T any](tf *timeFormat) encoderFunc[T] {
+	return func(s *encodeState[T], v reflect.Value) error {
+		t := v.Interface().(time.Time)
+
+		switch tf.epoch {
+		case EpochSeconds:
+			return s.encodeValue(strconv.AppendInt(s.scratch[:0], t.Unix(), 10))
+		case EpochMillis:
+			return s.encodeValue(strconv.AppendInt(s.scratch[:0], t.UnixMilli(), 10))
+		case EpochMicros:
+			return s.encodeValue(strconv.AppendInt(s.scratch[:0], t.UnixMicro(), 10))
+		}
+		if tf.loc != nil {
+			t = t.In(tf.loc)
+		}
+		return s.encodeValue(t.AppendFormat(s.scratch[:0], tf.layout))
+	}
+}
+
+func timeDecoder[T any](tf *timeFormat) decoderFunc[T] {
+	loc := tf.loc
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	return func(s *decodeState[T], v reflect.Value) error {
+		var (
+			t   time.Time
+			n   int64
+			err error
+		)
+
+		switch {
+		case tf.epoch == NoEpoch:
+			if t, err = time.ParseInLocation(tf.layout, s.String(), loc); err == nil && tf.shortYear {
+				t, err = tf.pivotYear(t, s.yearPivot)
+			}
+		default:
+			if n, err = strconv.ParseInt(s.String(), 10, 64); err != nil {
+				return err
+			}
+			switch tf.epoch {
+			case EpochSeconds:
+				t = time.Unix(n, 0)
+			case EpochMillis:
+				t = time.UnixMilli(n)
+			default:
+				t = time.UnixMicro(n)
+			}
+		}
+		if err != nil {
+			return err
+		}
+
+		v.Set(reflect.ValueOf(t.In(loc)))
+		return nil
+	}
+}
+
+// pivotYear moves the time parsed with a two-digit year, which the time package puts between 1969 and 2068,
+// to the century chosen by the pivot, see Config.YearPivot.
+func (tf *timeFormat) pivotYear(t time.Time, pivot int) (time.Time, error) {
+	year := fullYear(t.Year()%100, pivot)
+	if year == t.Year() {
+		return t, nil
+	}
+
+	moved := time.Date(year, t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	if tf.yearDay {
+		moved = time.Date(year, time.January, t.YearDay(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	}
+	// February 29 and the 366th day exist in some centuries only.
+	if moved.Year() != year || !tf.yearDay && moved.Day() != t.Day() {
+		return time.Time{}, fmt.Errorf("%w: %s in %d", ErrInvalidDate, t.Format(tf.layout), year)
+	}
+	return moved, nil
+}
+
+// fullYear returns the year of the two-digit year, see Config.YearPivot.
+func fullYear(yy, pivot int) int {
+	if pivot == 0 {
+		pivot = 69
+	}
+	if yy < pivot {
+		return 2000 + yy
+	}
+	return 1900 + yy
+}
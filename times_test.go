@@ -0,0 +1,184 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// timeMeta is a parsed tag declaring the encoding of a time.Time field: "s", "ms" or "us" for the timestamps,
+// "local" for a layout in the location of Berlin or the layout itself, see TimeFormatter.
+type timeMeta struct {
+	layout string
+	epoch  EpochUnit
+	loc    *time.Location
+}
+
+func (m *timeMeta) parse(tagValue string) (bool, error) {
+	var err error
+	switch tagValue {
+	case "s":
+		m.epoch = EpochSeconds
+	case "ms":
+		m.epoch = EpochMillis
+	case "us":
+		m.epoch = EpochMicros
+	case "local":
+		m.layout = "2006-01-02 15:04"
+		m.loc, err = time.LoadLocation("Europe/Berlin")
+	default:
+		m.layout = tagValue
+	}
+	return false, err
+}
+
+func (m *timeMeta) TimeFormat() (string, EpochUnit, *time.Location) {
+	return m.layout, m.epoch, m.loc
+}
+
+// newTimeEngine returns an engine of the time fields separating the values with '|', so that the layouts may hold ','.
+func newTimeEngine(configure func(cfg *Config)) Engine {
+	return newEngineOf[timeMeta](func(cfg *Config) {
+		cfg.ValueSeparator = []byte("|")
+		if configure != nil {
+			configure(cfg)
+		}
+	})
+}
+
+func TestTimeFormatter(t *testing.T) {
+	type record struct {
+		A time.Time `test:"s"`
+		B time.Time `test:"ms"`
+		C time.Time `test:"us"`
+		D time.Time `test:"local"`
+		E time.Time `test:""`
+		F time.Time `test:"02.01.2006"`
+	}
+	e := newTimeEngine(nil)
+	at := time.Date(2024, 5, 6, 7, 8, 9, 123456789, time.UTC)
+
+	b, err := e.Marshal(record{A: at, B: at, C: at, D: at, E: at, F: at})
+	equal(t, nil, err)
+	equal(t, "1714979289|1714979289123|1714979289123456|2024-05-06 09:08|2024-05-06T07:08:09.123456789Z|06.05.2024", string(b))
+
+	var got record
+	equal(t, nil, e.Unmarshal(b, &got))
+	equal(t, true, got.A.Equal(at.Truncate(time.Second)))
+	equal(t, true, got.B.Equal(at.Truncate(time.Millisecond)))
+	equal(t, true, got.C.Equal(at.Truncate(time.Microsecond)))
+	equal(t, true, got.D.Equal(at.Truncate(time.Minute)))
+	equal(t, "Europe/Berlin", got.D.Location().String())
+	equal(t, true, got.E.Equal(at))
+	equal(t, time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC), got.F)
+
+	err = e.Unmarshal([]byte("x"), &got)
+	equal(t, true, err != nil)
+
+	var bad struct {
+		A int `test:"s"`
+	}
+	_, err = e.Marshal(bad)
+	equal(t, true, errors.Is(err, ErrNotSupportType))
+}
+
+func TestJulianDate(t *testing.T) {
+	type record struct {
+		A time.Time `test:"06002"`
+		B time.Time `test:"2006002"`
+	}
+	e := newTimeEngine(nil)
+
+	var tests = []struct {
+		data  string
+		value record
+	}{
+		{
+			data: "24060|2024060",
+			value: record{
+				A: time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC),
+				B: time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			data: "99365|1999001",
+			value: record{
+				A: time.Date(1999, time.December, 31, 0, 0, 0, 0, time.UTC),
+				B: time.Date(1999, time.January, 1, 0, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+	for _, tt := range tests {
+		var got record
+		equal(t, nil, e.Unmarshal([]byte(tt.data), &got))
+		equal(t, tt.value, got)
+
+		b, err := e.Marshal(got)
+		equal(t, nil, err)
+		equal(t, tt.data, string(b))
+	}
+
+	// The 366th day exists in leap years only.
+	var got record
+	equal(t, true, e.Unmarshal([]byte("23366|"), &got) != nil)
+	equal(t, true, e.Unmarshal([]byte("|2023366"), &got) != nil)
+}
+
+func TestYearPivot(t *testing.T) {
+	type record struct {
+		A time.Time `test:"06002"`
+		B time.Time `test:"02.01.06"`
+	}
+
+	var tests = []struct {
+		pivot int
+		data  string
+		year  int
+	}{
+		{
+			pivot: 0,
+			data:  "68001|01.01.68",
+			year:  2068,
+		},
+		{
+			pivot: 0,
+			data:  "69001|01.01.69",
+			year:  1969,
+		},
+		{
+			pivot: 30,
+			data:  "29001|01.01.29",
+			year:  2029,
+		},
+		{
+			pivot: 30,
+			data:  "30001|01.01.30",
+			year:  1930,
+		},
+		{
+			pivot: 30,
+			data:  "60001|01.01.60",
+			year:  1960,
+		},
+		{
+			pivot: 99,
+			data:  "98001|01.01.98",
+			year:  2098,
+		},
+	}
+	for _, tt := range tests {
+		e := newTimeEngine(func(cfg *Config) { cfg.YearPivot = tt.pivot })
+
+		var got record
+		equal(t, nil, e.Unmarshal([]byte(tt.data), &got))
+		equal(t, time.Date(tt.year, time.January, 1, 0, 0, 0, 0, time.UTC), got.A)
+		equal(t, got.A, got.B)
+
+		// The years of the layouts with four digits aren't moved.
+		var full struct {
+			A time.Time `test:"2006"`
+		}
+		equal(t, nil, e.Unmarshal([]byte("1960"), &full))
+		equal(t, 1960, full.A.Year())
+	}
+}
@@ -0,0 +1,146 @@
+package engine
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrTransform is returned for a value that its Transform can't convert back.
+var ErrTransform = errors.New("the value can't be transformed")
+
+// Transform converts the encoded value of a field, e.g. compresses a large blob, after it is encoded
+// and converted to the charset of the field and before it is escaped and passed to Tag.Encode,
+// and converts it back after Tag.Decode and before it is decoded. The field is a single value,
+// the lists and the composite values can't be transformed.
+type Transform interface {
+	// Encode appends the value p transformed to dst.
+	Encode(dst, p []byte) ([]byte, error)
+	// Decode appends the transformed value p converted back to dst.
+	Decode(dst, p []byte) ([]byte, error)
+}
+
+// LimitedTransform is the interface implemented by a Transform that bounds the size of the value it converts back,
+// so that a small transformed value can't expand beyond Config.MaxValueSize when decoding untrusted data.
+// RunLength and the zlib transforms implement it.
+type LimitedTransform interface {
+	Transform
+	// DecodeLimit appends the transformed value p converted back to dst like Decode, but fails with ErrLimit
+	// as soon as the value converted back is larger than limit bytes. If limit is 0, the size isn't limited.
+	DecodeLimit(dst, p []byte, limit int) ([]byte, error)
+}
+
+// Transformer is the interface implemented by a parsed tag, a *T of the engine Tag, of a field
+// whose encoded value is transformed, see Transform.
+type Transformer interface {
+	// Transform returns the transform of the value of the field, nil if it isn't transformed.
+	Transform() Transform
+}
+
+var (
+	// RunLength encodes the runs of a byte as the length of the run, from 1 to 255, followed by the byte.
+	RunLength Transform = runLength{}
+	// Zlib compresses the value in the zlib format with the default compression level.
+	Zlib = ZlibLevel(zlib.DefaultCompression)
+)
+
+type runLength struct{}
+
+// Encode encodes the runs of the value, see RunLength.
+func (runLength) Encode(dst, p []byte) ([]byte, error) {
+	for len(p) != 0 {
+		n := 1
+		for n < len(p) && n < 255 && p[n] == p[0] {
+			n++
+		}
+		dst = append(dst, byte(n), p[0])
+		p = p[n:]
+	}
+	return dst, nil
+}
+
+// Decode expands the runs of the value, see RunLength.
+func (rl runLength) Decode(dst, p []byte) ([]byte, error) {
+	return rl.DecodeLimit(dst, p, 0)
+}
+
+// DecodeLimit expands the runs of the value up to limit bytes, see LimitedTransform.
+func (runLength) DecodeLimit(dst, p []byte, limit int) ([]byte, error) {
+	if len(p)%2 != 0 {
+		return dst, fmt.Errorf("%w: run-length of odd length %d", ErrTransform, len(p))
+	}
+	var size int
+	for ; len(p) != 0; p = p[2:] {
+		if p[0] == 0 {
+			return dst, fmt.Errorf("%w: run-length of empty run", ErrTransform)
+		}
+		if size += int(p[0]); limit > 0 && size > limit {
+			return dst, valueLimitError(limit)
+		}
+		for n := 0; n < int(p[0]); n++ {
+			dst = append(dst, p[1])
+		}
+	}
+	return dst, nil
+}
+
+// ZlibLevel returns the Transform compressing the value in the zlib format with the compression level,
+// see compress/zlib for the levels.
+func ZlibLevel(level int) Transform {
+	return zlibLevel(level)
+}
+
+type zlibLevel int
+
+// Encode compresses the value, see Zlib.
+func (l zlibLevel) Encode(dst, p []byte) ([]byte, error) {
+	buf := bytes.NewBuffer(dst)
+	w, err := zlib.NewWriterLevel(buf, int(l))
+	if err != nil {
+		return dst, err
+	}
+	if _, err = w.Write(p); err != nil {
+		return dst, err
+	}
+	if err = w.Close(); err != nil {
+		return dst, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode decompresses the value, see Zlib.
+func (l zlibLevel) Decode(dst, p []byte) ([]byte, error) {
+	return l.DecodeLimit(dst, p, 0)
+}
+
+// DecodeLimit decompresses the value up to limit bytes, see LimitedTransform.
+func (zlibLevel) DecodeLimit(dst, p []byte, limit int) ([]byte, error) {
+	r, err := zlib.NewReader(bytes.NewReader(p))
+	if err != nil {
+		return dst, fmt.Errorf("%w: zlib: %v", ErrTransform, err)
+	}
+	var src io.Reader = r
+	if limit > 0 {
+		// A byte more than the limit is read to tell a value of the limit from a larger one.
+		src = io.LimitReader(r, int64(limit)+1)
+	}
+	buf := bytes.NewBuffer(dst)
+	n, err := buf.ReadFrom(src)
+	if err != nil {
+		return dst, fmt.Errorf("%w: zlib: %v", ErrTransform, err)
+	}
+	if limit > 0 && n > int64(limit) {
+		return dst, valueLimitError(limit)
+	}
+	if err = r.Close(); err != nil {
+		return dst, fmt.Errorf("%w: zlib: %v", ErrTransform, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// valueLimitError returns ErrLimit for a value converted back larger than limit bytes, see LimitedTransform.
+func valueLimitError(limit int) error {
+	return fmt.Errorf("%w: a value of more than %d bytes", ErrLimit, limit)
+}
@@ -0,0 +1,134 @@
+package engine
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"runtime"
+	"testing"
+)
+
+// transformMeta is a parsed tag transforming the value of its field with RunLength if the value of the tag
+// is "rle" or with Zlib if it is "zlib", see Transformer.
+type transformMeta struct {
+	transform Transform
+}
+
+func (m *transformMeta) parse(tagValue string) (bool, error) {
+	switch tagValue {
+	case "rle":
+		m.transform = RunLength
+	case "zlib":
+		m.transform = Zlib
+	}
+	return false, nil
+}
+
+func (m *transformMeta) Transform() Transform {
+	return m.transform
+}
+
+func TestTransformer(t *testing.T) {
+	type record struct {
+		A string `test:"rle"`
+		B []byte `test:"zlib"`
+		C int
+	}
+	// The transformed values hold the separators, they are escaped.
+	e := newEngineOf[transformMeta](func(cfg *Config) { cfg.EscapeChar = '\\' })
+	value := record{A: "aaaab", B: bytes.Repeat([]byte("xy,"), 50), C: 7}
+
+	b, err := e.Marshal(value)
+	equal(t, nil, err)
+	equal(t, true, bytes.HasPrefix(b, []byte("\x04a\x01b,")))
+	equal(t, true, len(b) < len(value.B))
+
+	var got record
+	equal(t, nil, e.Unmarshal(b, &got))
+	equal(t, value, got)
+
+	err = e.Unmarshal([]byte("\x01,x,1"), &got)
+	equal(t, true, errors.Is(err, ErrTransform))
+	err = e.Unmarshal([]byte("\x00a,,1"), &got)
+	equal(t, true, errors.Is(err, ErrTransform))
+
+	var list struct {
+		L []string `test:"rle"`
+	}
+	_, err = e.Marshal(list)
+	equal(t, true, errors.Is(err, ErrNotSupportType))
+}
+
+func TestRunLength(t *testing.T) {
+	long := bytes.Repeat([]byte("a"), 300)
+	var tests = []struct {
+		value   string
+		encoded string
+	}{
+		{value: "", encoded: ""},
+		{value: "abb", encoded: "\x01a\x02b"},
+		{value: string(long), encoded: "\xffa\x2da"},
+	}
+	for _, tt := range tests {
+		p, err := RunLength.Encode([]byte("dst"), []byte(tt.value))
+		equal(t, nil, err)
+		equal(t, "dst"+tt.encoded, string(p))
+
+		p, err = RunLength.Decode(nil, []byte(tt.encoded))
+		equal(t, nil, err)
+		equal(t, tt.value, string(p))
+	}
+}
+
+func TestTransformLimit(t *testing.T) {
+	type record struct {
+		A string `test:"rle"`
+		B []byte `test:"zlib"`
+	}
+	e := newEngineOf[transformMeta](func(cfg *Config) {
+		cfg.EscapeChar = '\\'
+		cfg.MaxValueSize = 1 << 16
+	})
+
+	var got record
+	err := e.Unmarshal(append(bytes.Repeat([]byte("\xffa"), 300), ",x"...), &got)
+	equal(t, true, errors.Is(err, ErrLimit))
+
+	// A small compressed value of 16 MB is rejected without being decompressed.
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	chunk := make([]byte, 1<<20)
+	for i := 0; i < 16; i++ {
+		_, _ = w.Write(chunk)
+	}
+	equal(t, nil, w.Close())
+	b, err := e.Marshal(record{A: "a"})
+	equal(t, nil, err)
+	data := append(b[:bytes.IndexByte(b, ',')+1], escapeTransformed(buf.Bytes())...)
+	equal(t, true, len(data) < 1<<16)
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	err = e.Unmarshal(data, &got)
+	runtime.ReadMemStats(&after)
+	equal(t, true, errors.Is(err, ErrLimit))
+	equal(t, true, after.TotalAlloc-before.TotalAlloc < 4<<20)
+
+	p, err := RunLength.(LimitedTransform).DecodeLimit(nil, []byte("\x02a\x02b"), 4)
+	equal(t, nil, err)
+	equal(t, "aabb", string(p))
+	_, err = RunLength.(LimitedTransform).DecodeLimit(nil, []byte("\x02a\x03b"), 4)
+	equal(t, true, errors.Is(err, ErrLimit))
+}
+
+// escapeTransformed escapes the separators and the escape characters in the transformed value p.
+func escapeTransformed(p []byte) []byte {
+	var dst []byte
+	for _, c := range p {
+		if c == ',' || c == '\\' {
+			dst = append(dst, '\\')
+		}
+		dst = append(dst, c)
+	}
+	return dst
+}
@@ -0,0 +1,169 @@
+package engine
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Valid reports whether the openers and the closers of the data are balanced for the engine e without decoding
+// the data, a lightweight structural check of the data. Escaped bytes are skipped, see Config.EscapeChar.
+// It returns false if e doesn't have the Valid method.
+func Valid(e Engine, data []byte) bool {
+	v, ok := e.(interface{ Valid(data []byte) bool })
+	return ok && v.Valid(data)
+}
+
+// Valid reports whether the data is well-formed, see the function Valid.
+func (e *engine[T]) Valid(data []byte) bool {
+	_, ok := e.load().scan(nil, data, false)
+	return ok
+}
+
+// Compact appends the data to dst without the insignificant whitespace for the engine e, the whitespace
+// around the data and around its openers, closers and separators, and returns the extended buffer.
+// If the data isn't valid, see Valid, dst is returned unchanged with ErrInvalidFormat.
+// It returns ErrUnsupported if e doesn't have the Compact method.
+func Compact(e Engine, dst, data []byte) ([]byte, error) {
+	if c, ok := e.(compacter); ok {
+		return c.Compact(dst, data)
+	}
+	return dst, unsupported(e, "Compact")
+}
+
+// compacter is implemented by the engines compacting the data, see Compact.
+type compacter interface {
+	Compact(dst, data []byte) ([]byte, error)
+}
+
+// Compact appends the data without the insignificant whitespace to dst, see the function Compact.
+func (e *engine[T]) Compact(dst, data []byte) ([]byte, error) {
+	out, ok := e.load().scan(dst, data, true)
+	if !ok {
+		return dst, fmt.Errorf("%s: %w", e.Name(), ErrInvalidFormat)
+	}
+	return out, nil
+}
+
+// pair is the opener and the closer of a struct, a slice, a map or an element.
+type pair struct {
+	opener, closer []byte
+}
+
+// scan checks that the openers and the closers of the data are balanced and that the data doesn't end
+// with the EscapeChar. If compact is set, it appends the data to dst without the whitespace around the openers,
+// the closers and the separators, and around the data itself.
+func (s *settings) scan(dst, data []byte, compact bool) ([]byte, bool) {
+	c := s.config
+
+	var pairs []pair
+	for _, p := range []pair{
+		{c.StructOpener, c.StructCloser},
+		{c.SliceOpener, c.SliceCloser},
+		{c.MapOpener, c.MapCloser},
+		{c.ElementOpener, c.ElementCloser},
+	} {
+		if len(p.opener) != 0 && len(p.closer) != 0 {
+			pairs = append(pairs, p)
+		}
+	}
+	// The longest openers are matched first, e.g. "{{" before "{".
+	sort.SliceStable(pairs, func(i, j int) bool {
+		return len(pairs[i].opener) > len(pairs[j].opener)
+	})
+
+	var separators [][]byte
+	for _, sep := range append([][]byte{c.ElementSeparator, c.KeyValueSeparator, c.EntrySeparator, c.RecordSeparator}, s.separators...) {
+		if len(sep) != 0 {
+			separators = append(separators, sep)
+		}
+	}
+	sort.SliceStable(separators, func(i, j int) bool {
+		return len(separators[i]) > len(separators[j])
+	})
+
+	var (
+		open []pair // the openers waiting for their closers
+		keep = len(dst)
+	)
+	// skipSpace drops the whitespace at the start of the data, but not the separators made of it, e.g. tabs.
+	skipSpace := func() {
+		for compact && len(data) != 0 && strings.IndexByte(whitespace, data[0]) >= 0 {
+			for _, sep := range separators {
+				if bytes.HasPrefix(data, sep) {
+					return
+				}
+			}
+			data = data[1:]
+		}
+	}
+	// token appends the structural bytes, the whitespace around them is dropped.
+	token := func(p []byte) {
+		if compact {
+			dst = append(trimSpaceRight(dst, keep), p...)
+			keep = len(dst)
+		}
+		data = data[len(p):]
+		skipSpace()
+	}
+
+	skipSpace()
+
+scan:
+	for len(data) != 0 {
+		if s.escape != 0 && data[0] == s.escape {
+			if len(data) == 1 {
+				return dst, false
+			}
+			if compact {
+				dst = append(dst, data[:2]...)
+				keep = len(dst)
+			}
+			data = data[2:]
+			continue
+		}
+
+		// A closer that is also an opener, e.g. a quote, closes the innermost pair first.
+		if n := len(open); n != 0 && bytes.HasPrefix(data, open[n-1].closer) {
+			token(open[n-1].closer)
+			open = open[:n-1]
+			continue
+		}
+		for _, p := range pairs {
+			if bytes.HasPrefix(data, p.opener) {
+				token(p.opener)
+				open = append(open, p)
+				continue scan
+			}
+		}
+		for _, p := range pairs {
+			if bytes.HasPrefix(data, p.closer) {
+				return dst, false
+			}
+		}
+		for _, sep := range separators {
+			if bytes.HasPrefix(data, sep) {
+				token(sep)
+				continue scan
+			}
+		}
+
+		if compact {
+			dst = append(dst, data[0])
+		}
+		data = data[1:]
+	}
+
+	if compact {
+		dst = trimSpaceRight(dst, keep)
+	}
+	return dst, len(open) == 0
+}
+
+const whitespace = " \t\r\n"
+
+// trimSpaceRight drops the whitespace at the end of p, but not before the offset keep.
+func trimSpaceRight(p []byte, keep int) []byte {
+	return p[:keep+len(bytes.TrimRight(p[keep:], whitespace))]
+}
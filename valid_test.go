@@ -0,0 +1,70 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValid(t *testing.T) {
+	e := newTestEngine(func(cfg *Config) {
+		cfg.StructOpener, cfg.StructCloser, cfg.UnwrapWhenDecoding = []byte("{"), []byte("}"), true
+		cfg.SliceOpener, cfg.SliceCloser = []byte("["), []byte("]")
+		cfg.EscapeChar = '\\'
+	})
+
+	var tests = []struct {
+		data  string
+		valid bool
+	}{
+		{data: "{a,{1:2},[x~y]}", valid: true},
+		{data: "", valid: true},
+		{data: "{a,\\}}", valid: true},
+		{data: "{a,{1:2}", valid: false},
+		{data: "{a,[x}]", valid: false},
+		{data: "a}", valid: false},
+		{data: "{a\\", valid: false},
+	}
+	for _, tt := range tests {
+		equal(t, tt.valid, Valid(e, []byte(tt.data)))
+	}
+
+	// The data is checked after the middleware.
+	var decoded int
+	equal(t, true, Valid(Wrap(e, upper(&decoded)), []byte("{A,{1:2}}")))
+	equal(t, false, Valid(foreignEngine{e}, []byte("{a}")))
+}
+
+func TestCompact(t *testing.T) {
+	e := newTestEngine(func(cfg *Config) {
+		cfg.StructOpener, cfg.StructCloser, cfg.UnwrapWhenDecoding = []byte("{"), []byte("}"), true
+		cfg.EscapeChar = '\\'
+	})
+	value := nameAndAddress{Qualifier: "BY", Party: party{ID: "1", Agency: 9}, Agent: &party{}, Name: "ACME"}
+
+	indented, err := MarshalIndent(e, value, "", "\t")
+	equal(t, nil, err)
+	b, err := e.Marshal(value)
+	equal(t, nil, err)
+
+	got, err := Compact(e, []byte("dst:"), indented)
+	equal(t, nil, err)
+	equal(t, "dst:"+string(b), string(got))
+
+	// The whitespace inside the values and the escaped one are kept.
+	got, err = Compact(e, nil, []byte(" { a b ,\\ , c } "))
+	equal(t, nil, err)
+	equal(t, "{a b,\\ ,c}", string(got))
+
+	got, err = Compact(e, []byte("dst"), []byte("{a,"))
+	equal(t, true, errors.Is(err, ErrInvalidFormat))
+	equal(t, "dst", string(got))
+
+	var decoded int
+	got, err = Compact(Wrap(e), nil, []byte(" {a} "))
+	equal(t, nil, err)
+	equal(t, "{a}", string(got))
+	_, err = Compact(Wrap(e, upper(&decoded)), nil, []byte(" {A} "))
+	equal(t, true, errors.Is(err, ErrMiddleware))
+	_, err = Compact(foreignEngine{e}, nil, []byte("{a}"))
+	equal(t, true, errors.Is(err, ErrUnsupported))
+}
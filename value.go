@@ -0,0 +1,153 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// EncodeValue encodes the single value v with the rules of the engine e, outside of a struct, and writes
+// the output of Tag.Encode to out. The value is encoded like a top-level value with the options of a field
+// whose tag is parsed into meta, e.g. its name, see Renamer, or the representation of a number,
+// see NumberFormatter. The meta may be nil like the parsed tag of a field without the tag.
+// It returns ErrUnsupported if e isn't an engine of the tag parsed into T returned by New.
+func EncodeValue[T any](e Engine, meta *T, v reflect.Value, out Writer) error {
+	en, ok := implementation[*engine[T]](e)
+	if !ok {
+		return unsupported(e, "EncodeValue")
+	}
+	return en.encodeSingle(meta, v, out)
+}
+
+// DecodeValue decodes the data into the single value v with the rules of the engine e, outside of a struct.
+// The data is passed to Tag.Decode like the value of a field whose tag is parsed into meta, see EncodeValue,
+// and v must be settable, e.g. an element of a slice or a value a pointer points to.
+// It returns ErrUnsupported if e isn't an engine of the tag parsed into T returned by New.
+func DecodeValue[T any](e Engine, meta *T, data []byte, v reflect.Value) error {
+	en, ok := implementation[*engine[T]](e)
+	if !ok {
+		return unsupported(e, "DecodeValue")
+	}
+	return en.decodeSingle(meta, data, v)
+}
+
+// MarshalValue encodes the value v with the engine e like Marshal encodes the value v holds, so that the code
+// operating on reflect.Values needn't convert them to interfaces. The value mustn't be obtained through
+// unexported fields. An engine without the MarshalValue method encodes v.Interface() with Marshal.
+func MarshalValue(e Engine, v reflect.Value) ([]byte, error) {
+	if !v.IsValid() {
+		return nil, fmt.Errorf("%s: %w", NameOf(e), ErrNilInterface)
+	}
+	if !v.CanInterface() {
+		return nil, fmt.Errorf("%s: %w: value of an unexported field of type %s", NameOf(e), ErrNotSupportType, v.Type())
+	}
+	if m, ok := e.(interface {
+		MarshalValue(v reflect.Value) ([]byte, error)
+	}); ok {
+		return m.MarshalValue(v)
+	}
+	return e.Marshal(v.Interface())
+}
+
+// MarshalValue encodes the value v, see the function MarshalValue.
+func (e *engine[T]) MarshalValue(v reflect.Value) ([]byte, error) {
+	s := e.newEncodeState()
+	defer encodeStatePool.Put(s)
+
+	if s.marshalValue(v); s.err != nil {
+		return nil, s.err
+	}
+	return append([]byte(nil), s.Bytes()...), nil
+}
+
+// UnmarshalValue decodes the encoded data with the engine e into the value v, which must be settable,
+// e.g. an element of a slice or a field of a struct reached through a pointer, like Unmarshal decodes it
+// into the value a pointer points to. An engine without the UnmarshalValue method decodes the data with Unmarshal
+// into the value v.Addr() points to.
+func UnmarshalValue(e Engine, data []byte, v reflect.Value) error {
+	if !v.IsValid() {
+		return fmt.Errorf("%s: %w", NameOf(e), ErrNilInterface)
+	}
+	if !v.CanSet() {
+		return fmt.Errorf("%s: %w: %s", NameOf(e), ErrNotSettable, v.Type())
+	}
+	if u, ok := e.(interface {
+		UnmarshalValue(data []byte, v reflect.Value) error
+	}); ok {
+		return u.UnmarshalValue(data, v)
+	}
+	return e.Unmarshal(data, v.Addr().Interface())
+}
+
+// UnmarshalValue decodes the encoded data into the settable value v, see the function UnmarshalValue.
+func (e *engine[T]) UnmarshalValue(data []byte, v reflect.Value) error {
+	return e.unmarshalValue(data, v.Addr(), decodeOptions{})
+}
+
+// single returns the field holding a single value of the type t whose tag is parsed into meta.
+func (e *engine[T]) single(meta *T, t reflect.Type) field[T] {
+	fld := field[T]{typ: t, meta: meta}
+	if meta != nil {
+		e.setOptions(&fld)
+	}
+	e.setCoders(&fld, t)
+	return fld
+}
+
+// encodeSingle encodes the value v with the options of the field whose tag is parsed into meta and writes it to out.
+func (e *engine[T]) encodeSingle(meta *T, v reflect.Value, out Writer) error {
+	if !v.IsValid() {
+		return fmt.Errorf("%s: %w", e.Name(), ErrNilInterface)
+	}
+
+	s := e.newEncodeState()
+	defer encodeStatePool.Put(s)
+
+	s.field = e.single(meta, v.Type())
+	if err := s.field.encoder(s, v); err != nil {
+		s.fail(err)
+		return s.err
+	}
+	_, err := out.Write(s.Bytes())
+	return err
+}
+
+// decodeSingle decodes the data into the value v with the options of the field whose tag is parsed into meta.
+func (e *engine[T]) decodeSingle(meta *T, data []byte, v reflect.Value) error {
+	if !v.IsValid() {
+		return fmt.Errorf("%s: %w", e.Name(), ErrNilInterface)
+	}
+	if !v.CanSet() {
+		return fmt.Errorf("%s: %w: %s", e.Name(), ErrNotSettable, v.Type())
+	}
+
+	s := e.newDecodeState()
+	defer decodeStatePool.Put(s)
+
+	s.data = append([]byte(nil), data...)
+	s.input, s.rebuilt = s.data, nil
+	s.pos = -1
+	s.decodeOptions = decodeOptions{}
+	s.paths = s.logger != nil
+	s.field = e.single(meta, v.Type())
+
+	// A composite value is split by its decoder, like a top-level value.
+	var err error
+	if !s.field.composite {
+		value := s.data
+		if s.split && !s.field.list {
+			value = s.release(value)
+		}
+		err = s.decodeValue(value, s.field.list)
+	}
+	if err == nil {
+		err = s.field.decoder(s, v)
+	}
+	if err != nil {
+		s.fail(err)
+	}
+	if s.errs != nil {
+		return errors.Join(append(s.errs, s.err)...)
+	}
+	return s.err
+}
@@ -0,0 +1,61 @@
+package engine
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestEncodeValue(t *testing.T) {
+	e := newEngineOf[numberMeta](nil)
+
+	var buf bytes.Buffer
+	equal(t, nil, EncodeValue(e, &numberMeta{format: Overpunch, scale: 2}, reflect.ValueOf(-1.25), &buf))
+	equal(t, "12N", buf.String())
+
+	var f float64
+	equal(t, nil, DecodeValue(e, &numberMeta{format: Overpunch, scale: 2}, buf.Bytes(), reflect.ValueOf(&f).Elem()))
+	equal(t, -1.25, f)
+
+	// A composite value is encoded like a top-level value.
+	buf.Reset()
+	equal(t, nil, EncodeValue[numberMeta](e, nil, reflect.ValueOf(party{ID: "1", Agency: 9}), &buf))
+	equal(t, "1,9,", buf.String())
+
+	ps := make([]party, 1)
+	equal(t, nil, DecodeValue[numberMeta](e, nil, buf.Bytes(), reflect.ValueOf(ps).Index(0)))
+	equal(t, party{ID: "1", Agency: 9}, ps[0])
+
+	err := DecodeValue[numberMeta](e, nil, []byte("x"), reflect.ValueOf(f))
+	equal(t, true, errors.Is(err, ErrNotSettable))
+	err = DecodeValue[numberMeta](e, nil, []byte("x"), reflect.ValueOf(&f).Elem())
+	var de *DecodeError
+	equal(t, true, errors.As(err, &de))
+
+	// The engine must be of the tag parsed into the type of meta.
+	err = EncodeValue(e, &testMeta{}, reflect.ValueOf(1), &buf)
+	equal(t, true, errors.Is(err, ErrUnsupported))
+	err = DecodeValue[numberMeta](foreignEngine{e}, nil, nil, reflect.ValueOf(&f).Elem())
+	equal(t, true, errors.Is(err, ErrUnsupported))
+}
+
+func TestMarshalValue(t *testing.T) {
+	e := newTestEngine(nil)
+	values := []party{{ID: "1", Agency: 9}, {}}
+
+	for _, e := range []Engine{e, foreignEngine{e}} {
+		b, err := MarshalValue(e, reflect.ValueOf(values).Index(0))
+		equal(t, nil, err)
+		equal(t, "1,9,", string(b))
+
+		// The value is decoded in place.
+		equal(t, nil, UnmarshalValue(e, []byte("2,8,x"), reflect.ValueOf(values).Index(1)))
+		equal(t, party{ID: "2", Agency: 8, Code: "x"}, values[1])
+
+		err = UnmarshalValue(e, b, reflect.ValueOf(values[0]))
+		equal(t, true, errors.Is(err, ErrNotSettable))
+		_, err = MarshalValue(e, reflect.Value{})
+		equal(t, true, errors.Is(err, ErrNilInterface))
+	}
+}
@@ -0,0 +1,35 @@
+// Code generated by format-engine. DO NOT EDIT.
+
+package vcardtag
+
+import "reflect"
+
+// Marshaller is the interface implemented by types that can marshal themselves into valid VCARDTAG.
+type Marshaller interface {
+	MarshalVCARDTAG() ([]byte, error)
+}
+
+// IsMarshaller attempts to cast the value to VCARDTAG Marshaller interface,
+// if so, returns a marshal function.
+func (e engineTag) IsMarshaller(rv reflect.Value) (func() ([]byte, error), bool) {
+	if i, ok := rv.Interface().(Marshaller); ok {
+		return i.MarshalVCARDTAG, ok
+	}
+
+	return nil, false
+}
+
+// Unmarshaler is the interface implemented by types that can unmarshal VCARDTAG description of themselves.
+type Unmarshaler interface {
+	UnmarshalVCARDTAG([]byte) error
+}
+
+// IsUnmarshaler attempts to cast the value to VCARDTAG Unmarshaler interface,
+// if so, returns an unmarshal function.
+func (e engineTag) IsUnmarshaler(rv reflect.Value) (func([]byte) error, bool) {
+	if i, ok := rv.Interface().(Unmarshaler); ok {
+		return i.UnmarshalVCARDTAG, ok
+	}
+
+	return nil, false
+}
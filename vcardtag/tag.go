@@ -0,0 +1,143 @@
+package vcardtag
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+
+	"github.com/gromey/format-engine"
+)
+
+const (
+	propertyBegin = "BEGIN"
+	propertyEnd   = "END"
+)
+
+var (
+	cfg = engine.Config{
+		// BEGIN opens a component and END closes it, the rest keep the declaration order.
+		FieldLess: func(a, b engine.FieldInfo) bool {
+			return rank(a) < rank(b)
+		},
+		// WARNING: DO NOT DELETE CONFIGURATIONS BELOW!
+		Marshaller:  reflect.TypeOf((*Marshaller)(nil)).Elem(),
+		Unmarshaler: reflect.TypeOf((*Unmarshaler)(nil)).Elem(),
+	}
+	vcard = engine.New[tag](&engineTag{name: "vcard"}, cfg)
+)
+
+type engineTag struct {
+	name string
+	engine.Default[tag]
+}
+
+type tag struct {
+	name   string
+	params string
+}
+
+// Rename returns the name of the property, the engine passes it to Encode and Decode instead of the field name.
+func (t *tag) Rename() string {
+	return t.name
+}
+
+// Name returns the name of the tag.
+func (e engineTag) Name() string {
+	return e.name
+}
+
+// Skip returns a flag indicating that the field should be ignored.
+func (e engineTag) Skip(tagValue string) bool {
+	return tagValue == "-"
+}
+
+// Parse gets a tagValue string in the form "NAME[;PARAM=x...][,omitempty]", parses the tagValue into tag *tag,
+// returns a flag indicating that the field is skipped if it's empty.
+func (e engineTag) Parse(tagValue string, tag *tag) (omit bool, err error) {
+	if strings.HasSuffix(tagValue, ",omitempty") {
+		tagValue, omit = strings.TrimSuffix(tagValue, ",omitempty"), true
+	}
+	name, params, _ := strings.Cut(tagValue, ";")
+	tag.name, tag.params = strings.ToUpper(name), params
+	return
+}
+
+// Encode writes the value as a folded "NAME;PARAM=x:value" content line.
+func (e engineTag) Encode(fieldName string, tag *tag, in []byte, out engine.Writer) (err error) {
+	line := make([]byte, 0, len(in)+32)
+	line = append(line, propertyName(fieldName)...)
+	if tag != nil && tag.params != "" {
+		line = append(append(line, ';'), tag.params...)
+	}
+	line = append(append(line, ':'), escape(in)...)
+
+	if _, err = out.Write(Fold(line)); err != nil {
+		return
+	}
+	_, err = out.Write(crlf)
+	return
+}
+
+// Decode finds the content line of the property in the unfolded data and writes its unescaped value.
+// Property and parameter names are compared case-insensitively.
+func (e engineTag) Decode(fieldName string, tag *tag, in []byte, out engine.Writer) (err error) {
+	name := propertyName(fieldName)
+
+	var params string
+	if tag != nil {
+		params = tag.params
+	}
+
+	for len(in) != 0 {
+		line := in
+		if i := bytes.IndexByte(in, '\n'); i >= 0 {
+			line, in = in[:i], in[i+1:]
+		} else {
+			in = nil
+		}
+
+		lineName, lineParams, value, ok := parseLine(bytes.TrimRight(line, "\r"))
+		if ok && strings.EqualFold(lineName, name) && (params == "" || strings.EqualFold(lineParams, params)) {
+			_, err = out.Write(unescape(value))
+			return
+		}
+	}
+
+	return
+}
+
+// parseLine splits a content line into a name, parameters and a value.
+// Colons inside quoted parameter values don't end the parameters.
+func parseLine(line []byte) (name, params string, value []byte, ok bool) {
+	var quoted bool
+	for i, c := range line {
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case c == ':' && !quoted:
+			name, params, _ = strings.Cut(string(line[:i]), ";")
+			return name, params, line[i+1:], true
+		}
+	}
+	return
+}
+
+func propertyName(fieldName string) string {
+	return strings.ToUpper(fieldName)
+}
+
+// rank places the BEGIN property at the beginning of a component and the END property at the end.
+func rank(fi engine.FieldInfo) int {
+	t, ok := fi.Meta.(*tag)
+	if !ok {
+		return 1
+	}
+	switch t.name {
+	case propertyBegin:
+		return 0
+	case propertyEnd:
+		return 2
+	default:
+		return 1
+	}
+}
@@ -0,0 +1,122 @@
+// Package vcardtag implements encoding and decoding of vCard (RFC 6350) and iCalendar (RFC 5545)
+// content lines of the form "NAME;PARAM=x:value" with 75-octet line folding.
+//
+// Struct fields are bound to properties with tags like `vcard:"FN"` or `vcard:"TEL;TYPE=work,omitempty"`,
+// an untagged field is bound to the property named as the field in upper case.
+// Fields bound to the BEGIN property are encoded first and to the END property last,
+// so a struct can describe a whole component:
+//
+//	type Card struct {
+//		Begin   string `vcard:"BEGIN"` // VCARD
+//		Version string `vcard:"VERSION"`
+//		FN      string
+//		End     string `vcard:"END"` // VCARD
+//	}
+package vcardtag
+
+import (
+	"bytes"
+	"unicode/utf8"
+)
+
+// MaxLineLength is the maximum length of a content line in octets, excluding the line break.
+const MaxLineLength = 75
+
+var crlf = []byte("\r\n")
+
+// Marshal encodes the value v and returns folded content lines.
+func Marshal(v any) ([]byte, error) {
+	return vcard.Marshal(v)
+}
+
+// Unmarshal unfolds the content lines of data and stores the result in the value pointed to by v.
+func Unmarshal(data []byte, v any) error {
+	return vcard.Unmarshal(Unfold(data), v)
+}
+
+// Fold splits a content line into lines of at most MaxLineLength octets,
+// each continuation line starts with a space. Multi-octet characters are never split.
+func Fold(line []byte) []byte {
+	if len(line) <= MaxLineLength {
+		return line
+	}
+
+	out := make([]byte, 0, len(line)+len(line)/MaxLineLength*3)
+	n := 0
+	for len(line) != 0 {
+		_, size := utf8.DecodeRune(line)
+		if n+size > MaxLineLength {
+			out = append(out, "\r\n "...)
+			n = 1
+		}
+		out = append(out, line[:size]...)
+		n += size
+		line = line[size:]
+	}
+	return out
+}
+
+// Unfold joins folded lines, removing every line break followed by a space or a horizontal tab.
+func Unfold(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	for len(data) != 0 {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			return append(out, data...)
+		}
+
+		end := i
+		if end > 0 && data[end-1] == '\r' {
+			end--
+		}
+		if i+1 < len(data) && (data[i+1] == ' ' || data[i+1] == '\t') {
+			out = append(out, data[:end]...)
+			data = data[i+2:]
+			continue
+		}
+
+		out = append(out, data[:i+1]...)
+		data = data[i+1:]
+	}
+	return out
+}
+
+// escape escapes backslashes, commas, semicolons and line breaks of a text value.
+func escape(value []byte) []byte {
+	if bytes.IndexAny(value, "\\,;\n") < 0 {
+		return value
+	}
+
+	out := make([]byte, 0, len(value)+8)
+	for _, c := range value {
+		switch c {
+		case '\\', ',', ';':
+			out = append(out, '\\', c)
+		case '\n':
+			out = append(out, '\\', 'n')
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// unescape reverts escape.
+func unescape(value []byte) []byte {
+	if bytes.IndexByte(value, '\\') < 0 {
+		return value
+	}
+
+	out := make([]byte, 0, len(value))
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c == '\\' && i+1 < len(value) {
+			i++
+			if c = value[i]; c == 'n' || c == 'N' {
+				c = '\n'
+			}
+		}
+		out = append(out, c)
+	}
+	return out
+}
@@ -0,0 +1,135 @@
+package vcardtag
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func equal(t *testing.T, exp, got interface{}) {
+	if !reflect.DeepEqual(exp, got) {
+		t.Fatalf("Not equal:\nexp: %v\ngot: %v", exp, got)
+	}
+}
+
+func TestFold(t *testing.T) {
+	var tests = []struct {
+		line   string
+		expect string
+	}{
+		{
+			line:   "FN:John Doe",
+			expect: "FN:John Doe",
+		},
+		{
+			line:   strings.Repeat("a", 75),
+			expect: strings.Repeat("a", 75),
+		},
+		{
+			line:   strings.Repeat("a", 76),
+			expect: strings.Repeat("a", 75) + "\r\n a",
+		},
+		{
+			line:   strings.Repeat("a", 75+74+1),
+			expect: strings.Repeat("a", 75) + "\r\n " + strings.Repeat("a", 74) + "\r\n a",
+		},
+		{
+			// The two octets of "ü" aren't split.
+			line:   strings.Repeat("a", 74) + "ü",
+			expect: strings.Repeat("a", 74) + "\r\n ü",
+		},
+	}
+	for _, tt := range tests {
+		got := Fold([]byte(tt.line))
+		equal(t, tt.expect, string(got))
+		for _, line := range bytes.Split(got, crlf) {
+			equal(t, true, len(line) <= MaxLineLength)
+		}
+		equal(t, tt.line, string(Unfold(got)))
+	}
+}
+
+func TestUnfold(t *testing.T) {
+	var tests = []struct {
+		data   string
+		expect string
+	}{
+		{
+			data:   "FN:John\r\n  Doe\r\nN:Doe\r\n",
+			expect: "FN:John Doe\r\nN:Doe\r\n",
+		},
+		{
+			data:   "NOTE:a\n\tb\nN:x",
+			expect: "NOTE:ab\nN:x",
+		},
+		{
+			data:   "N:x\r\n",
+			expect: "N:x\r\n",
+		},
+	}
+	for _, tt := range tests {
+		equal(t, tt.expect, string(Unfold([]byte(tt.data))))
+	}
+}
+
+func Test_escape(t *testing.T) {
+	var tests = []struct {
+		value  string
+		expect string
+	}{
+		{
+			value:  "plain",
+			expect: "plain",
+		},
+		{
+			value:  "Doe;John,Jr.\\\nnext",
+			expect: `Doe\;John\,Jr.\\\nnext`,
+		},
+	}
+	for _, tt := range tests {
+		equal(t, tt.expect, string(escape([]byte(tt.value))))
+		equal(t, tt.value, string(unescape([]byte(tt.expect))))
+	}
+	equal(t, "a\nb", string(unescape([]byte(`a\Nb`))))
+}
+
+type card struct {
+	Version string `vcard:"VERSION"`
+	End     string `vcard:"END"`
+	FN      string
+	Note    string `vcard:"NOTE,omitempty"`
+	Tel     string `vcard:"TEL;TYPE=work"`
+	Begin   string `vcard:"BEGIN"`
+}
+
+func TestMarshal(t *testing.T) {
+	b, err := Marshal(card{Begin: "VCARD", Version: "4.0", FN: "John; Doe", Tel: "+1", End: "VCARD"})
+	equal(t, nil, err)
+	equal(t, "BEGIN:VCARD\r\nVERSION:4.0\r\nFN:John\\; Doe\r\nTEL;TYPE=work:+1\r\nEND:VCARD\r\n", string(b))
+}
+
+func TestRoundTrip(t *testing.T) {
+	var tests = []card{
+		{Begin: "VCARD", Version: "4.0", FN: "John Doe", End: "VCARD"},
+		{Begin: "VCARD", Version: "4.0", FN: "Jöhn; Doe", Note: strings.Repeat("ü long, note ", 10), Tel: "+1", End: "VCARD"},
+	}
+	for _, tt := range tests {
+		b, err := Marshal(tt)
+		equal(t, nil, err)
+		for _, line := range bytes.Split(b, crlf) {
+			equal(t, true, len(line) <= MaxLineLength)
+		}
+
+		var got card
+		equal(t, nil, Unmarshal(b, &got))
+		equal(t, tt, got)
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	data := "BEGIN:VCARD\r\nfn:John\r\n  Doe\r\nTEL;TYPE=home:+2\r\ntel;type=WORK:+1\r\nEND:VCARD\r\n"
+	var got card
+	equal(t, nil, Unmarshal([]byte(data), &got))
+	equal(t, card{Begin: "VCARD", FN: "John Doe", Tel: "+1", End: "VCARD"}, got)
+}
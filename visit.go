@@ -0,0 +1,101 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrTagType is returned by VisitFields when the engine isn't created for the parsed tags of the type T.
+var ErrTagType = errors.New("the engine isn't created for the tag type")
+
+// VisitFields walks the fields of the struct v, or the struct it points to, in the order the engine processes them
+// and calls fn with the path of every field, its parsed tag and its value, so that external validation or transform
+// passes reuse the fields and the tags the engine has already parsed instead of parsing the tags again.
+// The path is the names of the fields from the struct v separated by dots, the fields of embedded structs
+// have the same paths as the fields of the struct. The fields of nested structs are visited after the field
+// holding them, nil pointers are not followed. The values are settable if v is a pointer, the parsed tags
+// of the fields without the engine tag are nil.
+// The engine must be created by New with a Tag[T]. Walking stops at the first error returned by fn.
+func VisitFields[T any](e Engine, v any, fn func(path string, meta *T, value reflect.Value) error) error {
+	en, ok := implementation[*engine[T]](e)
+	if !ok {
+		var meta *T
+		return fmt.Errorf("%s: %w: %T", NameOf(e), ErrTagType, meta)
+	}
+
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() || rv.Kind() != reflect.Struct && !reflect.PointerTo(rv.Type()).Implements(describerType) {
+		return fmt.Errorf("%s: %w: %T", NameOf(e), ErrNotSupportType, v)
+	}
+
+	return en.visitFields("", rv, fn)
+}
+
+// visitFields calls fn with the fields of the struct v and of the structs they hold, see VisitFields.
+func (e *engine[T]) visitFields(path string, v reflect.Value, fn func(path string, meta *T, value reflect.Value) error) error {
+	var f structFields[T]
+	if v.Kind() == reflect.Struct {
+		f = e.cachedFields(v.Type())
+	} else {
+		f = e.describedFields(pointerTo(v).Interface().(FieldDescriber))
+	}
+	return f.visit(e, path, v, fn)
+}
+
+func (f structFields[T]) visit(e *engine[T], path string, v reflect.Value, fn func(path string, meta *T, value reflect.Value) error) error {
+	for i := range f {
+		fld := &f[i]
+		if fld.err != nil {
+			return fld.err
+		}
+
+		switch {
+		case fld.extras:
+			continue
+		case fld.embedded != nil:
+			ev := v.Field(fld.index)
+			if ev.Kind() == reflect.Pointer {
+				if ev.IsNil() {
+					continue
+				}
+				ev = ev.Elem()
+			}
+			if err := fld.embedded.visit(e, path, ev, fn); err != nil {
+				return err
+			}
+			continue
+		}
+
+		name := fld.name
+		if path != "" {
+			name = path + "." + name
+		}
+
+		rv := fld.value(v)
+		if err := fn(name, fld.meta, rv); err != nil {
+			return err
+		}
+
+		for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+			rv = rv.Elem()
+		}
+		if rv.Kind() == reflect.Struct && !e.isCustom(rv.Type()) && !e.isCoded(reflect.PointerTo(rv.Type())) {
+			if err := e.visitFields(name, rv, fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// isCoded reports whether the values of the type p points to are encoded or decoded by their own methods.
+func (e *engine[T]) isCoded(p reflect.Type) bool {
+	return p.Implements(e.marshaller) || e.isUnmarshaler(p)
+}
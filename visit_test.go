@@ -0,0 +1,71 @@
+package engine
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestVisitFields(t *testing.T) {
+	type base struct {
+		Kind string
+	}
+	type record struct {
+		base
+		A     string `test:"a"`
+		Party nameAndAddress
+		Extra *party
+	}
+	e := newTestEngine(nil)
+
+	value := record{A: "a", Party: nameAndAddress{Agent: &party{ID: "1"}}}
+	var paths, tags []string
+	err := VisitFields(e, &value, func(path string, meta *testMeta, value reflect.Value) error {
+		paths = append(paths, path)
+		if meta != nil {
+			tags = append(tags, path+"="+meta.value)
+		}
+		if path == "Party.Agent.ID" {
+			value.SetString("2")
+		}
+		return nil
+	})
+	equal(t, nil, err)
+	equal(t, []string{
+		"Kind", "A", "Party", "Party.Qualifier", "Party.Party", "Party.Party.ID", "Party.Party.Agency", "Party.Party.Code",
+		"Party.Agent", "Party.Agent.ID", "Party.Agent.Agency", "Party.Agent.Code", "Party.Name", "Extra",
+	}, paths)
+	equal(t, []string{"A=a"}, tags)
+	equal(t, "2", value.Party.Agent.ID)
+
+	// The walking stops at the first error.
+	errStop := errors.New("stop")
+	paths = nil
+	err = VisitFields(e, value, func(path string, _ *testMeta, _ reflect.Value) error {
+		paths = append(paths, path)
+		if path == "A" {
+			return errStop
+		}
+		return nil
+	})
+	equal(t, true, errors.Is(err, errStop))
+	equal(t, []string{"Kind", "A"}, paths)
+
+	// The engine wrapped by middleware is visited as well.
+	paths = nil
+	err = VisitFields(Wrap(e), party{}, func(path string, _ *testMeta, _ reflect.Value) error {
+		paths = append(paths, path)
+		return nil
+	})
+	equal(t, nil, err)
+	equal(t, []string{"ID", "Agency", "Code"}, paths)
+
+	err = VisitFields[testMeta](e, (*party)(nil), nil)
+	equal(t, nil, err)
+
+	err = VisitFields[nameMeta](e, &value, nil)
+	equal(t, true, errors.Is(err, ErrTagType))
+
+	err = VisitFields[testMeta](e, 5, nil)
+	equal(t, true, errors.Is(err, ErrNotSupportType))
+}